@@ -0,0 +1,339 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package tar
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+var errTooManyLinks = errors.New("archive/tar: too many levels of symbolic links")
+
+// maxSymlinks is the maximum number of symbolic links followed while
+// resolving a single name, matching the Linux limit.
+const maxSymlinks = 40
+
+// An FS provides random access to the files in a tar archive.
+// It implements fs.FS, so an archive can be served with http.FS or
+// parsed with template.ParseFS without extracting it first.
+//
+// The archive headers are read once, by NewFS. Later entries replace
+// earlier entries with the same name, hard links refer to the data of
+// their target, and directories that are implied by the names of other
+// entries but missing from the archive are synthesized.
+//
+// Open follows symbolic links. Links are resolved within the archive:
+// absolute link targets are relative to the root of the archive and
+// ".." elements never leave it.
+type FS struct {
+	r     io.ReaderAt
+	root  *fsEntry
+	index map[string]*fsEntry
+}
+
+// An fsEntry is a single named entry in an FS.
+type fsEntry struct {
+	name     string        // cleaned slash-separated name
+	hdr      *Header       // nil for synthesized directories
+	off      int64         // offset of the entry data in FS.r
+	nb       int64         // number of physical data bytes
+	sp       []sparseEntry // sparse holes, or nil if not sparse
+	isDir    bool
+	children []*fsEntry // only for directories
+}
+
+// NewFS returns an FS reading a tar archive from r,
+// which is assumed to have the given size in bytes.
+// It reads all the headers in the archive before returning,
+// and reports any error encountered while doing so.
+func NewFS(r io.ReaderAt, size int64) (*FS, error) {
+	fsys := &FS{
+		r:     r,
+		root:  &fsEntry{name: ".", isDir: true},
+		index: make(map[string]*fsEntry),
+	}
+	sr := io.NewSectionReader(r, 0, size)
+	tr := NewReader(sr)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if hdr.Typeflag == TypeXGlobalHeader {
+			continue
+		}
+		name := toValidName(hdr.Name)
+		if name == "." {
+			continue
+		}
+		off, err := sr.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return nil, err
+		}
+		e := &fsEntry{
+			name:  name,
+			hdr:   hdr,
+			off:   off,
+			nb:    tr.curr.PhysicalRemaining(),
+			isDir: hdr.FileInfo().IsDir(),
+		}
+		if spr, ok := tr.curr.(*sparseFileReader); ok {
+			e.sp = spr.sp
+		}
+		if hdr.Typeflag == TypeLink {
+			fsys.resolveHardLink(e)
+		}
+		fsys.add(e)
+	}
+	fsys.sort(fsys.root)
+	return fsys, nil
+}
+
+// resolveHardLink points e at the data of the entry it links to.
+// A link to an entry that is not in the archive is left as is;
+// opening it reports fs.ErrNotExist.
+func (fsys *FS) resolveHardLink(e *fsEntry) {
+	target := fsys.index[toValidName(e.hdr.Linkname)]
+	if target == nil || target.hdr == nil || target.isDir {
+		return
+	}
+	// Report the metadata of the target under the name of the link.
+	h := *target.hdr
+	h.Name = e.hdr.Name
+	e.hdr = &h
+	e.off, e.nb, e.sp = target.off, target.nb, target.sp
+}
+
+// add inserts e into the tree, replacing any entry with the same name
+// and synthesizing any missing parent directories.
+func (fsys *FS) add(e *fsEntry) {
+	if old := fsys.index[e.name]; old != nil {
+		children := old.children
+		*old = *e
+		if old.isDir {
+			old.children = children
+		}
+		return
+	}
+	fsys.index[e.name] = e
+	parent := fsys.root
+	if dir := path.Dir(e.name); dir != "." {
+		parent = fsys.index[dir]
+		if parent == nil {
+			parent = &fsEntry{name: dir, isDir: true}
+			fsys.add(parent)
+		}
+	}
+	parent.children = append(parent.children, e)
+}
+
+func (fsys *FS) sort(e *fsEntry) {
+	sort.Slice(e.children, func(i, j int) bool { return e.children[i].name < e.children[j].name })
+	for _, c := range e.children {
+		fsys.sort(c)
+	}
+}
+
+// toValidName coerces name to be a valid name for fs.FS.Open.
+func toValidName(name string) string {
+	p := path.Clean(name)
+	if strings.HasPrefix(p, "/") {
+		p = p[len("/"):]
+	}
+	for strings.HasPrefix(p, "../") {
+		p = p[len("../"):]
+	}
+	if p == "" || p == ".." {
+		return "."
+	}
+	return p
+}
+
+// resolve returns the entry named by name, following symbolic links.
+func (fsys *FS) resolve(name string) (*fsEntry, error) {
+	e := fsys.root
+	rest := name
+	if rest == "." {
+		rest = ""
+	}
+	links := 0
+	for rest != "" {
+		var elem string
+		if i := strings.IndexByte(rest, '/'); i >= 0 {
+			elem, rest = rest[:i], rest[i+1:]
+		} else {
+			elem, rest = rest, ""
+		}
+		if !e.isDir {
+			return nil, fs.ErrNotExist
+		}
+		next := fsys.index[path.Join(e.name, elem)]
+		if next == nil {
+			return nil, fs.ErrNotExist
+		}
+		if next.hdr != nil && next.hdr.Typeflag == TypeSymlink {
+			if links++; links > maxSymlinks {
+				return nil, errTooManyLinks
+			}
+			target := next.hdr.Linkname
+			if !path.IsAbs(target) {
+				target = path.Join(e.name, target)
+			}
+			rest = toValidName(path.Join(target, rest))
+			if rest == "." {
+				rest = ""
+			}
+			e = fsys.root
+			continue
+		}
+		e = next
+	}
+	return e, nil
+}
+
+// Open opens the named file in the tar archive,
+// using the semantics of fs.FS.Open:
+// paths are always slash separated, with no
+// leading / or ../ elements.
+func (fsys *FS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	e, err := fsys.resolve(name)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	if e.isDir {
+		return &fsDir{e: e}, nil
+	}
+	if e.hdr.Typeflag == TypeLink {
+		// A hard link whose target is not in the archive.
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	var ra io.ReaderAt = io.NewSectionReader(fsys.r, e.off, e.nb)
+	size := e.nb
+	if e.sp != nil {
+		ra = &sparseReaderAt{ra, e.sp}
+		size = e.hdr.Size
+	}
+	return &fsFile{e, io.NewSectionReader(ra, 0, size)}, nil
+}
+
+func (e *fsEntry) stat() fs.FileInfo {
+	if e.hdr == nil {
+		return e
+	}
+	return e.hdr.FileInfo()
+}
+
+// Only used for synthesized directories.
+func (e *fsEntry) Name() string       { return path.Base(e.name) }
+func (e *fsEntry) Size() int64        { return 0 }
+func (e *fsEntry) Mode() fs.FileMode  { return fs.ModeDir | 0555 }
+func (e *fsEntry) ModTime() time.Time { return time.Time{} }
+func (e *fsEntry) IsDir() bool        { return true }
+func (e *fsEntry) Sys() interface{}   { return nil }
+
+// An fsFile is an open non-directory entry.
+type fsFile struct {
+	e *fsEntry
+	*io.SectionReader
+}
+
+func (f *fsFile) Stat() (fs.FileInfo, error) { return f.e.stat(), nil }
+func (f *fsFile) Close() error               { return nil }
+
+// An fsDir is an open directory entry.
+type fsDir struct {
+	e      *fsEntry
+	offset int
+}
+
+func (d *fsDir) Stat() (fs.FileInfo, error) { return d.e.stat(), nil }
+func (d *fsDir) Close() error               { return nil }
+
+func (d *fsDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.e.name, Err: errors.New("is a directory")}
+}
+
+func (d *fsDir) ReadDir(count int) ([]fs.DirEntry, error) {
+	n := len(d.e.children) - d.offset
+	if count > 0 && n > count {
+		n = count
+	}
+	if n == 0 {
+		if count <= 0 {
+			return nil, nil
+		}
+		return nil, io.EOF
+	}
+	list := make([]fs.DirEntry, n)
+	for i := range list {
+		list[i] = fs.FileInfoToDirEntry(d.e.children[d.offset+i].stat())
+	}
+	d.offset += n
+	return list, nil
+}
+
+// sparseReaderAt reads the logical contents of a sparse file
+// given the physical data fragments in r and the holes in sp.
+type sparseReaderAt struct {
+	r  io.ReaderAt
+	sp []sparseEntry // Normalized list of sparse holes
+}
+
+func (sr *sparseReaderAt) ReadAt(b []byte, off int64) (n int, err error) {
+	size := sr.sp[len(sr.sp)-1].endOffset()
+	if off >= size {
+		return 0, io.EOF
+	}
+	if int64(len(b)) > size-off {
+		b = b[:size-off]
+		defer func() {
+			if err == nil {
+				err = io.EOF
+			}
+		}()
+	}
+
+	pos := off
+	end := off + int64(len(b))
+	var holes int64 // Total length of the holes before pos
+	for _, h := range sr.sp {
+		if pos >= end {
+			break
+		}
+		if pos < h.Offset { // In a data fragment
+			nf := min(end, h.Offset) - pos
+			nr, err := sr.r.ReadAt(b[n:n+int(nf)], pos-holes)
+			n += nr
+			if nr < int(nf) {
+				if err == io.EOF || err == nil {
+					err = errMissData
+				}
+				return n, err
+			}
+			pos += nf
+		}
+		if pos < h.endOffset() { // In a hole fragment
+			nf := min(end, h.endOffset()) - pos
+			for i := range b[n : n+int(nf)] {
+				b[n+i] = 0
+			}
+			n += int(nf)
+			pos += nf
+		}
+		holes += h.Length
+	}
+	return n, nil
+}
@@ -0,0 +1,225 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package tar
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestFS(t *testing.T) {
+	var b bytes.Buffer
+	tw := NewWriter(&b)
+	modTime := time.Unix(1600000000, 0)
+	entries := []struct {
+		hdr  Header
+		data string
+	}{
+		{Header{Typeflag: TypeDir, Name: "dir/", Mode: 0755}, ""},
+		{Header{Typeflag: TypeReg, Name: "dir/a.txt", Mode: 0644}, "hello"},
+		{Header{Typeflag: TypeReg, Name: "dir/b.txt", Mode: 0600}, "old contents"},
+		{Header{Typeflag: TypeReg, Name: "./implicit/sub/c.txt", Mode: 0644}, "world"},
+		{Header{Typeflag: TypeLink, Name: "dir/hard", Linkname: "dir/a.txt"}, ""},
+		{Header{Typeflag: TypeSymlink, Name: "dir/rel", Linkname: "../implicit/sub/c.txt"}, ""},
+		{Header{Typeflag: TypeSymlink, Name: "dir/abs", Linkname: "/dir/a.txt"}, ""},
+		{Header{Typeflag: TypeSymlink, Name: "escape", Linkname: "../../../dir/a.txt"}, ""},
+		{Header{Typeflag: TypeReg, Name: "dir/b.txt", Mode: 0640}, "new contents"},
+	}
+	for _, e := range entries {
+		hdr := e.hdr
+		hdr.ModTime = modTime
+		hdr.Size = int64(len(e.data))
+		if err := tw.WriteHeader(&hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := io.WriteString(tw, e.data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	fsys, err := NewFS(bytes.NewReader(b.Bytes()), int64(b.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := fstest.TestFS(fsys, "dir/a.txt", "dir/b.txt", "dir/hard", "dir/rel", "dir/abs", "implicit/sub/c.txt", "escape"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		name string
+		want string
+	}{
+		{"dir/a.txt", "hello"},
+		{"dir/b.txt", "new contents"},
+		{"dir/hard", "hello"},
+		{"dir/rel", "world"},
+		{"dir/abs", "hello"},
+		{"escape", "hello"},
+	} {
+		got, err := fs.ReadFile(fsys, tt.name)
+		if err != nil {
+			t.Errorf("ReadFile(%q): %v", tt.name, err)
+			continue
+		}
+		if string(got) != tt.want {
+			t.Errorf("ReadFile(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	fi, err := fs.Stat(fsys, "dir/b.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Mode() != 0640 || !fi.ModTime().Equal(modTime) || fi.Size() != int64(len("new contents")) {
+		t.Errorf("Stat(dir/b.txt) = %v %v %d, want %v %v %d", fi.Mode(), fi.ModTime(), fi.Size(), fs.FileMode(0640), modTime, len("new contents"))
+	}
+	if _, ok := fi.Sys().(*Header); !ok {
+		t.Errorf("Stat(dir/b.txt).Sys() = %T, want *Header", fi.Sys())
+	}
+
+	list, err := fs.ReadDir(fsys, "dir")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, de := range list {
+		if de.Name() == "rel" && de.Type() != fs.ModeSymlink {
+			t.Errorf("ReadDir(dir): rel has type %v, want %v", de.Type(), fs.ModeSymlink)
+		}
+	}
+
+	f, err := fsys.Open("dir/b.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	rs, ok := f.(io.ReadSeeker)
+	if !ok {
+		t.Fatalf("Open(dir/b.txt) = %T, does not implement io.ReadSeeker", f)
+	}
+	if _, err := rs.Seek(4, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := io.ReadAll(rs); err != nil || string(got) != "contents" {
+		t.Errorf("ReadAll after Seek = %q, %v; want %q, nil", got, err, "contents")
+	}
+}
+
+func TestFSLinks(t *testing.T) {
+	var b bytes.Buffer
+	tw := NewWriter(&b)
+	for _, hdr := range []*Header{
+		{Typeflag: TypeDir, Name: "dir/"},
+		{Typeflag: TypeReg, Name: "dir/file"},
+		{Typeflag: TypeSymlink, Name: "link", Linkname: "dir"},
+		{Typeflag: TypeSymlink, Name: "dir/up", Linkname: ".."},
+		{Typeflag: TypeSymlink, Name: "a", Linkname: "b"},
+		{Typeflag: TypeSymlink, Name: "b", Linkname: "a"},
+		{Typeflag: TypeLink, Name: "missing", Linkname: "nowhere"},
+	} {
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	fsys, err := NewFS(bytes.NewReader(b.Bytes()), int64(b.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"link/file", "dir/up/dir/file", "link/up/link/file"} {
+		if _, err := fs.Stat(fsys, name); err != nil {
+			t.Errorf("Stat(%q): %v", name, err)
+		}
+	}
+	if fi, err := fs.Stat(fsys, "link"); err != nil || !fi.IsDir() {
+		t.Errorf("Stat(link) = %v, %v; want directory", fi, err)
+	}
+	if _, err := fsys.Open("a"); !errors.Is(err, errTooManyLinks) {
+		t.Errorf("Open(a) error = %v, want %v", err, errTooManyLinks)
+	}
+	if _, err := fsys.Open("missing"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Open(missing) error = %v, want %v", err, fs.ErrNotExist)
+	}
+}
+
+func TestFSTestdata(t *testing.T) {
+	for _, file := range []string{
+		"testdata/gnu.tar",
+		"testdata/sparse-formats.tar",
+		"testdata/star.tar",
+		"testdata/v7.tar",
+		"testdata/pax.tar",
+		"testdata/pax-records.tar",
+		"testdata/ustar.tar",
+		"testdata/hardlink.tar",
+		"testdata/file-and-dir.tar",
+		"testdata/trailing-slash.tar",
+		"testdata/gnu-nil-sparse-data.tar",
+		"testdata/gnu-nil-sparse-hole.tar",
+		"testdata/pax-nil-sparse-data.tar",
+		"testdata/pax-nil-sparse-hole.tar",
+	} {
+		t.Run(path.Base(file), func(t *testing.T) {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Read the archive sequentially to learn the expected contents.
+			want := make(map[string][]byte)
+			var names []string
+			tr := NewReader(bytes.NewReader(data))
+			for {
+				hdr, err := tr.Next()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				name := toValidName(hdr.Name)
+				names = append(names, name)
+				if hdr.Typeflag != TypeReg && hdr.Typeflag != TypeGNUSparse {
+					delete(want, name)
+					continue
+				}
+				b, err := io.ReadAll(tr)
+				if err != nil {
+					t.Fatal(err)
+				}
+				want[name] = b
+			}
+
+			fsys, err := NewFS(bytes.NewReader(data), int64(len(data)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := fstest.TestFS(fsys, names...); err != nil {
+				t.Fatal(err)
+			}
+			for name, b := range want {
+				got, err := fs.ReadFile(fsys, name)
+				if err != nil {
+					t.Errorf("ReadFile(%q): %v", name, err)
+					continue
+				}
+				if !bytes.Equal(got, b) {
+					t.Errorf("ReadFile(%q) does not match sequential read", name)
+				}
+			}
+		})
+	}
+}
@@ -457,6 +457,7 @@ var passes = [...]pass{
 	{name: "branchelim", fn: branchelim},
 	{name: "late fuse", fn: fuseLate},
 	{name: "dse", fn: dse},
+	{name: "licm", fn: licm},                                 // hoist loop-invariant values out of loops
 	{name: "writebarrier", fn: writebarrier, required: true}, // expand write barrier ops
 	{name: "insert resched checks", fn: insertLoopReschedChecks,
 		disabled: !buildcfg.Experiment.PreemptibleLoops}, // insert resched checks in loops.
@@ -520,6 +521,12 @@ var passOrder = [...]constraint{
 	{"generic cse", "tighten"},
 	// checkbce needs the values removed
 	{"generic deadcode", "check bce"},
+	// licm needs loads to be decomposed and calls to be expanded.
+	{"decompose builtin", "licm"},
+	{"expand calls", "licm"},
+	// licm only hoists loads out of loops that don't call functions,
+	// and so must run before write barriers are expanded into calls.
+	{"licm", "writebarrier"},
 	// don't run optimization pass until we've decomposed builtin objects
 	{"decompose builtin", "late opt"},
 	// decompose builtin is the last pass that may introduce new float ops, so run softfloat after it
@@ -0,0 +1,495 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ssa
+
+import "cmd/compile/internal/types"
+
+// licm performs loop-invariant code motion: it moves values whose
+// arguments are all defined outside of a loop to the loop's preheader,
+// the block through which the loop is entered, so that they are
+// computed once instead of on every iteration.
+//
+// Values that can neither fault nor have side effects, such as
+// arithmetic and comparisons, are hoisted from anywhere in the loop;
+// computing them when the loop body does not run is harmless.
+// Values that may fault are hoisted only from blocks that run whenever
+// the loop is entered, so that hoisting them cannot introduce a panic.
+// These are nil checks, loads, divisions, and pointer arithmetic that
+// may produce an invalid pointer. Loads through a field of a pointer
+// that is known to be non-nil before the loop cannot fault and are
+// hoisted from anywhere as well.
+//
+// Loads are only invariant if the memory they read is not changed by
+// the loop. That is the case if the loop does not modify memory at
+// all, or if it only stores to memory that is provably disjoint from
+// the loaded memory and makes no calls.
+//
+// A loop like
+//
+//   for i := 0; i < n; i++ {
+//     body
+//   }
+//
+// checks its condition in the header, so only the header is known to
+// run when the loop is entered. To hoist nil checks and loads out of
+// the first block of the body, licm rotates such loops by evaluating
+// the condition for the first iteration in the preheader:
+//
+//   if 0 < n {
+//     for i := 0; i < n; i++ {
+//       body
+//     }
+//   }
+//
+// after which the first block of the body runs whenever the inner loop
+// is entered. Loops are only rotated when that lets licm hoist something.
+func licm(f *Func) {
+	ln := f.loopnest()
+	if ln.hasIrreducible || len(ln.loops) == 0 {
+		return
+	}
+
+	// Nil checks are moved but never created,
+	// so they can be indexed by pointer once.
+	nilChecks := map[ID][]*Value{}
+	for _, b := range f.Blocks {
+		for _, v := range b.Values {
+			if v.Op == OpNilCheck {
+				nilChecks[v.Args[0].ID] = append(nilChecks[v.Args[0].ID], v)
+			}
+		}
+	}
+
+	done := map[ID]bool{}    // headers of loops that have been processed
+	rotated := map[ID]bool{} // headers of loops that have been rotated
+
+	// Process inner loops first, so that values they hoist into
+	// an outer loop can be hoisted out of that loop in turn.
+	// The CFG changes when a preheader is created or a loop is
+	// rotated. The loop nest is then recomputed and the loop is
+	// processed again.
+	for {
+		ln := f.loopnest()
+		ln.calculateDepths()
+		var l *loop
+		for _, c := range ln.loops {
+			if !done[c.header.ID] && (l == nil || c.depth > l.depth) {
+				l = c
+			}
+		}
+		if l == nil {
+			return
+		}
+		s := &licmState{
+			f:         f,
+			ln:        ln,
+			l:         l,
+			sdom:      f.Sdom(),
+			nilChecks: nilChecks,
+			rotated:   rotated[l.header.ID],
+		}
+		if !s.run() {
+			done[l.header.ID] = true
+		} else if s.rotatedNow {
+			rotated[l.header.ID] = true
+		}
+	}
+}
+
+// licmState holds the state of licm for a single loop.
+type licmState struct {
+	f         *Func
+	ln        *loopnest
+	l         *loop
+	sdom      SparseTree
+	nilChecks map[ID][]*Value
+
+	header    *Block
+	preheader *Block
+	entry     int    // index of the preheader in header.Preds
+	mem       *Value // memory phi of the header, or nil
+	body      *Block // first block of the body, if the header exits the loop and is its only predecessor
+	exit      *Block // loop exit taken by the header
+
+	rotated    bool // the loop was rotated, so body runs whenever the loop is entered
+	rotatedNow bool // the loop was rotated by this run
+
+	blocks   []*Block // blocks of the loop, in reverse postorder
+	memOps   []*Value // values in the loop that modify memory
+	memOpsOK bool     // memOps has been computed and holds only stores
+	memDone  bool     // memOps and memOpsOK have been computed
+}
+
+// run hoists the invariant values of the loop. It reports whether it
+// changed the CFG, in which case the loop must be processed again.
+func (s *licmState) run() bool {
+	f := s.f
+	h := s.l.header
+	s.header = h
+
+	// Find the preheader, creating it if the loop
+	// is entered from a block with other successors.
+	s.entry = -1
+	for i, e := range h.Preds {
+		if s.inLoop(e.b) {
+			continue
+		}
+		if s.entry >= 0 {
+			return false // more than one entry
+		}
+		s.entry = i
+	}
+	if s.entry < 0 {
+		return false
+	}
+	p := h.Preds[s.entry].b
+	if p.Kind != BlockPlain {
+		d := f.NewBlock(BlockPlain)
+		d.Pos = p.Pos
+		pi := h.Preds[s.entry].i
+		p.Succs[pi] = Edge{d, 0}
+		h.Preds[s.entry] = Edge{d, 0}
+		d.Preds = append(d.Preds, Edge{p, pi})
+		d.Succs = append(d.Succs, Edge{h, s.entry})
+		f.invalidateCFG()
+		return true
+	}
+	s.preheader = p
+
+	for _, v := range h.Values {
+		if v.Op == OpPhi && v.Type.IsMemory() {
+			s.mem = v
+		}
+	}
+	if h.Kind == BlockIf {
+		for i, e := range h.Succs {
+			if !s.inLoop(e.b) && s.inLoop(h.Succs[1-i].b) {
+				s.exit, s.body = e.b, h.Succs[1-i].b
+			}
+		}
+		if s.body != nil && len(s.body.Preds) != 1 {
+			s.body = nil
+		}
+	}
+
+	po := f.postorder()
+	for i := len(po) - 1; i >= 0; i-- {
+		if s.inLoop(po[i]) {
+			s.blocks = append(s.blocks, po[i])
+		}
+	}
+
+	for changed := true; changed; {
+		changed = false
+		for _, b := range s.blocks {
+			guaranteed := b == h || s.rotated && b == s.body
+			for i := 0; i < len(b.Values); i++ {
+				v := b.Values[i]
+				if !s.hoistable(v, guaranteed) {
+					continue
+				}
+				s.hoist(v, b, i)
+				i--
+				changed = true
+			}
+		}
+	}
+
+	if s.rotated || s.body == nil {
+		return false
+	}
+	for _, v := range s.body.Values {
+		if s.hoistable(v, true) {
+			// Rotating the loop lets v be hoisted.
+			s.rotatedNow = s.rotate()
+			return s.rotatedNow
+		}
+	}
+	return false
+}
+
+// inLoop reports whether b is in the loop or in a loop nested within it.
+func (s *licmState) inLoop(b *Block) bool {
+	l := s.ln.b2l[b.ID]
+	return l != nil && l.isWithinOrEq(s.l)
+}
+
+// hoist moves v, which is b.Values[i], to the preheader.
+func (s *licmState) hoist(v *Value, b *Block, i int) {
+	if s.f.pass.debug > 0 {
+		s.f.Warnl(v.Pos, "Hoisted %s", v.Op)
+	}
+	if m := v.MemoryArg(); m != nil && m == s.mem {
+		// The memory at the start of the first iteration.
+		v.SetArg(len(v.Args)-1, m.Args[s.entry])
+	}
+	last := len(b.Values) - 1
+	b.Values[i] = b.Values[last]
+	b.Values[last] = nil
+	b.Values = b.Values[:last]
+	v.Block = s.preheader
+	s.preheader.Values = append(s.preheader.Values, v)
+}
+
+// hoistable reports whether v is invariant in the loop and can be
+// hoisted to the preheader. If guaranteed is set, v's block runs
+// whenever the loop is entered, right after the preheader.
+func (s *licmState) hoistable(v *Value, guaranteed bool) bool {
+	if len(v.Args) == 0 {
+		// Constants and the like are cheaper to rematerialize.
+		return false
+	}
+	for _, a := range v.Args {
+		if !a.Type.IsMemory() && s.inLoop(a.Block) {
+			return false
+		}
+	}
+	switch v.Op {
+	case OpPhi, OpSelect0, OpSelect1, OpSelectN, OpSelectNAddr:
+		return false
+	case OpNilCheck:
+		return guaranteed && s.invariantMem(v.Args[1], nil, 0)
+	case OpLoad:
+		if !s.invariantMem(v.Args[1], v.Args[0], v.Type.Size()) {
+			return false
+		}
+		return guaranteed || s.safeAddr(v.Args[0], v.Type.Size())
+	case OpOffPtr:
+		// A pointer to a field of a nil pointer is invalid.
+		return guaranteed || s.safeAddr(v, 0)
+	case OpAddPtr, OpPtrIndex,
+		OpDiv8, OpDiv8u, OpDiv16, OpDiv16u, OpDiv32, OpDiv32u, OpDiv64, OpDiv64u,
+		OpMod8, OpMod8u, OpMod16, OpMod16u, OpMod32, OpMod32u, OpMod64, OpMod64u:
+		// Pointer arithmetic is guarded by bounds checks, and so is
+		// integer division by checks for zero divisors.
+		return guaranteed
+	}
+	op := &opcodeTable[v.Op]
+	if v.MemoryArg() != nil || v.Type.IsMemory() || v.Type.IsTuple() ||
+		op.hasSideEffects || op.call || op.nilCheck {
+		return false
+	}
+	return true
+}
+
+// invariantMem reports whether the memory state m, as read by a value
+// in the loop, is the same in all iterations. If ptr is not nil, the
+// value reads size bytes at ptr, and the loop may store elsewhere.
+func (s *licmState) invariantMem(m, ptr *Value, size int64) bool {
+	if !s.inLoop(m.Block) {
+		return true
+	}
+	if m != s.mem {
+		// Memory was modified earlier in the iteration.
+		return false
+	}
+	if ptr == nil {
+		// Nil checks don't read memory, they are only ordered by it.
+		return true
+	}
+	if !s.memDone {
+		s.memDone = true
+		s.memOpsOK = true
+		for _, b := range s.blocks {
+			for _, v := range b.Values {
+				if !v.Type.IsMemory() && !(v.Type.IsTuple() && v.Type.FieldType(1).IsMemory()) {
+					continue
+				}
+				switch v.Op {
+				case OpPhi, OpVarDef, OpVarKill, OpVarLive, OpKeepAlive:
+				case OpStore, OpZero:
+					s.memOps = append(s.memOps, v)
+				default:
+					s.memOpsOK = false
+				}
+			}
+		}
+	}
+	if !s.memOpsOK {
+		return false
+	}
+	for _, v := range s.memOps {
+		n := v.AuxInt
+		if v.Op == OpStore {
+			n = v.Aux.(*types.Type).Size()
+		}
+		if !disjoint(ptr, size, v.Args[0], n) {
+			return false
+		}
+	}
+	return true
+}
+
+// safeAddr reports whether ptr, and the size bytes at ptr, lie within
+// an object that is known to exist before the loop is entered,
+// so that ptr can be computed and loaded from there.
+func (s *licmState) safeAddr(ptr *Value, size int64) bool {
+	base, off := ptr, int64(0)
+	for base.Op == OpOffPtr {
+		off += base.AuxInt
+		base = base.Args[0]
+	}
+	if !base.Type.IsPtr() {
+		return false
+	}
+	if size == 0 {
+		size = 1 // no pointers past the end
+	}
+	if off < 0 || off+size > base.Type.Elem().Size() {
+		return false
+	}
+	switch base.Op {
+	case OpAddr, OpLocalAddr:
+		return true
+	}
+	for _, c := range s.nilChecks[base.ID] {
+		if s.sdom.IsAncestorEq(c.Block, s.preheader) {
+			return true
+		}
+	}
+	return false
+}
+
+// rotate rotates the loop, so that its condition is first evaluated in
+// the preheader, which then jumps to the exit or to a new preheader.
+// It reports whether the loop was rotated.
+func (s *licmState) rotate() bool {
+	f := s.f
+	h, p, exit := s.header, s.preheader, s.exit
+	if len(exit.Preds) != 1 {
+		return false
+	}
+	for _, v := range exit.Values {
+		if v.Op == OpPhi {
+			return false
+		}
+	}
+	// The values of the header, which are computed for the first
+	// iteration in the preheader, must not have side effects,
+	// as the preheader skips the header if the loop does not run.
+	for _, v := range h.Values {
+		if v.Op == OpPhi {
+			continue
+		}
+		op := &opcodeTable[v.Op]
+		if v.MemoryArg() != nil || v.Type.IsMemory() || v.Type.IsTuple() ||
+			op.hasSideEffects || op.call || op.nilCheck {
+			return false
+		}
+	}
+
+	// The exit no longer follows the header only. Values of the
+	// header that are used after the exit must be merged there
+	// with their values for the first iteration. Other uses outside
+	// of the loop would not be dominated by their definitions.
+	type use struct {
+		v *Value // user, or nil for the control of b
+		b *Block
+		i int
+	}
+	var uses []use
+	for _, b := range f.Blocks {
+		if s.inLoop(b) {
+			continue
+		}
+		for _, v := range b.Values {
+			for i, a := range v.Args {
+				if a.Block != h {
+					continue
+				}
+				u := b
+				if v.Op == OpPhi {
+					u = b.Preds[i].b
+					if s.inLoop(u) {
+						continue
+					}
+				}
+				if !s.sdom.IsAncestorEq(exit, u) {
+					return false
+				}
+				uses = append(uses, use{v, b, i})
+			}
+		}
+		for i, c := range b.ControlValues() {
+			if c.Block != h {
+				continue
+			}
+			if !s.sdom.IsAncestorEq(exit, b) {
+				return false
+			}
+			uses = append(uses, use{nil, b, i})
+		}
+	}
+
+	if f.pass.debug > 0 {
+		f.Warnl(h.Pos, "Rotated loop")
+	}
+
+	// Compute the header's values for the first iteration in p.
+	first := map[ID]*Value{}
+	var firstValue func(v *Value) *Value
+	firstValue = func(v *Value) *Value {
+		if v.Block != h {
+			return v
+		}
+		if v.Op == OpPhi {
+			return v.Args[s.entry]
+		}
+		c := first[v.ID]
+		if c == nil {
+			c = v.copyInto(p)
+			for i, a := range v.Args {
+				c.SetArg(i, firstValue(a))
+			}
+			first[v.ID] = c
+		}
+		return c
+	}
+	cond := firstValue(h.Controls[0])
+
+	// p -> p2 -> h
+	p2 := f.NewBlock(BlockPlain)
+	p2.Pos = p.Pos
+	p2.Succs = append(p2.Succs, Edge{h, s.entry})
+	h.Preds[s.entry] = Edge{p2, 0}
+
+	// p branches to p2 or the exit, in the order of h's successors.
+	bi := 0
+	if h.Succs[0].b == exit {
+		bi = 1
+	}
+	var succs [2]Edge
+	succs[bi] = Edge{p2, 0}
+	succs[1-bi] = Edge{exit, len(exit.Preds)}
+	p2.Preds = append(p2.Preds, Edge{p, bi})
+	exit.Preds = append(exit.Preds, Edge{p, 1 - bi})
+	p.Succs = append(p.Succs[:0], succs[0], succs[1])
+	p.Kind = BlockIf
+	p.SetControl(cond)
+	p.Likely = h.Likely
+	p.Pos = h.Pos
+	f.invalidateCFG()
+
+	phis := map[ID]*Value{}
+	for _, u := range uses {
+		var a *Value
+		if u.v == nil {
+			a = u.b.Controls[u.i]
+		} else {
+			a = u.v.Args[u.i]
+		}
+		phi := phis[a.ID]
+		if phi == nil {
+			phi = exit.NewValue2(a.Pos.WithNotStmt(), OpPhi, a.Type, a, firstValue(a))
+			phis[a.ID] = phi
+		}
+		if u.v == nil {
+			u.b.ReplaceControl(u.i, phi)
+		} else {
+			u.v.SetArg(u.i, phi)
+		}
+	}
+	return true
+}
@@ -0,0 +1,147 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ssa
+
+import (
+	"cmd/compile/internal/types"
+	"testing"
+)
+
+// TestLICMRotate checks that licm hoists invariant arithmetic out of a
+// loop, and that it rotates the loop to hoist a nil check and a load
+// out of its body.
+func TestLICMRotate(t *testing.T) {
+	c := testConfig(t)
+	i64 := c.config.Types.Int64
+	fun := c.Fun("entry",
+		Bloc("entry",
+			Valu("mem", OpInitMem, types.TypeMem, 0, nil),
+			Valu("sb", OpSB, c.config.Types.Uintptr, 0, nil),
+			Valu("zero", OpConst64, i64, 0, nil),
+			Valu("one", OpConst64, i64, 1, nil),
+			Valu("n", OpLoad, i64, 0, nil, "sb", "mem"),
+			Valu("p", OpLoad, c.config.Types.BytePtr, 0, nil, "sb", "mem"),
+			Goto("header")),
+		Bloc("header",
+			Valu("i", OpPhi, i64, 0, nil, "zero", "inc"),
+			Valu("cmp", OpLess64, c.config.Types.Bool, 0, nil, "i", "n"),
+			If("cmp", "body", "exit")),
+		Bloc("body",
+			Valu("check", OpNilCheck, types.TypeVoid, 0, nil, "p", "mem"),
+			Valu("x", OpLoad, c.config.Types.UInt8, 0, nil, "p", "mem"),
+			Valu("step", OpAdd64, i64, 0, nil, "n", "one"),
+			Valu("inc", OpAdd64, i64, 0, nil, "i", "step"),
+			Goto("header")),
+		Bloc("exit",
+			Valu("store", OpStore, types.TypeMem, 0, i64, "sb", "i", "mem"),
+			Exit("store")))
+
+	CheckFunc(fun.f)
+	licm(fun.f)
+	CheckFunc(fun.f)
+
+	entry, header := fun.blocks["entry"], fun.blocks["header"]
+	if entry.Kind != BlockIf {
+		t.Fatalf("loop was not rotated:\n%s", fun.f)
+	}
+	if b := fun.values["step"].Block; b == header || b == fun.blocks["body"] {
+		t.Errorf("step was not hoisted")
+	}
+	for _, name := range []string{"check", "x"} {
+		if b := fun.values[name].Block; b != header.Preds[0].b {
+			t.Errorf("%s is in %s, want the preheader %s", name, b, header.Preds[0].b)
+		}
+	}
+	if a := fun.values["store"].Args[1]; a.Op != OpPhi || a.Block != fun.blocks["exit"] {
+		t.Errorf("store of i after rotated loop uses %s, want phi in exit", a.LongString())
+	}
+}
+
+// TestLICMStore checks that licm hoists a load only if the loop
+// provably does not store to the loaded memory.
+func TestLICMStore(t *testing.T) {
+	c := testConfig(t)
+	i64 := c.config.Types.Int64
+	i64ptr := c.config.Types.Int64.PtrTo()
+	fun := c.Fun("entry",
+		Bloc("entry",
+			Valu("mem", OpInitMem, types.TypeMem, 0, nil),
+			Valu("sb", OpSB, c.config.Types.Uintptr, 0, nil),
+			Valu("zero", OpConst64, i64, 0, nil),
+			Valu("p", OpLoad, i64ptr, 0, nil, "sb", "mem"),
+			Valu("q", OpLoad, i64ptr, 0, nil, "sb", "mem"),
+			Valu("p8", OpOffPtr, i64ptr, 8, nil, "p"),
+			Valu("p16", OpOffPtr, i64ptr, 16, nil, "p"),
+			Goto("header")),
+		Bloc("header",
+			Valu("m", OpPhi, types.TypeMem, 0, nil, "mem", "store2"),
+			Valu("x", OpLoad, i64, 0, nil, "p", "m"),
+			Valu("y", OpLoad, i64, 0, nil, "q", "m"),
+			Valu("cmp", OpLess64, c.config.Types.Bool, 0, nil, "x", "y"),
+			If("cmp", "body", "exit")),
+		Bloc("body",
+			Valu("store1", OpStore, types.TypeMem, 0, i64, "p8", "zero", "m"),
+			Valu("store2", OpStore, types.TypeMem, 0, i64, "p16", "x", "store1"),
+			Goto("header")),
+		Bloc("exit",
+			Exit("m")))
+
+	CheckFunc(fun.f)
+	licm(fun.f)
+	CheckFunc(fun.f)
+
+	header := fun.blocks["header"]
+	if b := fun.values["x"].Block; b == header {
+		t.Errorf("load from p was not hoisted")
+	} else if m := fun.values["x"].Args[1]; m != fun.values["mem"] {
+		t.Errorf("hoisted load from p reads %s, want the memory before the loop", m)
+	}
+	if b := fun.values["y"].Block; b != header {
+		t.Errorf("load from q, which may alias the stores, was hoisted to %s", b)
+	}
+}
+
+// TestLICMNoSpeculation checks that licm does not hoist a nil check
+// that only runs on some iterations.
+func TestLICMNoSpeculation(t *testing.T) {
+	c := testConfig(t)
+	i64 := c.config.Types.Int64
+	fun := c.Fun("entry",
+		Bloc("entry",
+			Valu("mem", OpInitMem, types.TypeMem, 0, nil),
+			Valu("sb", OpSB, c.config.Types.Uintptr, 0, nil),
+			Valu("zero", OpConst64, i64, 0, nil),
+			Valu("one", OpConst64, i64, 1, nil),
+			Valu("n", OpLoad, i64, 0, nil, "sb", "mem"),
+			Valu("p", OpLoad, c.config.Types.BytePtr, 0, nil, "sb", "mem"),
+			Goto("header")),
+		Bloc("header",
+			Valu("i", OpPhi, i64, 0, nil, "zero", "inc"),
+			Valu("cmp", OpLess64, c.config.Types.Bool, 0, nil, "i", "n"),
+			If("cmp", "body", "exit")),
+		Bloc("body",
+			Valu("odd", OpAnd64, i64, 0, nil, "i", "one"),
+			Valu("isodd", OpNeq64, c.config.Types.Bool, 0, nil, "odd", "zero"),
+			If("isodd", "then", "latch")),
+		Bloc("then",
+			Valu("check", OpNilCheck, types.TypeVoid, 0, nil, "p", "mem"),
+			Goto("latch")),
+		Bloc("latch",
+			Valu("inc", OpAdd64, i64, 0, nil, "i", "one"),
+			Goto("header")),
+		Bloc("exit",
+			Exit("mem")))
+
+	CheckFunc(fun.f)
+	licm(fun.f)
+	CheckFunc(fun.f)
+
+	if b := fun.values["check"].Block; b != fun.blocks["then"] {
+		t.Errorf("conditional nil check was hoisted to %s", b)
+	}
+	if k := fun.blocks["entry"].Kind; k != BlockPlain {
+		t.Errorf("loop was rotated to no benefit")
+	}
+}
@@ -34,6 +34,10 @@
 // The -all flag causes doc to print all documentation for the package and
 // all its visible symbols. The argument must identify a package.
 //
+// The -http flag causes doc to serve HTML documentation for the packages
+// of the standard library, the current module and its dependencies on a
+// local web server and to open a browser at the page for the argument, if any.
+//
 // For complete documentation, run "go help doc".
 package main
 
@@ -58,6 +62,7 @@ var (
 	showCmd    bool // -cmd flag
 	showSrc    bool // -src flag
 	short      bool // -short flag
+	serve      bool // -http flag
 )
 
 // usage is a replacement usage function for the flags package.
@@ -97,7 +102,11 @@ func do(writer io.Writer, flagSet *flag.FlagSet, args []string) (err error) {
 	flagSet.BoolVar(&showCmd, "cmd", false, "show symbols with package docs even if package is a command")
 	flagSet.BoolVar(&showSrc, "src", false, "show source code for symbol")
 	flagSet.BoolVar(&short, "short", false, "one-line representation for each symbol")
+	flagSet.BoolVar(&serve, "http", false, "serve HTML documentation over HTTP")
 	flagSet.Parse(args)
+	if serve {
+		return serveHTTP(writer, flagSet.Args())
+	}
 	var paths []string
 	var symbol, method string
 	// Loop until something is printed.
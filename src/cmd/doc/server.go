@@ -0,0 +1,539 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/doc"
+	"go/format"
+	"go/parser"
+	"go/printer"
+	"go/scanner"
+	"go/token"
+	"html/template"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"cmd/internal/browser"
+)
+
+// serveHTTP runs a web server rendering the documentation for every
+// package found by the directory scan: the standard library, the main
+// module and, in module mode, each dependency present in the module cache.
+// The address of the server is printed to w and, if possible, a browser
+// is opened at the page for the package and symbol named by args.
+// It does not return unless the server fails.
+func serveHTTP(w io.Writer, args []string) error {
+	s := newServer()
+	page := "/"
+	if len(args) > 0 {
+		buildPackage, userPath, sym, _ := parseArgs(args)
+		if buildPackage == nil {
+			return fmt.Errorf("no such package: %s", userPath)
+		}
+		d, ok := s.byDir[filepath.Clean(buildPackage.Dir)]
+		if !ok {
+			return fmt.Errorf("no documentation for package in %s", buildPackage.Dir)
+		}
+		page = "/pkg/" + d.importPath
+		if symbol, method := parseSymbol(sym); method != "" {
+			page += "#" + symbol + "." + method
+		} else if symbol != "" {
+			page += "#" + symbol
+		}
+	}
+
+	ln, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		return err
+	}
+	url := "http://" + ln.Addr().String() + page
+	fmt.Fprintf(w, "Serving documentation at %s\n", url)
+	go browser.Open(url)
+	return http.Serve(ln, s)
+}
+
+// A server serves HTML documentation for a fixed set of package directories.
+type server struct {
+	pkgs   []Dir          // Packages in scan order.
+	byPath map[string]Dir // Packages by import path.
+	byDir  map[string]Dir // Packages by file system directory.
+	mux    http.ServeMux
+}
+
+// newServer returns a server for the packages found by the directory scan.
+// It consumes the whole scan, so it must not run concurrently with other
+// users of dirs.
+func newServer() *server {
+	s := &server{
+		byPath: make(map[string]Dir),
+		byDir:  make(map[string]Dir),
+	}
+	dirs.Reset()
+	for {
+		d, ok := dirs.Next()
+		if !ok {
+			break
+		}
+		if _, dup := s.byPath[d.importPath]; dup || d.importPath == "" {
+			continue
+		}
+		s.pkgs = append(s.pkgs, d)
+		s.byPath[d.importPath] = d
+		s.byDir[filepath.Clean(d.dir)] = d
+	}
+	dirs.Reset()
+
+	s.mux.HandleFunc("/", s.serveIndex)
+	s.mux.HandleFunc("/pkg/", s.servePackage)
+	s.mux.HandleFunc("/src/", s.serveSource)
+	return s
+}
+
+func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// serveIndex serves the list of all known packages.
+func (s *server) serveIndex(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	goroot := filepath.Join(buildCtx.GOROOT, "src")
+	var data struct {
+		Title      string
+		Std, Other []string // Import paths.
+	}
+	data.Title = "Packages"
+	for _, d := range s.pkgs {
+		if _, ok := trim(filepath.ToSlash(d.dir), filepath.ToSlash(goroot)); ok {
+			data.Std = append(data.Std, d.importPath)
+		} else {
+			data.Other = append(data.Other, d.importPath)
+		}
+	}
+	s.execute(w, "index", data)
+}
+
+// servePackage serves the documentation page of the package
+// whose import path follows /pkg/.
+func (s *server) servePackage(w http.ResponseWriter, r *http.Request) {
+	importPath := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/pkg/"), "/")
+	d, ok := s.byPath[importPath]
+	if !ok {
+		http.Error(w, "no such package: "+importPath, http.StatusNotFound)
+		return
+	}
+	p, err := loadPage(d)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	s.execute(w, "package", p)
+}
+
+// serveSource serves a Go source file, with one anchor per line,
+// named by /src/ followed by its package import path and file name.
+func (s *server) serveSource(w http.ResponseWriter, r *http.Request) {
+	importPath, name := path.Split(strings.TrimPrefix(r.URL.Path, "/src/"))
+	d, ok := s.byPath[strings.TrimSuffix(importPath, "/")]
+	if !ok || !strings.HasSuffix(name, ".go") {
+		http.NotFound(w, r)
+		return
+	}
+	data, err := os.ReadFile(filepath.Join(d.dir, name))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	var buf bytes.Buffer
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	sc.Buffer(nil, len(data)+1)
+	for n := 1; sc.Scan(); n++ {
+		fmt.Fprintf(&buf, "<span id=\"L%d\"><a class=\"ln\" href=\"#L%[1]d\">%6[1]d</a>  %s</span>\n", n, template.HTMLEscapeString(sc.Text()))
+	}
+	s.execute(w, "source", struct {
+		Title      string
+		ImportPath string
+		Name       string
+		Code       template.HTML
+	}{
+		Title:      path.Join(d.importPath, name),
+		ImportPath: d.importPath,
+		Name:       name,
+		Code:       template.HTML(buf.String()),
+	})
+}
+
+// execute renders the named template to w.
+func (s *server) execute(w http.ResponseWriter, name string, data interface{}) {
+	var buf bytes.Buffer
+	if err := pageTemplate.ExecuteTemplate(&buf, name, data); err != nil {
+		log.Print(err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(buf.Bytes())
+}
+
+// A page holds the documentation of a single package as rendered in HTML.
+type page struct {
+	Title      string
+	ImportPath string
+	Doc        *doc.Package
+	Files      []string // Names of the package's non-test source files.
+
+	fset    *token.FileSet
+	imports map[string]map[string]string // File name to import name to import path.
+	types   map[string]bool              // Names of the package's documented types.
+}
+
+// loadPage parses the package in d, including its test files for the sake
+// of examples, and computes its documentation.
+func loadPage(d Dir) (*page, error) {
+	bp, err := build.ImportDir(d.dir, build.ImportComment)
+	if err != nil {
+		return nil, err
+	}
+	fset := token.NewFileSet()
+	imports := make(map[string]map[string]string)
+	var files []*ast.File
+	for _, list := range [][]string{bp.GoFiles, bp.CgoFiles, bp.TestGoFiles, bp.XTestGoFiles} {
+		for _, name := range list {
+			filename := filepath.Join(bp.Dir, name)
+			f, err := parser.ParseFile(fset, filename, nil, parser.ParseComments)
+			if err != nil {
+				return nil, err
+			}
+			files = append(files, f)
+			imports[filename] = importNames(f)
+		}
+	}
+
+	// As with the command-line output, the builtin package
+	// documents lower-case identifiers.
+	var mode doc.Mode
+	if unexported || bp.ImportPath == "builtin" || d.importPath == "builtin" {
+		mode |= doc.AllDecls
+	}
+	docPkg, err := doc.NewFromFiles(fset, files, d.importPath, mode)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &page{
+		Title:      d.importPath,
+		ImportPath: d.importPath,
+		Doc:        docPkg,
+		fset:       fset,
+		imports:    imports,
+		types:      make(map[string]bool),
+	}
+	p.Files = append(p.Files, bp.GoFiles...)
+	p.Files = append(p.Files, bp.CgoFiles...)
+	for _, t := range docPkg.Types {
+		p.types[t.Name] = true
+	}
+	return p, nil
+}
+
+// IsCommand reports whether the package is a command.
+func (p *page) IsCommand() bool {
+	return p.Doc.Name == "main"
+}
+
+// ShowSymbols reports whether the page lists the package's symbols.
+// As with the command-line output, the symbols of a command
+// are only shown if the -cmd flag is set.
+func (p *page) ShowSymbols() bool {
+	return !p.IsCommand() || showCmd
+}
+
+// importNames returns the names by which f refers to its imports.
+// Without a parse of the imported packages, the name of an unnamed import
+// is guessed from the last element of its path, skipping a major version
+// suffix such as "v2".
+func importNames(f *ast.File) map[string]string {
+	m := make(map[string]string)
+	for _, imp := range f.Imports {
+		importPath, err := strconv.Unquote(imp.Path.Value)
+		if err != nil {
+			continue
+		}
+		var name string
+		if imp.Name != nil {
+			name = imp.Name.Name
+		} else {
+			elem := strings.Split(importPath, "/")
+			name = elem[len(elem)-1]
+			if len(elem) > 1 && isMajorVersion(name) {
+				name = elem[len(elem)-2]
+			}
+			if i := strings.IndexByte(name, '.'); i >= 0 {
+				name = name[:i]
+			}
+			name = strings.TrimPrefix(name, "go-")
+		}
+		if name != "_" && name != "." {
+			m[name] = importPath
+		}
+	}
+	return m
+}
+
+// isMajorVersion reports whether s is a major version suffix such as "v2".
+func isMajorVersion(s string) bool {
+	if len(s) < 2 || s[0] != 'v' {
+		return false
+	}
+	for _, c := range s[1:] {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Source returns the URL of the source line declaring node.
+func (p *page) Source(node ast.Node) string {
+	pos := p.fset.Position(node.Pos())
+	return fmt.Sprintf("/src/%s/%s#L%d", p.ImportPath, filepath.Base(pos.Filename), pos.Line)
+}
+
+// Comment renders a doc comment as HTML.
+func (p *page) Comment(text string) template.HTML {
+	var buf bytes.Buffer
+	doc.ToHTML(&buf, text, nil)
+	return template.HTML(buf.String())
+}
+
+// Decl renders the declaration node as HTML, linking each
+// reference to a type of this package to its documentation,
+// each qualified identifier to the documentation of the imported
+// package, and each predeclared identifier to package builtin.
+func (p *page) Decl(node ast.Node) template.HTML {
+	var buf bytes.Buffer
+	if err := format.Node(&buf, p.fset, node); err != nil {
+		return template.HTML(template.HTMLEscapeString(err.Error()))
+	}
+	return p.linkify(buf.Bytes(), p.imports[p.fset.Position(node.Pos()).Filename])
+}
+
+// An exampleList is a list of examples to be rendered on a page.
+type exampleList struct {
+	Page *page
+	List []*doc.Example
+}
+
+// ExampleList returns the examples in list for rendering on p.
+func (p *page) ExampleList(list []*doc.Example) exampleList {
+	return exampleList{p, list}
+}
+
+// Example renders the code of ex as HTML.
+// Examples that are complete programs are shown as such.
+func (p *page) Example(ex *doc.Example) template.HTML {
+	var node interface{} = &printer.CommentedNode{Node: ex.Code, Comments: ex.Comments}
+	if ex.Play != nil {
+		node = ex.Play
+	}
+	var buf bytes.Buffer
+	if err := format.Node(&buf, p.fset, node); err != nil {
+		return template.HTML(template.HTMLEscapeString(err.Error()))
+	}
+	code := buf.String()
+	if _, ok := ex.Code.(*ast.BlockStmt); ok && ex.Play == nil {
+		// Remove the surrounding braces and the indentation they imply.
+		code = strings.TrimSpace(code)
+		code = strings.TrimPrefix(strings.TrimSuffix(code, "}"), "{")
+		code = strings.ReplaceAll(strings.Trim(code, "\n"), "\n\t", "\n")
+		code = strings.TrimPrefix(code, "\t")
+	}
+	return template.HTML(template.HTMLEscapeString(code))
+}
+
+// A tok is a token scanned from printed source.
+type tok struct {
+	off int
+	tok token.Token
+	lit string
+}
+
+// linkify returns the HTML for the Go source src, adding links
+// for the identifiers described by Decl. imports maps the import
+// names visible in src to their paths.
+func (p *page) linkify(src []byte, imports map[string]string) template.HTML {
+	fset := token.NewFileSet()
+	file := fset.AddFile("", fset.Base(), len(src))
+	var s scanner.Scanner
+	s.Init(file, src, nil, scanner.ScanComments)
+	var toks []tok
+	for {
+		pos, t, lit := s.Scan()
+		if t == token.EOF {
+			break
+		}
+		toks = append(toks, tok{file.Offset(pos), t, lit})
+	}
+
+	var out strings.Builder
+	last := 0
+	link := func(start, end int, href string) {
+		out.WriteString(template.HTMLEscapeString(string(src[last:start])))
+		fmt.Fprintf(&out, `<a href="%s">%s</a>`, template.HTMLEscapeString(href), template.HTMLEscapeString(string(src[start:end])))
+		last = end
+	}
+	for i := 0; i < len(toks); i++ {
+		t := toks[i]
+		if t.tok != token.IDENT || i > 0 && toks[i-1].tok == token.PERIOD {
+			continue
+		}
+		if i+2 < len(toks) && toks[i+1].tok == token.PERIOD && toks[i+2].tok == token.IDENT {
+			if importPath, ok := imports[t.lit]; ok {
+				sel := toks[i+2]
+				link(t.off, sel.off+len(sel.lit), "/pkg/"+importPath+"#"+sel.lit)
+				i += 2
+			}
+			continue
+		}
+		switch {
+		case p.types[t.lit]:
+			link(t.off, t.off+len(t.lit), "#"+t.lit)
+		case doc.IsPredeclared(t.lit) && p.ImportPath != "builtin":
+			link(t.off, t.off+len(t.lit), "/pkg/builtin#"+t.lit)
+		}
+	}
+	out.WriteString(template.HTMLEscapeString(string(src[last:])))
+	return template.HTML(out.String())
+}
+
+var pageTemplate = template.Must(template.New("").Parse(`
+{{define "header"}}<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}} - Go Documentation</title>
+<style>
+body { font-family: sans-serif; margin: 1em 2em; max-width: 60em; line-height: 1.4; }
+pre { background: #f5f5f5; padding: 0.5em; overflow-x: auto; line-height: 1.3; }
+pre.source { background: none; padding: 0; }
+a { color: #007d9c; text-decoration: none; }
+a:hover { text-decoration: underline; }
+a.ln { color: #999; }
+a.src { font-size: small; font-weight: normal; margin-left: 0.5em; }
+h3 { margin-top: 2em; }
+details { margin: 1em 0; }
+summary { cursor: pointer; color: #007d9c; }
+nav { margin-bottom: 1em; }
+</style>
+</head>
+<body>
+<nav><a href="/">Packages</a></nav>
+{{end}}
+
+{{define "footer"}}</body>
+</html>
+{{end}}
+
+{{define "index"}}{{template "header" .}}
+<h1>Packages</h1>
+{{with .Other}}<h2>Modules</h2>
+<ul>{{range .}}
+<li><a href="/pkg/{{.}}">{{.}}</a></li>{{end}}
+</ul>{{end}}
+{{with .Std}}<h2>Standard library</h2>
+<ul>{{range .}}
+<li><a href="/pkg/{{.}}">{{.}}</a></li>{{end}}
+</ul>{{end}}
+{{template "footer"}}{{end}}
+
+{{define "source"}}{{template "header" .}}
+<h1>Source file {{.Name}}</h1>
+<p>Package <a href="/pkg/{{.ImportPath}}">{{.ImportPath}}</a></p>
+<pre class="source">{{.Code}}</pre>
+{{template "footer"}}{{end}}
+
+{{define "examples"}}{{$page := .Page}}{{range .List}}<details id="example-{{.Name}}">
+<summary>Example{{with .Suffix}} ({{.}}){{end}}</summary>
+{{with .Doc}}<p>{{.}}</p>{{end}}
+<pre>{{$page.Example .}}</pre>
+{{with .Output}}<p>Output:</p>
+<pre>{{.}}</pre>{{end}}
+</details>
+{{end}}{{end}}
+
+{{define "package"}}{{template "header" .}}{{$page := .}}
+<h1>{{if .IsCommand}}Command{{else}}Package{{end}} {{.Doc.Name}}</h1>
+<p><code>import "{{.ImportPath}}"</code></p>
+{{.Comment .Doc.Doc}}
+{{template "examples" (.ExampleList .Doc.Examples)}}
+{{if .ShowSymbols}}
+<h2 id="pkg-index">Index</h2>
+<ul>
+{{if .Doc.Consts}}<li><a href="#pkg-constants">Constants</a></li>{{end}}
+{{if .Doc.Vars}}<li><a href="#pkg-variables">Variables</a></li>{{end}}
+{{range .Doc.Funcs}}<li><a href="#{{.Name}}">func {{.Name}}</a></li>
+{{end}}{{range .Doc.Types}}{{$type := .}}<li><a href="#{{.Name}}">type {{.Name}}</a>
+{{if or .Funcs .Methods}}<ul>
+{{range .Funcs}}<li><a href="#{{.Name}}">func {{.Name}}</a></li>
+{{end}}{{range .Methods}}<li><a href="#{{$type.Name}}.{{.Name}}">func ({{.Recv}}) {{.Name}}</a></li>
+{{end}}</ul>{{end}}</li>
+{{end}}</ul>
+
+{{with .Doc.Consts}}<h2 id="pkg-constants">Constants</h2>
+{{range .}}{{range .Names}}<span id="{{.}}"></span>{{end}}<pre>{{$page.Decl .Decl}}</pre>
+{{$page.Comment .Doc}}
+{{end}}{{end}}
+
+{{with .Doc.Vars}}<h2 id="pkg-variables">Variables</h2>
+{{range .}}{{range .Names}}<span id="{{.}}"></span>{{end}}<pre>{{$page.Decl .Decl}}</pre>
+{{$page.Comment .Doc}}
+{{end}}{{end}}
+
+{{with .Doc.Funcs}}<h2 id="pkg-functions">Functions</h2>
+{{range .}}<h3 id="{{.Name}}">func {{.Name}}<a class="src" href="{{$page.Source .Decl}}">source</a></h3>
+<pre>{{$page.Decl .Decl}}</pre>
+{{$page.Comment .Doc}}
+{{template "examples" ($page.ExampleList .Examples)}}
+{{end}}{{end}}
+
+{{with .Doc.Types}}<h2 id="pkg-types">Types</h2>
+{{range .}}{{$type := .}}<h3 id="{{.Name}}">type {{.Name}}<a class="src" href="{{$page.Source .Decl}}">source</a></h3>
+<pre>{{$page.Decl .Decl}}</pre>
+{{$page.Comment .Doc}}
+{{template "examples" ($page.ExampleList .Examples)}}
+{{range .Consts}}{{range .Names}}<span id="{{.}}"></span>{{end}}<pre>{{$page.Decl .Decl}}</pre>
+{{$page.Comment .Doc}}
+{{end}}{{range .Vars}}{{range .Names}}<span id="{{.}}"></span>{{end}}<pre>{{$page.Decl .Decl}}</pre>
+{{$page.Comment .Doc}}
+{{end}}{{range .Funcs}}<h4 id="{{.Name}}">func {{.Name}}<a class="src" href="{{$page.Source .Decl}}">source</a></h4>
+<pre>{{$page.Decl .Decl}}</pre>
+{{$page.Comment .Doc}}
+{{template "examples" ($page.ExampleList .Examples)}}
+{{end}}{{range .Methods}}<h4 id="{{$type.Name}}.{{.Name}}">func ({{.Recv}}) {{.Name}}<a class="src" href="{{$page.Source .Decl}}">source</a></h4>
+<pre>{{$page.Decl .Decl}}</pre>
+{{$page.Comment .Doc}}
+{{template "examples" ($page.ExampleList .Examples)}}
+{{end}}{{end}}{{end}}
+{{end}}
+
+{{with .Files}}<h2 id="pkg-files">Source files</h2>
+<p>{{range .}}<a href="/src/{{$page.ImportPath}}/{{.}}">{{.}}</a> {{end}}</p>{{end}}
+{{template "footer"}}{{end}}
+`))
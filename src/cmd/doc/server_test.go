@@ -0,0 +1,105 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+)
+
+func TestServer(t *testing.T) {
+	maybeSkip(t)
+	s := newServer()
+
+	tests := []struct {
+		url    string
+		status int
+		yes    []string // Regular expressions that should match.
+		no     []string // Regular expressions that should not match.
+	}{
+		{
+			url:    "/",
+			status: http.StatusOK,
+			yes: []string{
+				`<li><a href="/pkg/testdata">testdata</a></li>`,
+				`<li><a href="/pkg/fmt">fmt</a></li>`,
+			},
+		},
+		{
+			url:    "/pkg/testdata",
+			status: http.StatusOK,
+			yes: []string{
+				`<h1>Package pkg</h1>`,
+				`<p>\s*Package comment.\s*</p>`,
+				`<h3 id="ExportedFunc">func ExportedFunc<a class="src" href="/src/testdata/pkg.go#L59">source</a></h3>`,
+				`<h4 id="ExportedType.ExportedMethod">`,
+				`func ReturnExported\(\) <a href="#ExportedType">ExportedType</a>`,
+				`<a href="/pkg/io#Reader">io.Reader</a>`,
+				`<a href="/pkg/builtin#error">error</a>`,
+				`<span id="ConstOne"></span>`,
+				`<a href="/src/testdata/pkg.go">pkg.go</a>`,
+			},
+			no: []string{
+				`internalFunc`,
+				`id="unexportedType"`,
+			},
+		},
+		{
+			url:    "/pkg/strings",
+			status: http.StatusOK,
+			yes: []string{
+				`<details id="example-ToUpper">`,
+				`fmt.Println\(strings.ToUpper\(&#34;Gopher&#34;\)\)`,
+				`<p>Output:</p>\s*<pre>GOPHER\s*</pre>`,
+			},
+		},
+		{
+			url:    "/src/testdata/pkg.go",
+			status: http.StatusOK,
+			yes: []string{
+				`<span id="L5"><a class="ln" href="#L5">\s+5</a>  // Package comment.</span>`,
+			},
+		},
+		{
+			url:    "/pkg/no/such/package",
+			status: http.StatusNotFound,
+		},
+		{
+			url:    "/src/testdata/nofile.go",
+			status: http.StatusNotFound,
+		},
+		{
+			url:    "/src/testdata/pkg.go.orig",
+			status: http.StatusNotFound,
+		},
+	}
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, httptest.NewRequest("GET", test.url, nil))
+		if w.Code != test.status {
+			t.Errorf("GET %s: status %d, want %d", test.url, w.Code, test.status)
+			continue
+		}
+		body := w.Body.Bytes()
+		failed := false
+		for _, yes := range test.yes {
+			if !regexp.MustCompile(yes).Match(body) {
+				t.Errorf("GET %s: no match for %#q", test.url, yes)
+				failed = true
+			}
+		}
+		for _, no := range test.no {
+			if regexp.MustCompile(no).Match(body) {
+				t.Errorf("GET %s: unexpected match for %#q", test.url, no)
+				failed = true
+			}
+		}
+		if failed {
+			t.Logf("body:\n%s", body)
+		}
+	}
+}
@@ -72,7 +72,7 @@
 //
 // Usage:
 //
-// 	go build [-o output] [build flags] [packages]
+// 	go build [-o output] [-json] [build flags] [packages]
 //
 // Build compiles the packages named by the import paths,
 // along with their dependencies, but it does not install the results.
@@ -101,6 +101,33 @@
 // The -i flag installs the packages that are dependencies of the target.
 // The -i flag is deprecated. Compiled packages are cached automatically.
 //
+// The -json flag prints build output and failures to standard output as
+// a stream of JSON objects, one per line, instead of as plain text on
+// standard error. Each object is a BuildEvent:
+//
+// 	type BuildEvent struct {
+// 		Time    time.Time // encodes as an RFC3339-format string
+// 		Action  string    // "build-output" or "build-fail"
+// 		Package string    // import path of the package being built
+// 		Mode    string    // action that produced the event: "build", "link", "vet", and so on
+// 		Output  string    // compiler, linker or vet output
+// 		Errors  []struct {
+// 			File string
+// 			Line int
+// 			Col  int
+// 			Msg  string // message, including any indented lines that follow it
+// 		}
+// 	}
+//
+// A "build-output" event carries the output of one action, with
+// the position of each diagnostic in it listed in Errors. A "build-fail"
+// event reports that the action failed; its Output is empty if the
+// reason was already reported by a "build-output" event. The Time, Action,
+// Package and Output fields have the same meaning as in 'go test -json',
+// so the two streams can be decoded the same way. Errors found before
+// building begins, such as missing packages, are still printed to
+// standard error, as are the outputs of the -n, -x and -v flags.
+//
 // The build flags are shared by the build, clean, get, install, list, run,
 // and test commands:
 //
@@ -133,6 +160,16 @@
 // 		arguments to pass on each go tool asm invocation.
 // 	-buildmode mode
 // 		build mode to use. See 'go help buildmode' for more.
+// 	-buildvcs
+// 		Whether to stamp binaries with version control information. By default,
+// 		version control information is stamped into a binary if the main package
+// 		and the main module containing it are in the repository containing the
+// 		current directory (if there is a repository). Use -buildvcs=false to
+// 		omit version control information. Only Git repositories are supported.
+// 		If the repository status cannot be determined, for example because git
+// 		is not installed, the information is omitted, unless -buildvcs=true was
+// 		given explicitly, in which case the build fails.
+// 		The stamped information can be printed with 'go version -m'.
 // 	-compiler name
 // 		name of compiler to use, as in runtime.Compiler (gccgo or gc).
 // 	-gccgoflags '[pattern=]arg list'
@@ -393,6 +430,13 @@
 // 		Treat a command (package main) like a regular package.
 // 		Otherwise package main's exported symbols are hidden
 // 		when showing the package's top-level documentation.
+// 	-http
+// 		Serve HTML documentation for the packages of the standard
+// 		library, the main module and its dependencies in the module
+// 		cache on a local web server, and open a browser at the page
+// 		for the argument, if any. The pages include examples, links
+// 		to the source of each declaration and links to the
+// 		documentation of referenced packages.
 // 	-short
 // 		One-line representation for each symbol.
 // 	-src
@@ -598,7 +642,7 @@
 //
 // Usage:
 //
-// 	go get [-d] [-t] [-u] [-v] [build flags] [packages]
+// 	go get [-d] [-t] [-u] [-v] [-tool] [build flags] [packages]
 //
 // Get resolves its command-line arguments to packages at specific module versions,
 // updates go.mod to require those versions, downloads source code into the
@@ -646,6 +690,14 @@
 // The -d flag instructs get not to build or install packages. get will only
 // update go.mod and download source code needed to build packages.
 //
+// The -tool flag instructs get to add a tool directive to go.mod for each
+// named main package, recording it as a tool of the current module, or,
+// for arguments of the form path@none, to remove the tool directive
+// for path without otherwise changing the module's requirements.
+// The -tool flag implies -d. Tools are run with 'go tool', and the
+// pattern "tool" names all of them; for example, 'go get -tool tool'
+// upgrades every tool. See 'go help tool'.
+//
 // Building and installing packages with get is deprecated. In a future release,
 // the -d flag will be enabled by default, and 'go get' will be only be used to
 // adjust dependencies of the current module. To install a package using
@@ -669,7 +721,7 @@
 //
 // Usage:
 //
-// 	go install [build flags] [packages]
+// 	go install [-json] [build flags] [packages]
 //
 // Install compiles and installs the packages named by the import paths.
 //
@@ -716,6 +768,8 @@
 // The -i flag installs the dependencies of the named packages as well.
 // The -i flag is deprecated. Compiled packages are cached automatically.
 //
+// The -json flag prints build output as JSON, as described in 'go help build'.
+//
 // For more about the build flags, see 'go help build'.
 // For more about specifying packages, see 'go help packages'.
 //
@@ -764,6 +818,7 @@
 //         BuildID       string   // build ID of the compiled package (when using -export)
 //         Module        *Module  // info about package's containing module, if any (can be nil)
 //         Match         []string // command-line patterns matching this package
+//         DefaultGODEBUG string  // default GODEBUG setting, for main packages
 //         DepOnly       bool     // package is only a dependency, not explicitly listed
 //
 //         // Source files
@@ -1095,6 +1150,12 @@
 //
 // The -module flag changes the module's path (the go.mod file's module line).
 //
+// The -godebug=key=value flag adds a godebug key=value line,
+// replacing any existing godebug lines with the given key.
+//
+// The -dropgodebug=key flag drops any existing godebug lines
+// with the given key.
+//
 // The -require=path@version and -droprequire=path flags
 // add and drop a requirement on the given module path and version.
 // Note that -require overrides any existing requirements on path.
@@ -1124,9 +1185,9 @@
 // like "v1.2.3" or a closed interval like "[v1.1.0,v1.1.9]". Note that
 // -retract=version is a no-op if that retraction already exists.
 //
-// The -require, -droprequire, -exclude, -dropexclude, -replace,
-// -dropreplace, -retract, and -dropretract editing flags may be repeated,
-// and the changes are applied in the order given.
+// The -godebug, -dropgodebug, -require, -droprequire, -exclude, -dropexclude,
+// -replace, -dropreplace, -retract, and -dropretract editing flags may be
+// repeated, and the changes are applied in the order given.
 //
 // The -go=version flag sets the expected Go language version.
 //
@@ -1144,6 +1205,7 @@
 // 	type GoMod struct {
 // 		Module  ModPath
 // 		Go      string
+// 		Godebug []Godebug
 // 		Require []Require
 // 		Exclude []Module
 // 		Replace []Replace
@@ -1155,6 +1217,11 @@
 // 		Deprecated string
 // 	}
 //
+// 	type Godebug struct {
+// 		Key   string
+// 		Value string
+// 	}
+//
 // 	type Require struct {
 // 		Path string
 // 		Version string
@@ -1510,12 +1577,21 @@
 //
 // For more about each tool command, see 'go doc cmd/<command>'.
 //
+// In module-aware mode, the command may also name a tool of the main
+// module, recorded by a tool directive in go.mod (see 'go help get' for
+// adding one with 'go get -tool'). Such a tool may be named by its full
+// package path or, if no Go distribution tool has that name, by the last
+// element of its path (ignoring a major version suffix such as /v2). The
+// go command builds the tool using the main module's requirements,
+// caches the binary in the build cache directory, and runs it. The binary
+// is rebuilt only when the tool or its dependencies change.
+//
 //
 // Print Go version
 //
 // Usage:
 //
-// 	go version [-m] [-v] [file ...]
+// 	go version [-m] [-v] [-json] [-sbom=format] [file ...]
 //
 // Version prints the build information for Go executables.
 //
@@ -1533,7 +1609,23 @@
 // The -m flag causes go version to print each executable's embedded
 // module version information, when available. In the output, the module
 // information consists of multiple lines following the version line, each
-// indented by a leading tab character.
+// indented by a leading tab character. When the executable was built
+// in a version control checkout, the module information also includes
+// "build" lines recording the revision and commit time of the main module
+// (see the -buildvcs build flag).
+//
+// The -json flag is similar to -m but outputs the build information
+// as a JSON object for each executable, with the same fields as
+// runtime/debug.BuildInfo plus a GoVersion field.
+//
+// The -sbom flag is similar to -m but outputs a software bill of materials
+// for each executable, built from its embedded build information.
+// The format may be "spdx" for an SPDX 2.3 JSON document or "cyclonedx"
+// for a CycloneDX 1.4 JSON document. The bill of materials lists the
+// main module, the Go standard library, and every dependency module,
+// with package URLs (purls), the module hashes recorded in go.sum,
+// any module replacements, and the version control metadata of the
+// main module.
 //
 // See also: go doc runtime/debug.BuildInfo.
 //
@@ -1542,7 +1634,7 @@
 //
 // Usage:
 //
-// 	go vet [-n] [-x] [-vettool prog] [build flags] [vet flags] [packages]
+// 	go vet [-n] [-x] [-json] [-vettool prog] [build flags] [vet flags] [packages]
 //
 // Vet runs the Go vet command on the packages named by the import paths.
 //
@@ -1554,6 +1646,12 @@
 // The -n flag prints commands that would be executed.
 // The -x flag prints commands as they are executed.
 //
+// The -json flag is passed to the vet tool, which then reports its
+// diagnostics as JSON on standard error and succeeds. It also makes
+// go vet print the output and failures of the other actions it runs,
+// including a vet tool that fails, for example on a type error, as JSON
+// build events on standard output, as described in 'go help build'.
+//
 // The -vettool=prog flag selects a different analysis tool with alternative
 // or additional checks.
 // For example, the 'shadow' analyzer can be built and run using these commands:
@@ -1746,6 +1844,25 @@
 // See 'go help test' for details. Running 'go clean -testcache' removes
 // all cached test results (but not cached build results).
 //
+// Setting the GOCACHEPROG environment variable to a command
+// (with optional space-separated flags) makes the go command share its
+// cache with that program, so that the cache can be backed by remote or
+// shared storage. The go command starts the program once and exchanges
+// JSON messages with it, one per line, over the program's standard input
+// and output. The program first writes a response with ID 0 and
+// a KnownCommands list naming the commands it supports among "get",
+// "put" and "close". Each later request carries a unique ID, a Command,
+// and for "get" and "put" a base64 ActionID; the program must answer
+// each request with a response with the same ID, in any order.
+// A "get" response either sets Miss or gives the OutputID, Size, Time and
+// the DiskPath of a local file holding the output. A "put" request also
+// carries an OutputID and BodySize, and when BodySize is non-zero it is
+// followed by a line holding the output as a base64 JSON string.
+// A response with a non-empty Err reports that the request failed.
+// On "close", the program should finish pending work, reply, and exit
+// when its standard input is closed. The go command keeps using GOCACHE
+// as a local copy of the entries it reads from or writes to the program.
+//
 // The GODEBUG environment variable can enable printing of debugging
 // information about the state of the cache:
 //
@@ -1791,11 +1908,16 @@
 // 	GOCACHE
 // 		The directory where the go command will store cached
 // 		information for reuse in future builds.
+// 	GOCACHEPROG
+// 		A command (with optional space-separated flags) that implements
+// 		a shared build cache behind the local GOCACHE directory.
+// 		See 'go help cache' for details.
 // 	GOMODCACHE
 // 		The directory where the go command will store downloaded modules.
 // 	GODEBUG
 // 		Enable various debugging facilities. See 'go doc runtime'
-// 		for details.
+// 		for details. Default values can also be set in go.mod and in
+// 		//go:debug directives; see 'go help go.mod'.
 // 	GOENV
 // 		The location of the Go environment configuration file.
 // 		Cannot be set using 'go env -w'.
@@ -2003,6 +2125,33 @@
 // 'go get'. For details, see 'go help module-get' or
 // https://golang.org/ref/mod#go-get.
 //
+// A tool directive records a command that the module uses as a tool,
+// such as a code generator, by its package path:
+//
+// 	tool golang.org/x/tools/cmd/stringer
+//
+// The tool's module is required like any other dependency, and the
+// tool's packages are part of the "all" pattern, so 'go mod tidy' keeps
+// them. Use 'go get -tool' to add or remove tool directives, 'go tool'
+// to run a tool, and the "tool" pattern (as in 'go list tool') to name
+// all of the tools.
+//
+// A godebug line sets the default value of a GODEBUG setting for the
+// main packages built in the module, overriding the default implied by
+// the go line, which restores the behavior of the Go release it names:
+//
+// 	godebug http2client=0
+//
+// Several settings may be grouped in a block, and 'godebug default=go1.N'
+// selects the defaults of Go 1.N instead of those of the go line.
+// A main package can change the defaults further with //go:debug
+// directives before its package clause, such as
+//
+// 	//go:debug http2client=0
+//
+// The GODEBUG environment variable overrides all of these at run time.
+// Use 'go list -f {{.DefaultGODEBUG}}' to see the defaults for a package.
+//
 // To make other changes or to parse go.mod as JSON for use by other tools,
 // use 'go mod edit'. See 'go help mod edit' or
 // https://golang.org/ref/mod#go-mod-edit.
@@ -2481,7 +2630,7 @@
 // If no import paths are given, the action applies to the
 // package in the current directory.
 //
-// There are four reserved names for paths that should not be used
+// There are five reserved names for paths that should not be used
 // for packages to be built with the go tool:
 //
 // - "main" denotes the top-level package in a stand-alone executable.
@@ -2498,6 +2647,10 @@
 // - "cmd" expands to the Go repository's commands and their
 // internal libraries.
 //
+// - "tool" expands to the tools of the main module, named by tool
+// directives in its go.mod file. It is only meaningful when using
+// modules. The tools are also included in "all". See 'go help tool'.
+//
 // Import paths beginning with "cmd/" only match source code in
 // the Go repository.
 //
@@ -807,6 +807,7 @@ func TestNewReleaseRebuildsStalePackagesInGOPATH(t *testing.T) {
 		"src/internal/bytealg",
 		"src/internal/cpu",
 		"src/internal/goexperiment",
+		"src/internal/godebugs",
 		"src/math/bits",
 		"src/unsafe",
 		filepath.Join("pkg", runtime.GOOS+"_"+runtime.GOARCH),
@@ -32,8 +32,9 @@ type OutputID [HashSize]byte
 
 // A Cache is a package cache, backed by a file system directory tree.
 type Cache struct {
-	dir string
-	now func() time.Time
+	dir  string
+	now  func() time.Time
+	prog *progCache // if non-nil, the GOCACHEPROG child backing this cache
 }
 
 // Open opens and returns the cache in the given directory.
@@ -140,7 +141,11 @@ func (c *Cache) Get(id ActionID) (Entry, error) {
 	if verify {
 		return Entry{}, &entryNotFoundError{Err: errVerifyMode}
 	}
-	return c.get(id)
+	entry, err := c.get(id)
+	if err != nil && c.prog != nil {
+		return c.prog.get(c, id)
+	}
+	return entry, err
 }
 
 type Entry struct {
@@ -441,7 +446,17 @@ func (c *Cache) put(id ActionID, file io.ReadSeeker, allowVerify bool) (OutputID
 	}
 
 	// Add to cache index.
-	return out, size, c.putIndexEntry(id, out, size, allowVerify)
+	if err := c.putIndexEntry(id, out, size, allowVerify); err != nil {
+		return out, size, err
+	}
+
+	// Share with GOCACHEPROG, if any.
+	if c.prog != nil {
+		if err := c.prog.put(id, out, size, file); err != nil {
+			return out, size, err
+		}
+	}
+	return out, size, nil
 }
 
 // PutBytes stores the given bytes in the cache as the output for the action ID.
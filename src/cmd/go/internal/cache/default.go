@@ -55,6 +55,17 @@ func initDefaultCache() {
 	if err != nil {
 		base.Fatalf("failed to initialize build cache at %s: %s\n", dir, err)
 	}
+	if prog := cfg.Getenv("GOCACHEPROG"); prog != "" {
+		c.prog, err = startCacheProg(prog)
+		if err != nil {
+			base.Fatalf("go: %v", err)
+		}
+		base.AtExit(func() {
+			if err := c.prog.close(); err != nil {
+				fmt.Fprintf(os.Stderr, "go: closing GOCACHEPROG: %v\n", err)
+			}
+		})
+	}
 	defaultCache = c
 }
 
@@ -0,0 +1,357 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	"cmd/go/internal/str"
+)
+
+// The GOCACHEPROG protocol.
+//
+// When GOCACHEPROG is set, the go command starts the named program as
+// a child process and sends it requests for cache entries it does not
+// have locally, and for entries it adds to the local cache. The two
+// processes speak JSON over the child's stdin and stdout: each request
+// is a ProgRequest and each response a ProgResponse, one per line.
+//
+// The child begins by writing an unsolicited response with ID 0 whose
+// KnownCommands lists the commands it supports. Afterwards, it must
+// write exactly one response for each request, with the request's ID.
+// Responses may be written in any order.
+//
+// A "put" request with a non-zero BodySize is followed by a line holding
+// the output data, encoded as a base64 JSON string. On "close", the child
+// should finish any pending work, reply, and exit once its stdin is closed.
+
+// A ProgCmd is a command sent to a GOCACHEPROG child process.
+type ProgCmd string
+
+const (
+	// cmdGet asks for the output of an action ID.
+	// The child replies with Miss set, or with the output's OutputID,
+	// Size, Time and a DiskPath from which the go command can read it.
+	cmdGet = ProgCmd("get")
+
+	// cmdPut stores the output of an action ID.
+	cmdPut = ProgCmd("put")
+
+	// cmdClose tells the child that the go command is exiting.
+	cmdClose = ProgCmd("close")
+)
+
+// A ProgRequest is a request sent from the go command to a GOCACHEPROG
+// child process.
+type ProgRequest struct {
+	// ID is unique among the requests in flight.
+	ID int64
+
+	// Command is the request type.
+	Command ProgCmd
+
+	// ActionID is the action ID being looked up or stored,
+	// for "get" and "put" requests.
+	ActionID []byte `json:",omitempty"`
+
+	// OutputID is the SHA-256 of the output data, for "put" requests.
+	OutputID []byte `json:",omitempty"`
+
+	// Body holds the output data for "put" requests.
+	// It is sent on the line after the request itself.
+	Body io.Reader `json:"-"`
+
+	// BodySize is the number of bytes in Body.
+	BodySize int64 `json:",omitempty"`
+}
+
+// A ProgResponse is a response from a GOCACHEPROG child process.
+type ProgResponse struct {
+	// ID is the ID of the request being answered,
+	// or 0 for the initial response.
+	ID int64
+
+	// Err is non-empty if the request failed.
+	Err string `json:",omitempty"`
+
+	// KnownCommands lists the commands the child supports.
+	// It is set only in the initial response.
+	KnownCommands []ProgCmd `json:",omitempty"`
+
+	// Miss reports that a "get" found no entry.
+	Miss bool `json:",omitempty"`
+
+	// OutputID, Size and Time describe the entry found by a "get".
+	OutputID []byte     `json:",omitempty"`
+	Size     int64      `json:",omitempty"`
+	Time     *time.Time `json:",omitempty"`
+
+	// DiskPath is the absolute path of a file holding the output
+	// found by a "get". It may also be set in reply to a "put".
+	DiskPath string `json:",omitempty"`
+}
+
+// A progCache forwards cache misses and new cache entries to a
+// GOCACHEPROG child process.
+type progCache struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	bw     *bufio.Writer
+	jenc   *json.Encoder
+	can    map[ProgCmd]bool
+	doneCh chan struct{} // closed when readLoop returns
+
+	writeMu sync.Mutex // serializes writes to stdin
+
+	mu       sync.Mutex // guards the fields below
+	nextID   int64
+	inFlight map[int64]chan<- *ProgResponse
+	readErr  error
+	closing  bool
+}
+
+// startCacheProg starts the GOCACHEPROG program prog and waits for its
+// initial response.
+func startCacheProg(prog string) (*progCache, error) {
+	args, err := str.SplitQuotedFields(prog)
+	if err != nil {
+		return nil, fmt.Errorf("parsing GOCACHEPROG: %v", err)
+	}
+	if len(args) == 0 {
+		return nil, errors.New("GOCACHEPROG is empty")
+	}
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stderr = os.Stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("starting GOCACHEPROG program %q: %v", args[0], err)
+	}
+
+	jd := json.NewDecoder(stdout)
+	var res ProgResponse
+	if err := jd.Decode(&res); err != nil {
+		stdin.Close()
+		cmd.Wait()
+		return nil, fmt.Errorf("reading initial response from GOCACHEPROG: %v", err)
+	}
+	if res.ID != 0 {
+		stdin.Close()
+		cmd.Wait()
+		return nil, fmt.Errorf("GOCACHEPROG sent response ID %d before its initial response", res.ID)
+	}
+
+	bw := bufio.NewWriter(stdin)
+	c := &progCache{
+		cmd:      cmd,
+		stdin:    stdin,
+		bw:       bw,
+		jenc:     json.NewEncoder(bw),
+		can:      make(map[ProgCmd]bool),
+		doneCh:   make(chan struct{}),
+		inFlight: make(map[int64]chan<- *ProgResponse),
+	}
+	for _, cmd := range res.KnownCommands {
+		c.can[cmd] = true
+	}
+	go c.readLoop(jd)
+	return c, nil
+}
+
+// readLoop reads responses from the child and hands each one to the
+// request waiting for it. When the child's stdout is closed or sends
+// something invalid, readLoop fails all pending requests.
+func (c *progCache) readLoop(jd *json.Decoder) {
+	defer close(c.doneCh)
+	for {
+		res := new(ProgResponse)
+		err := jd.Decode(res)
+		c.mu.Lock()
+		if err == nil {
+			ch, ok := c.inFlight[res.ID]
+			delete(c.inFlight, res.ID)
+			if ok {
+				c.mu.Unlock()
+				ch <- res
+				continue
+			}
+			err = fmt.Errorf("GOCACHEPROG sent response for unknown request ID %d", res.ID)
+		} else if err == io.EOF {
+			err = errors.New("GOCACHEPROG exited")
+			if c.closing {
+				err = errors.New("GOCACHEPROG is closed")
+			}
+		} else {
+			err = fmt.Errorf("reading response from GOCACHEPROG: %v", err)
+		}
+		c.readErr = err
+		for id, ch := range c.inFlight {
+			close(ch)
+			delete(c.inFlight, id)
+		}
+		c.mu.Unlock()
+		return
+	}
+}
+
+// send sends req to the child and waits for its response.
+func (c *progCache) send(req *ProgRequest) (*ProgResponse, error) {
+	resc := make(chan *ProgResponse, 1)
+	if err := c.writeToChild(req, resc); err != nil {
+		return nil, err
+	}
+	res, ok := <-resc
+	if !ok {
+		c.mu.Lock()
+		err := c.readErr
+		c.mu.Unlock()
+		return nil, err
+	}
+	if res.Err != "" {
+		return nil, fmt.Errorf("GOCACHEPROG %s: %s", req.Command, res.Err)
+	}
+	return res, nil
+}
+
+func (c *progCache) writeToChild(req *ProgRequest, resc chan<- *ProgResponse) (err error) {
+	c.mu.Lock()
+	if c.readErr != nil {
+		c.mu.Unlock()
+		return c.readErr
+	}
+	c.nextID++
+	req.ID = c.nextID
+	c.inFlight[req.ID] = resc
+	c.mu.Unlock()
+
+	defer func() {
+		if err != nil {
+			c.mu.Lock()
+			delete(c.inFlight, req.ID)
+			c.mu.Unlock()
+		}
+	}()
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if err := c.jenc.Encode(req); err != nil {
+		return err
+	}
+	if req.BodySize > 0 {
+		if err := c.bw.WriteByte('"'); err != nil {
+			return err
+		}
+		enc := base64.NewEncoder(base64.StdEncoding, c.bw)
+		n, err := io.Copy(enc, req.Body)
+		if err != nil {
+			return err
+		}
+		if n != req.BodySize {
+			return fmt.Errorf("GOCACHEPROG put: body is %d bytes, want %d", n, req.BodySize)
+		}
+		if err := enc.Close(); err != nil {
+			return err
+		}
+		if _, err := c.bw.WriteString("\"\n"); err != nil {
+			return err
+		}
+	}
+	return c.bw.Flush()
+}
+
+// get asks the child for the output of id. If the child has it,
+// get copies the output and its index entry into the local cache c.
+func (c *progCache) get(local *Cache, id ActionID) (Entry, error) {
+	if !c.can[cmdGet] {
+		return Entry{}, &entryNotFoundError{Err: errors.New("GOCACHEPROG does not support get")}
+	}
+	res, err := c.send(&ProgRequest{Command: cmdGet, ActionID: id[:]})
+	if err != nil {
+		return Entry{}, &entryNotFoundError{Err: err}
+	}
+	if res.Miss {
+		return Entry{}, &entryNotFoundError{Err: errors.New("GOCACHEPROG miss")}
+	}
+
+	var e Entry
+	if len(res.OutputID) != len(e.OutputID) {
+		return Entry{}, &entryNotFoundError{Err: errors.New("GOCACHEPROG returned invalid OutputID")}
+	}
+	copy(e.OutputID[:], res.OutputID)
+	e.Size = res.Size
+	if res.Time != nil {
+		e.Time = *res.Time
+	} else {
+		e.Time = time.Now()
+	}
+	if res.DiskPath == "" {
+		return Entry{}, &entryNotFoundError{Err: errors.New("GOCACHEPROG returned no DiskPath")}
+	}
+	f, err := os.Open(res.DiskPath)
+	if err != nil {
+		return Entry{}, &entryNotFoundError{Err: err}
+	}
+	defer f.Close()
+	// copyFile checks that the file has the promised hash and size.
+	if err := local.copyFile(f, e.OutputID, e.Size); err != nil {
+		return Entry{}, &entryNotFoundError{Err: err}
+	}
+	if err := local.putIndexEntry(id, e.OutputID, e.Size, false); err != nil {
+		return Entry{}, &entryNotFoundError{Err: err}
+	}
+	return e, nil
+}
+
+// put sends the output in file, with the given output ID and size,
+// to the child as the output of id.
+func (c *progCache) put(id ActionID, out OutputID, size int64, file io.ReadSeeker) error {
+	if !c.can[cmdPut] {
+		return nil
+	}
+	if _, err := file.Seek(0, 0); err != nil {
+		return err
+	}
+	_, err := c.send(&ProgRequest{
+		Command:  cmdPut,
+		ActionID: id[:],
+		OutputID: out[:],
+		Body:     file,
+		BodySize: size,
+	})
+	return err
+}
+
+// close tells the child the go command is done with it and waits
+// for it to exit.
+func (c *progCache) close() error {
+	c.mu.Lock()
+	c.closing = true
+	c.mu.Unlock()
+	var err error
+	if c.can[cmdClose] {
+		_, err = c.send(&ProgRequest{Command: cmdClose})
+	}
+	c.stdin.Close()
+	<-c.doneCh
+	if werr := c.cmd.Wait(); err == nil {
+		err = werr
+	}
+	return err
+}
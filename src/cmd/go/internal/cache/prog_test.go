@@ -0,0 +1,175 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestMain(m *testing.M) {
+	if dir := os.Getenv("GO_CACHEPROG_TEST_DIR"); dir != "" {
+		if err := fileCacheProg(dir); err != nil {
+			fmt.Fprintf(os.Stderr, "cacheprog: %v\n", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// fileCacheProg is a reference GOCACHEPROG implementation that stores
+// entries as files in dir. The test binary runs it as a child process
+// when GO_CACHEPROG_TEST_DIR is set.
+func fileCacheProg(dir string) error {
+	type entry struct {
+		OutputID []byte
+		Size     int64
+		Time     time.Time
+	}
+	br := bufio.NewReader(os.Stdin)
+	jd := json.NewDecoder(br)
+	bw := bufio.NewWriter(os.Stdout)
+	je := json.NewEncoder(bw)
+	reply := func(res *ProgResponse) error {
+		if err := je.Encode(res); err != nil {
+			return err
+		}
+		return bw.Flush()
+	}
+	if err := reply(&ProgResponse{KnownCommands: []ProgCmd{cmdGet, cmdPut, cmdClose}}); err != nil {
+		return err
+	}
+	for {
+		var req ProgRequest
+		if err := jd.Decode(&req); err != nil {
+			return err
+		}
+		res := &ProgResponse{ID: req.ID}
+		actionFile := filepath.Join(dir, fmt.Sprintf("%x-a", req.ActionID))
+		switch req.Command {
+		default:
+			res.Err = "unknown command"
+		case cmdClose:
+			if err := reply(res); err != nil {
+				return err
+			}
+			return nil
+		case cmdGet:
+			var e entry
+			data, err := os.ReadFile(actionFile)
+			if err == nil {
+				err = json.Unmarshal(data, &e)
+			}
+			if err != nil {
+				res.Miss = true
+				break
+			}
+			res.OutputID = e.OutputID
+			res.Size = e.Size
+			res.Time = &e.Time
+			res.DiskPath = filepath.Join(dir, fmt.Sprintf("%x-d", e.OutputID))
+		case cmdPut:
+			var body []byte
+			if req.BodySize > 0 {
+				if err := jd.Decode(&body); err != nil {
+					return err
+				}
+			}
+			if int64(len(body)) != req.BodySize {
+				res.Err = "body has wrong size"
+				break
+			}
+			diskPath := filepath.Join(dir, fmt.Sprintf("%x-d", req.OutputID))
+			data, _ := json.Marshal(entry{req.OutputID, req.BodySize, time.Now()})
+			if err := os.WriteFile(diskPath, body, 0666); err != nil {
+				res.Err = err.Error()
+			} else if err := os.WriteFile(actionFile, data, 0666); err != nil {
+				res.Err = err.Error()
+			}
+			res.DiskPath = diskPath
+		}
+		if err := reply(res); err != nil {
+			return err
+		}
+	}
+}
+
+func TestProgCache(t *testing.T) {
+	exe, err := os.Executable()
+	if err != nil {
+		t.Skipf("cannot find test executable: %v", err)
+	}
+	tmp := t.TempDir()
+	remote := filepath.Join(tmp, "remote")
+	if err := os.Mkdir(remote, 0777); err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv("GO_CACHEPROG_TEST_DIR", remote)
+	defer os.Unsetenv("GO_CACHEPROG_TEST_DIR")
+
+	open := func(name string) *Cache {
+		t.Helper()
+		dir := filepath.Join(tmp, name)
+		if err := os.Mkdir(dir, 0777); err != nil {
+			t.Fatal(err)
+		}
+		c, err := Open(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c.prog, err = startCacheProg(exe)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+
+	// Store an entry through one cache, then read it back through
+	// another cache with an empty local directory.
+	data := []byte("hello, remote cache")
+	c1 := open("c1")
+	if err := c1.PutBytes(dummyID(1), data); err != nil {
+		t.Fatalf("PutBytes: %v", err)
+	}
+	if err := c1.prog.close(); err != nil {
+		t.Fatalf("closing c1: %v", err)
+	}
+
+	c2 := open("c2")
+	defer c2.prog.close()
+	got, entry, err := c2.GetBytes(dummyID(1))
+	if err != nil {
+		t.Fatalf("GetBytes: %v", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Errorf("GetBytes = %q, want %q", got, data)
+	}
+	if want := OutputID(sha256.Sum256(data)); entry.OutputID != want {
+		t.Errorf("GetBytes OutputID = %x, want %x", entry.OutputID, want)
+	}
+
+	// The entry is now in c2's local directory as well.
+	c2.prog.mu.Lock()
+	n := c2.prog.nextID
+	c2.prog.mu.Unlock()
+	if _, _, err := c2.GetBytes(dummyID(1)); err != nil {
+		t.Fatalf("second GetBytes: %v", err)
+	}
+	if c2.prog.nextID != n {
+		t.Errorf("second GetBytes asked GOCACHEPROG, want local hit")
+	}
+
+	if _, err := c2.Get(dummyID(2)); err == nil {
+		t.Errorf("Get of missing entry succeeded")
+	}
+}
@@ -26,11 +26,14 @@ import (
 var (
 	BuildA                 bool   // -a flag
 	BuildBuildmode         string // -buildmode flag
+	BuildBuildvcs          = true // -buildvcs flag
+	BuildBuildvcsExplicit  bool   // whether -buildvcs was set explicitly
 	BuildContext           = defaultContext()
 	BuildMod               string                  // -mod flag
 	BuildModExplicit       bool                    // whether -mod was set explicitly
 	BuildModReason         string                  // reason -mod was set, if set by default
 	BuildI                 bool                    // -i flag
+	BuildJSON              bool                    // -json flag
 	BuildLinkshared        bool                    // -linkshared flag
 	BuildMSan              bool                    // -msan flag
 	BuildN                 bool                    // -n flag
@@ -115,6 +115,13 @@ Flags:
 		Treat a command (package main) like a regular package.
 		Otherwise package main's exported symbols are hidden
 		when showing the package's top-level documentation.
+	-http
+		Serve HTML documentation for the packages of the standard
+		library, the main module and its dependencies in the module
+		cache on a local web server, and open a browser at the page
+		for the argument, if any. The pages include examples, links
+		to the source of each declaration and links to the
+		documentation of referenced packages.
 	-short
 		One-line representation for each symbol.
 	-src
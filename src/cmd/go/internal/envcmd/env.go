@@ -71,6 +71,7 @@ func MkEnv() []cfg.EnvVar {
 		{Name: "GOARCH", Value: cfg.Goarch},
 		{Name: "GOBIN", Value: cfg.GOBIN},
 		{Name: "GOCACHE", Value: cache.DefaultDir()},
+		{Name: "GOCACHEPROG", Value: cfg.Getenv("GOCACHEPROG")},
 		{Name: "GOENV", Value: envFile},
 		{Name: "GOEXE", Value: cfg.ExeSuffix},
 		{Name: "GOEXPERIMENT", Value: buildcfg.GOEXPERIMENT()},
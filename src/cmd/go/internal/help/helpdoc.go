@@ -49,7 +49,7 @@ environment variable (For more details see: 'go help gopath').
 If no import paths are given, the action applies to the
 package in the current directory.
 
-There are four reserved names for paths that should not be used
+There are five reserved names for paths that should not be used
 for packages to be built with the go tool:
 
 - "main" denotes the top-level package in a stand-alone executable.
@@ -66,6 +66,10 @@ Go library.
 - "cmd" expands to the Go repository's commands and their
 internal libraries.
 
+- "tool" expands to the tools of the main module, named by tool
+directives in its go.mod file. It is only meaningful when using
+modules. The tools are also included in "all". See 'go help tool'.
+
 Import paths beginning with "cmd/" only match source code in
 the Go repository.
 
@@ -498,11 +502,16 @@ General-purpose environment variables:
 	GOCACHE
 		The directory where the go command will store cached
 		information for reuse in future builds.
+	GOCACHEPROG
+		A command (with optional space-separated flags) that implements
+		a shared build cache behind the local GOCACHE directory.
+		See 'go help cache' for details.
 	GOMODCACHE
 		The directory where the go command will store downloaded modules.
 	GODEBUG
 		Enable various debugging facilities. See 'go doc runtime'
-		for details.
+		for details. Default values can also be set in go.mod and in
+		//go:debug directives; see 'go help go.mod'.
 	GOENV
 		The location of the Go environment configuration file.
 		Cannot be set using 'go env -w'.
@@ -771,6 +780,25 @@ The go command also caches successful package test results.
 See 'go help test' for details. Running 'go clean -testcache' removes
 all cached test results (but not cached build results).
 
+Setting the GOCACHEPROG environment variable to a command
+(with optional space-separated flags) makes the go command share its
+cache with that program, so that the cache can be backed by remote or
+shared storage. The go command starts the program once and exchanges
+JSON messages with it, one per line, over the program's standard input
+and output. The program first writes a response with ID 0 and
+a KnownCommands list naming the commands it supports among "get",
+"put" and "close". Each later request carries a unique ID, a Command,
+and for "get" and "put" a base64 ActionID; the program must answer
+each request with a response with the same ID, in any order.
+A "get" response either sets Miss or gives the OutputID, Size, Time and
+the DiskPath of a local file holding the output. A "put" request also
+carries an OutputID and BodySize, and when BodySize is non-zero it is
+followed by a line holding the output as a base64 JSON string.
+A response with a non-empty Err reports that the request failed.
+On "close", the program should finish pending work, reply, and exit
+when its standard input is closed. The go command keeps using GOCACHE
+as a local copy of the entries it reads from or writes to the program.
+
 The GODEBUG environment variable can enable printing of debugging
 information about the state of the cache:
 
@@ -69,6 +69,7 @@ to -f '{{.ImportPath}}'. The struct being passed to the template is:
         BuildID       string   // build ID of the compiled package (when using -export)
         Module        *Module  // info about package's containing module, if any (can be nil)
         Match         []string // command-line patterns matching this package
+        DefaultGODEBUG string  // default GODEBUG setting, for main packages
         DepOnly       bool     // package is only a dependency, not explicitly listed
 
         // Source files
@@ -0,0 +1,153 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package load
+
+import (
+	"errors"
+	"fmt"
+	"go/build"
+	"internal/godebugs"
+	"sort"
+	"strconv"
+	"strings"
+
+	"cmd/go/internal/modload"
+)
+
+var ErrNotGoDebug = errors.New("not //go:debug line")
+
+// ParseGoDebug parses the //go:debug directive text,
+// returning the key and value it sets.
+// It returns ErrNotGoDebug if text is not a //go:debug line.
+func ParseGoDebug(text string) (key, value string, err error) {
+	rest := strings.TrimPrefix(text, "//go:debug")
+	if rest == text || rest != "" && rest[0] != ' ' && rest[0] != '\t' {
+		return "", "", ErrNotGoDebug
+	}
+	kv := strings.TrimSpace(rest)
+	j := strings.Index(kv, "=")
+	if j < 0 {
+		return "", "", fmt.Errorf("missing key=value")
+	}
+	k, v := kv[:j], kv[j+1:]
+	if err := modload.CheckGodebug("//go:debug setting", k, v); err != nil {
+		return "", "", err
+	}
+	return k, v, nil
+}
+
+// checkGoDebug reports the first invalid //go:debug directive in p.
+// //go:debug directives are only meaningful in package main and in tests,
+// so checkGoDebug also rejects them in the non-test files of other packages.
+func checkGoDebug(p *Package) error {
+	for _, d := range p.Internal.Build.Directives {
+		_, _, err := ParseGoDebug(d.Text)
+		if err == ErrNotGoDebug {
+			continue
+		}
+		if err == nil && p.Name != "main" {
+			err = errors.New("only allowed in package main or test")
+		}
+		if err != nil {
+			return fmt.Errorf("%s: invalid //go:debug: %v", d.Pos, err)
+		}
+	}
+	for _, list := range [][]build.Directive{p.Internal.Build.TestDirectives, p.Internal.Build.XTestDirectives} {
+		for _, d := range list {
+			if _, _, err := ParseGoDebug(d.Text); err != nil && err != ErrNotGoDebug {
+				return fmt.Errorf("%s: invalid //go:debug: %v", d.Pos, err)
+			}
+		}
+	}
+	return nil
+}
+
+// defaultGODEBUG returns the default GODEBUG setting for the main package p.
+// It starts from the defaults implied by the main module's go version,
+// applies the godebug lines in go.mod, and then the //go:debug directives
+// in p and in the given directive lists, which come from test files.
+// When p is being linked as a test, directives holds the directives
+// of the package under test if that package is itself a main package.
+func defaultGODEBUG(p *Package, directives, testDirectives, xtestDirectives []build.Directive) string {
+	if p.Name != "main" || p.Standard {
+		// Commands in the standard library always use the
+		// toolchain's defaults.
+		return ""
+	}
+	goVersion := modload.MainModuleGoVersion()
+	if modload.RootMode == modload.NoRoot && p.Module != nil && p.Module.GoVersion != "" {
+		// This is go install pkg@version or go run pkg@version.
+		// Use the Go version from the package's own module.
+		goVersion = p.Module.GoVersion
+	}
+
+	m := make(map[string]string)
+	for _, g := range modload.Godebugs() {
+		m[g.Key] = g.Value
+	}
+	var pkgDirectives []build.Directive
+	if p.Internal.Build != nil {
+		pkgDirectives = p.Internal.Build.Directives
+	}
+	for _, list := range [][]build.Directive{pkgDirectives, directives, testDirectives, xtestDirectives} {
+		for _, d := range list {
+			k, v, err := ParseGoDebug(d.Text)
+			if err != nil {
+				continue
+			}
+			m[k] = v
+		}
+	}
+	if v, ok := m["default"]; ok {
+		delete(m, "default")
+		goVersion = strings.TrimPrefix(v, "go")
+	}
+
+	defaults := godebugForGoVersion(goVersion)
+	for k, v := range m {
+		defaults[k] = v
+	}
+
+	var keys []string
+	for k := range defaults {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	for _, k := range keys {
+		if b.Len() > 0 {
+			b.WriteString(",")
+		}
+		b.WriteString(k)
+		b.WriteString("=")
+		b.WriteString(defaults[k])
+	}
+	return b.String()
+}
+
+// godebugForGoVersion returns the GODEBUG settings that restore the
+// behavior of Go version v, for each setting whose default has changed
+// since then.
+func godebugForGoVersion(v string) map[string]string {
+	def := make(map[string]string)
+	if strings.Count(v, ".") >= 2 {
+		i := strings.Index(v, ".")
+		j := i + 1 + strings.Index(v[i+1:], ".")
+		v = v[:j]
+	}
+	if !strings.HasPrefix(v, "1.") {
+		return def
+	}
+	n, err := strconv.Atoi(v[len("1."):])
+	if err != nil {
+		return def
+	}
+	for _, info := range godebugs.All {
+		if n < info.Changed {
+			def[info.Name] = info.Old
+		}
+	}
+	return def
+}
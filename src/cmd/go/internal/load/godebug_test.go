@@ -0,0 +1,40 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package load
+
+import "testing"
+
+var parseGoDebugTests = []struct {
+	text       string
+	key, value string
+	err        string
+}{
+	{"//go:debug http2client=0", "http2client", "0", ""},
+	{"//go:debug\thttp2client=", "http2client", "", ""},
+	{"//go:debug default=go1.16", "default", "go1.16", ""},
+	{"//go:debugx http2client=0", "", "", "not //go:debug line"},
+	{"//go:build linux", "", "", "not //go:debug line"},
+	{"//go:debug", "", "", "missing key=value"},
+	{"//go:debug http2client", "", "", "missing key=value"},
+	{"//go:debug nosuchsetting=1", "", "", `unknown //go:debug setting "nosuchsetting"`},
+	{"//go:debug http2client=0,http2server=0", "", "", "value contains space or comma"},
+	{"//go:debug default=1.16", "", "", "value for default= must be goVERSION"},
+	{"//go:debug default=go1.999", "", "", "default=go1.999 too new"},
+}
+
+func TestParseGoDebug(t *testing.T) {
+	for _, tt := range parseGoDebugTests {
+		k, v, err := ParseGoDebug(tt.text)
+		if err != nil {
+			if tt.err == "" || len(err.Error()) < len(tt.err) || err.Error()[:len(tt.err)] != tt.err {
+				t.Errorf("ParseGoDebug(%q) = error %q, want %q", tt.text, err, tt.err)
+			}
+			continue
+		}
+		if tt.err != "" || k != tt.key || v != tt.value {
+			t.Errorf("ParseGoDebug(%q) = %q, %q, nil, want %q, %q, %q", tt.text, k, v, tt.key, tt.value, tt.err)
+		}
+	}
+}
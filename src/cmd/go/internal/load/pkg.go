@@ -14,6 +14,7 @@ import (
 	"go/build"
 	"go/scanner"
 	"go/token"
+	exec "internal/execabs"
 	"internal/goroot"
 	"io/fs"
 	"os"
@@ -24,6 +25,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 	"unicode"
 	"unicode/utf8"
 
@@ -38,9 +40,9 @@ import (
 	"cmd/go/internal/search"
 	"cmd/go/internal/str"
 	"cmd/go/internal/trace"
+	"cmd/go/internal/vcs"
 	"cmd/internal/sys"
 
-	"golang.org/x/mod/modfile"
 	"golang.org/x/mod/module"
 )
 
@@ -74,6 +76,8 @@ type PackagePublic struct {
 	BinaryOnly    bool                  `json:",omitempty"` // package cannot be recompiled
 	Incomplete    bool                  `json:",omitempty"` // was there an error loading this package or dependencies?
 
+	DefaultGODEBUG string `json:",omitempty"` // default GODEBUG setting, for main packages
+
 	// Stale and StaleReason remain here *only* for the list command.
 	// They are only initialized in preparation for list execution.
 	// The regular build determines staleness on the fly during action execution.
@@ -1861,6 +1865,14 @@ func (p *Package) load(ctx context.Context, opts PackageOpts, path string, stk *
 		p.Module = modload.PackageModuleInfo(ctx, pkgPath)
 	}
 
+	// Now that we know the package's module, check its //go:debug
+	// directives and compute its default GODEBUG.
+	if err := checkGoDebug(p); err != nil {
+		setError(err)
+		return
+	}
+	p.DefaultGODEBUG = defaultGODEBUG(p, nil, nil, nil)
+
 	p.EmbedFiles, p.Internal.Embed, err = resolveEmbed(p.Dir, p.EmbedPatterns)
 	if err != nil {
 		p.Incomplete = true
@@ -1922,6 +1934,9 @@ func (p *Package) load(ctx context.Context, opts PackageOpts, path string, stk *
 
 	if cfg.ModulesEnabled && p.Error == nil && p.Name == "main" && len(p.DepsErrors) == 0 {
 		p.Internal.BuildInfo = modload.PackageBuildInfo(pkgPath, p.Deps)
+		if opts.LoadVCS && cfg.BuildBuildvcs {
+			p.Internal.BuildInfo += p.vcsBuildInfo()
+		}
 	}
 
 	// unsafe is a fake package.
@@ -2151,6 +2166,65 @@ func isBadEmbedName(name string) bool {
 	return false
 }
 
+// vcsStatusCache maps repository root directories (string)
+// to their status (vcsStatus).
+var vcsStatusCache par.Cache
+
+type vcsStatus struct {
+	st  vcs.Status
+	err error
+}
+
+// vcsBuildInfo returns the "build" lines describing the version control
+// checkout containing the main package p, or the empty string if p
+// is not in a repository that the go command knows how to stamp.
+// If the repository status cannot be determined, vcsBuildInfo sets p.Error
+// when -buildvcs was set explicitly, and otherwise omits the information.
+//
+// Information is only recorded for main packages in the main module
+// when both the package and its module are inside the repository
+// containing the current directory.
+func (p *Package) vcsBuildInfo() string {
+	if p.Standard || p.Module == nil || !p.Module.Main || p.Module.Dir == "" {
+		return ""
+	}
+	vcsCmd, repoDir, err := vcs.FindRoot(base.Cwd())
+	if err != nil || vcsCmd.Status == nil {
+		return ""
+	}
+	if !str.HasFilePathPrefix(p.Dir, repoDir) || !str.HasFilePathPrefix(p.Module.Dir, repoDir) {
+		return ""
+	}
+	r := vcsStatusCache.Do(repoDir, func() interface{} {
+		if _, err := exec.LookPath(vcsCmd.Cmd); err != nil {
+			return vcsStatus{err: err}
+		}
+		st, err := vcsCmd.Status(vcsCmd, repoDir)
+		return vcsStatus{st, err}
+	}).(vcsStatus)
+	if r.err != nil {
+		if cfg.BuildBuildvcsExplicit {
+			p.Error = &PackageError{
+				ImportStack: []string{p.ImportPath},
+				Err:         fmt.Errorf("error obtaining VCS status: %v\n\tUse -buildvcs=false to disable VCS stamping.", r.err),
+			}
+		}
+		return ""
+	}
+	st := r.st
+
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "build\tvcs=%s\n", vcsCmd.Cmd)
+	if st.Revision != "" {
+		fmt.Fprintf(&buf, "build\tvcs.revision=%s\n", st.Revision)
+	}
+	if !st.CommitTime.IsZero() {
+		fmt.Fprintf(&buf, "build\tvcs.time=%s\n", st.CommitTime.UTC().Format(time.RFC3339Nano))
+	}
+	fmt.Fprintf(&buf, "build\tvcs.modified=%t\n", st.Uncommitted)
+	return buf.String()
+}
+
 // collectDeps populates p.Deps and p.DepsErrors by iterating over
 // p.Internal.Imports.
 //
@@ -2411,6 +2485,11 @@ type PackageOpts struct {
 	// are not be matched, and their dependencies may not be loaded. A warning
 	// may be printed for non-literal arguments that match no main packages.
 	MainOnly bool
+
+	// LoadVCS controls whether main packages in the main module are
+	// stamped with version control information (subject to the
+	// -buildvcs flag).
+	LoadVCS bool
 }
 
 // PackagesAndErrors returns the packages named by the command line arguments
@@ -2806,7 +2885,7 @@ func PackagesAndErrorsOutsideModule(ctx context.Context, opts PackageOpts, args
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", args[0], err)
 	}
-	f, err := modfile.Parse("go.mod", data, nil)
+	f, err := modload.ParseGoMod("go.mod", data, nil)
 	if err != nil {
 		return nil, fmt.Errorf("%s (in %s): %w", args[0], rootMod, err)
 	}
@@ -269,6 +269,11 @@ func TestPackagesAndErrors(ctx context.Context, opts PackageOpts, p *Package, co
 			OrigImportPath: p.Internal.OrigImportPath,
 		},
 	}
+	var mainDirectives []build.Directive
+	if p.Name == "main" {
+		mainDirectives = p.Internal.Build.Directives
+	}
+	pmain.DefaultGODEBUG = defaultGODEBUG(pmain, mainDirectives, p.Internal.Build.TestDirectives, p.Internal.Build.XTestDirectives)
 
 	// The generated main also imports testing, regexp, and os.
 	// Also the linker introduces implicit dependencies reported by LinkerDeps.
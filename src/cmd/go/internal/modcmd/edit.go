@@ -43,6 +43,12 @@ flags are specified, as in 'go mod edit -fmt'.
 
 The -module flag changes the module's path (the go.mod file's module line).
 
+The -godebug=key=value flag adds a godebug key=value line,
+replacing any existing godebug lines with the given key.
+
+The -dropgodebug=key flag drops any existing godebug lines
+with the given key.
+
 The -require=path@version and -droprequire=path flags
 add and drop a requirement on the given module path and version.
 Note that -require overrides any existing requirements on path.
@@ -72,9 +78,9 @@ retraction on the given version. The version may be a single version
 like "v1.2.3" or a closed interval like "[v1.1.0,v1.1.9]". Note that
 -retract=version is a no-op if that retraction already exists.
 
-The -require, -droprequire, -exclude, -dropexclude, -replace,
--dropreplace, -retract, and -dropretract editing flags may be repeated,
-and the changes are applied in the order given.
+The -godebug, -dropgodebug, -require, -droprequire, -exclude, -dropexclude,
+-replace, -dropreplace, -retract, and -dropretract editing flags may be
+repeated, and the changes are applied in the order given.
 
 The -go=version flag sets the expected Go language version.
 
@@ -92,6 +98,7 @@ writing it back to go.mod. The JSON output corresponds to these Go types:
 	type GoMod struct {
 		Module  ModPath
 		Go      string
+		Godebug []Godebug
 		Require []Require
 		Exclude []Module
 		Replace []Replace
@@ -103,6 +110,11 @@ writing it back to go.mod. The JSON output corresponds to these Go types:
 		Deprecated string
 	}
 
+	type Godebug struct {
+		Key   string
+		Value string
+	}
+
 	type Require struct {
 		Path string
 		Version string
@@ -148,6 +160,8 @@ func (f flagFunc) Set(s string) error { f(s); return nil }
 func init() {
 	cmdEdit.Run = runEdit // break init cycle
 
+	cmdEdit.Flag.Var(flagFunc(flagGodebug), "godebug", "")
+	cmdEdit.Flag.Var(flagFunc(flagDropGodebug), "dropgodebug", "")
 	cmdEdit.Flag.Var(flagFunc(flagRequire), "require", "")
 	cmdEdit.Flag.Var(flagFunc(flagDropRequire), "droprequire", "")
 	cmdEdit.Flag.Var(flagFunc(flagExclude), "exclude", "")
@@ -205,7 +219,7 @@ func runEdit(ctx context.Context, cmd *base.Command, args []string) {
 		base.Fatalf("go: %v", err)
 	}
 
-	modFile, err := modfile.Parse(gomod, data, nil)
+	modFile, err := modload.ParseGoMod(gomod, data, nil)
 	if err != nil {
 		base.Fatalf("go: errors parsing %s:\n%s", base.ShortPath(gomod), err)
 	}
@@ -453,10 +467,30 @@ func flagDropRetract(arg string) {
 	})
 }
 
+// flagGodebug implements the -godebug flag.
+func flagGodebug(arg string) {
+	i := strings.Index(arg, "=")
+	if i < 0 || strings.ContainsAny(arg, "\"`',") {
+		base.Fatalf("go mod: -godebug=%s: need key=value", arg)
+	}
+	key, value := arg[:i], arg[i+1:]
+	edits = append(edits, func(f *modfile.File) {
+		modload.AddModFileGodebug(f, key, value)
+	})
+}
+
+// flagDropGodebug implements the -dropgodebug flag.
+func flagDropGodebug(arg string) {
+	edits = append(edits, func(f *modfile.File) {
+		modload.DropModFileGodebug(f, arg)
+	})
+}
+
 // fileJSON is the -json output data structure.
 type fileJSON struct {
 	Module  editModuleJSON
-	Go      string `json:",omitempty"`
+	Go      string        `json:",omitempty"`
+	Godebug []godebugJSON `json:",omitempty"`
 	Require []requireJSON
 	Exclude []module.Version
 	Replace []replaceJSON
@@ -468,6 +502,11 @@ type editModuleJSON struct {
 	Deprecated string `json:",omitempty"`
 }
 
+type godebugJSON struct {
+	Key   string
+	Value string
+}
+
 type requireJSON struct {
 	Path     string
 	Version  string `json:",omitempty"`
@@ -497,6 +536,9 @@ func editPrintJSON(modFile *modfile.File) {
 	if modFile.Go != nil {
 		f.Go = modFile.Go.Version
 	}
+	for _, g := range modload.ModFileGodebugs(modFile) {
+		f.Godebug = append(f.Godebug, godebugJSON{g.Key, g.Value})
+	}
 	for _, r := range modFile.Require {
 		f.Require = append(f.Require, requireJSON{Path: r.Mod.Path, Version: r.Mod.Version, Indirect: r.Indirect})
 	}
@@ -28,6 +28,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"go/build"
 	"os"
 	"path/filepath"
 	"runtime"
@@ -52,7 +53,7 @@ import (
 var CmdGet = &base.Command{
 	// Note: -d -u are listed explicitly because they are the most common get flags.
 	// Do not send CLs removing them because they're covered by [get flags].
-	UsageLine: "go get [-d] [-t] [-u] [-v] [build flags] [packages]",
+	UsageLine: "go get [-d] [-t] [-u] [-v] [-tool] [build flags] [packages]",
 	Short:     "add dependencies to current module and install them",
 	Long: `
 Get resolves its command-line arguments to packages at specific module versions,
@@ -101,6 +102,14 @@ test dependencies as well.
 The -d flag instructs get not to build or install packages. get will only
 update go.mod and download source code needed to build packages.
 
+The -tool flag instructs get to add a tool directive to go.mod for each
+named main package, recording it as a tool of the current module, or,
+for arguments of the form path@none, to remove the tool directive
+for path without otherwise changing the module's requirements.
+The -tool flag implies -d. Tools are run with 'go tool', and the
+pattern "tool" names all of them; for example, 'go get -tool tool'
+upgrades every tool. See 'go help tool'.
+
 Building and installing packages with get is deprecated. In a future release,
 the -d flag will be enabled by default, and 'go get' will be only be used to
 adjust dependencies of the current module. To install a package using
@@ -225,6 +234,7 @@ var (
 	getT        = CmdGet.Flag.Bool("t", false, "")
 	getU        upgradeFlag
 	getInsecure = CmdGet.Flag.Bool("insecure", false, "")
+	getTool     = CmdGet.Flag.Bool("tool", false, "")
 	// -v is cfg.BuildV
 )
 
@@ -289,6 +299,28 @@ func runGet(ctx context.Context, cmd *base.Command, args []string) {
 
 	queries := parseArgs(ctx, args)
 
+	// With -tool, path@none removes a tool directive. It does not
+	// remove the module providing the tool, which may be needed by
+	// the main module or other tools.
+	var dropTools []string
+	if *getTool {
+		if !modload.HasModRoot() {
+			base.Fatalf("go get: -tool cannot be used outside a module")
+		}
+		var keep []*query
+		for _, q := range queries {
+			if q.version == "none" {
+				if q.isWildcard() || search.IsMetaPackage(q.pattern) {
+					base.Fatalf("go get: -tool: cannot remove tools matching %s", q.pattern)
+				}
+				dropTools = append(dropTools, q.pattern)
+				continue
+			}
+			keep = append(keep, q)
+		}
+		queries = keep
+	}
+
 	r := newResolver(ctx, queries)
 	r.performLocalQueries(ctx)
 	r.performPathQueries(ctx)
@@ -356,6 +388,10 @@ func runGet(ctx context.Context, cmd *base.Command, args []string) {
 	}
 	r.checkPackageProblems(ctx, pkgPatterns)
 
+	if *getTool {
+		updateTools(ctx, pkgPatterns, dropTools)
+	}
+
 	// We've already downloaded modules (and identified direct and indirect
 	// dependencies) by loading packages in findAndUpgradeImports.
 	// So if -d is set, we're done after the module work.
@@ -365,7 +401,7 @@ func runGet(ctx context.Context, cmd *base.Command, args []string) {
 	// Note that 'go get -u' without arguments is equivalent to
 	// 'go get -u .', so we'll typically build the package in the current
 	// directory.
-	if !*getD && len(pkgPatterns) > 0 {
+	if !*getD && !*getTool && len(pkgPatterns) > 0 {
 		work.BuildInit()
 
 		pkgOpts := load.PackageOpts{ModResolveTests: *getT}
@@ -429,6 +465,36 @@ func runGet(ctx context.Context, cmd *base.Command, args []string) {
 	r.reportChanges(oldReqs, newReqs)
 }
 
+// updateTools adds tool directives to the main module for the main packages
+// matching pkgPatterns, and removes the tool directives for dropTools.
+func updateTools(ctx context.Context, pkgPatterns, dropTools []string) {
+	for _, path := range dropTools {
+		modload.DropTool(path)
+	}
+	if len(pkgPatterns) == 0 {
+		return
+	}
+
+	// Load the packages to check that they are commands. The tool
+	// directives for literal paths are added first, so that loading
+	// records the modules providing them as direct dependencies.
+	for _, pattern := range pkgPatterns {
+		if !strings.Contains(pattern, "...") && !search.IsMetaPackage(pattern) && !build.IsLocalImport(pattern) && !filepath.IsAbs(pattern) {
+			modload.AddTool(pattern)
+		}
+	}
+	pkgs := load.PackagesAndErrors(ctx, load.PackageOpts{}, pkgPatterns)
+	load.CheckPackageErrors(pkgs)
+	for _, pkg := range pkgs {
+		if pkg.Name != "main" {
+			base.Errorf("go get: -tool: %s is not a main package", pkg.ImportPath)
+			continue
+		}
+		modload.AddTool(pkg.ImportPath)
+	}
+	base.ExitIfErrors()
+}
+
 // parseArgs parses command-line arguments and reports errors.
 //
 // The command-line arguments are of the form path@version or simply path, with
@@ -450,6 +516,23 @@ func parseArgs(ctx context.Context, rawArgs []string) []*query {
 			q.raw = ""
 		}
 
+		// "tool" stands for each tool of the main module.
+		if q.pattern == "tool" {
+			suffix := ""
+			if q.rawVersion != "" {
+				suffix = "@" + q.rawVersion
+			}
+			for _, path := range modload.Tools(ctx) {
+				tq, err := newQuery(path + suffix)
+				if err != nil {
+					base.Errorf("go get: %v", err)
+					continue
+				}
+				queries = append(queries, tq)
+			}
+			continue
+		}
+
 		// Guard against 'go get x.go', a common mistake.
 		// Note that package and module paths may end with '.go', so only print an error
 		// if the argument has no version and either has no slash or refers to an existing file.
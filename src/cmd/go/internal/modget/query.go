@@ -199,6 +199,14 @@ func (q *query) validate() error {
 		}
 	}
 
+	if q.pattern == "tool" {
+		// "tool" is expanded to the tools of the main module by parseArgs.
+		if !modload.HasModRoot() {
+			return fmt.Errorf(`cannot match "tool": %v`, modload.ErrNoModRoot)
+		}
+		return nil
+	}
+
 	if search.IsMetaPackage(q.pattern) && q.pattern != "all" {
 		if q.pattern != q.raw {
 			return fmt.Errorf("can't request explicit version of standard-library pattern %q", q.pattern)
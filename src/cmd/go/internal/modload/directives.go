@@ -0,0 +1,354 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package modload
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+)
+
+// This file implements the go.mod directives that the vendored copy of
+// golang.org/x/mod/modfile does not know about. ParseGoMod hides them from
+// modfile.Parse, checks them itself, and then splices their syntax back into
+// the parsed file, so that formatting and editing the file preserves them.
+// Their values are always read back from the syntax tree, which keeps them
+// in sync with edits and with (*modfile.File).Cleanup.
+
+// extVerbs lists the directives handled by ParseGoMod instead of modfile.Parse.
+var extVerbs = map[string]bool{
+	"godebug": true,
+	"tool":    true,
+}
+
+// ParseGoMod is like modfile.Parse, but additionally accepts the directives
+// listed in extVerbs.
+func ParseGoMod(file string, data []byte, fix modfile.VersionFixer) (*modfile.File, error) {
+	f, err := modfile.Parse(file, data, fix)
+	if err == nil {
+		return f, nil
+	}
+
+	// ParseLax reports no errors for directives it does not know, so a
+	// failure here is a genuine error. Report it as modfile.Parse would,
+	// less the complaints about our own directives.
+	lax, laxErr := modfile.ParseLax(file, data, fix)
+	if laxErr != nil {
+		return nil, dropExtErrors(err)
+	}
+
+	var ext []modfile.Expr
+	blanked := append([]byte(nil), data...)
+	for _, stmt := range lax.Syntax.Stmt {
+		if !isExtStmt(stmt) {
+			continue
+		}
+		ext = append(ext, stmt)
+		// Blank out the statement and its comments, keeping newlines so
+		// that the positions reported for the rest of the file stay valid.
+		start, end := stmtBytes(stmt)
+		for i := start; i < end; i++ {
+			if blanked[i] != '\n' {
+				blanked[i] = ' '
+			}
+		}
+	}
+	if len(ext) == 0 {
+		return nil, err
+	}
+
+	f, err = modfile.Parse(file, blanked, fix)
+	if err != nil {
+		return nil, err
+	}
+	var errs modfile.ErrorList
+	for _, stmt := range ext {
+		checkExtStmt(&errs, file, stmt)
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	f.Syntax.Stmt = append(f.Syntax.Stmt, ext...)
+	sort.SliceStable(f.Syntax.Stmt, func(i, j int) bool {
+		si, _ := f.Syntax.Stmt[i].Span()
+		sj, _ := f.Syntax.Stmt[j].Span()
+		return si.Byte < sj.Byte
+	})
+	return f, nil
+}
+
+// dropExtErrors removes from err, as returned by modfile.Parse, the errors
+// about the directives in extVerbs being unknown.
+func dropExtErrors(err error) error {
+	list, ok := err.(modfile.ErrorList)
+	if !ok {
+		return err
+	}
+	var errs modfile.ErrorList
+	for _, e := range list {
+		msg := e.Err.Error()
+		if v := strings.TrimPrefix(msg, "unknown directive: "); extVerbs[v] {
+			continue
+		}
+		if v := strings.TrimPrefix(msg, "unknown block type: "); extVerbs[v] {
+			continue
+		}
+		errs = append(errs, e)
+	}
+	if len(errs) == 0 {
+		return err
+	}
+	return errs
+}
+
+// isExtStmt reports whether stmt is a statement or block for one of the
+// directives in extVerbs.
+func isExtStmt(stmt modfile.Expr) bool {
+	switch stmt := stmt.(type) {
+	case *modfile.Line:
+		return len(stmt.Token) > 0 && extVerbs[stmt.Token[0]]
+	case *modfile.LineBlock:
+		return len(stmt.Token) > 0 && extVerbs[stmt.Token[0]]
+	}
+	return false
+}
+
+// stmtBytes returns the byte range in the file covered by stmt,
+// including its comments.
+func stmtBytes(stmt modfile.Expr) (start, end int) {
+	s, e := stmt.Span()
+	start, end = s.Byte, e.Byte
+	cover := func(c *modfile.Comments) {
+		for _, list := range [][]modfile.Comment{c.Before, c.Suffix, c.After} {
+			for _, com := range list {
+				if com.Start.Byte < start {
+					start = com.Start.Byte
+				}
+				if e := com.Start.Byte + len(com.Token); e > end {
+					end = e
+				}
+			}
+		}
+	}
+	cover(stmt.Comment())
+	if block, ok := stmt.(*modfile.LineBlock); ok {
+		cover(&block.LParen.Comments)
+		cover(&block.RParen.Comments)
+		for _, l := range block.Line {
+			cover(&l.Comments)
+		}
+	}
+	return start, end
+}
+
+// checkExtStmt appends to errs any errors in the statement or block stmt.
+func checkExtStmt(errs *modfile.ErrorList, file string, stmt modfile.Expr) {
+	switch stmt := stmt.(type) {
+	case *modfile.Line:
+		checkExtLine(errs, file, stmt, stmt.Token[0], stmt.Token[1:])
+	case *modfile.LineBlock:
+		if len(stmt.Token) > 1 {
+			*errs = append(*errs, modfile.Error{
+				Filename: file,
+				Pos:      stmt.Start,
+				Err:      fmt.Errorf("unknown block type: %s", strings.Join(stmt.Token, " ")),
+			})
+			return
+		}
+		for _, l := range stmt.Line {
+			checkExtLine(errs, file, l, stmt.Token[0], l.Token)
+		}
+	}
+}
+
+func checkExtLine(errs *modfile.ErrorList, file string, line *modfile.Line, verb string, args []string) {
+	wrapError := func(err error) {
+		*errs = append(*errs, modfile.Error{
+			Filename: file,
+			Pos:      line.Start,
+			Err:      err,
+		})
+	}
+	errorf := func(format string, args ...interface{}) {
+		wrapError(fmt.Errorf(format, args...))
+	}
+
+	switch verb {
+	case "tool":
+		if len(args) != 1 {
+			errorf("tool directive expects exactly one argument")
+			return
+		}
+		s, err := parseString(args[0])
+		if err != nil {
+			errorf("invalid quoted string: %v", err)
+			return
+		}
+		if err := module.CheckImportPath(s); err != nil {
+			wrapError(err)
+		}
+
+	case "godebug":
+		if len(args) != 1 || strings.ContainsAny(args[0], "\"`',") || !strings.Contains(args[0], "=") {
+			errorf("usage: godebug key=value")
+		}
+	}
+}
+
+// parseString returns the value of the go.mod token s,
+// which may be a quoted string.
+func parseString(s string) (string, error) {
+	if strings.HasPrefix(s, `"`) || strings.HasPrefix(s, "`") {
+		return strconv.Unquote(s)
+	}
+	return s, nil
+}
+
+// directiveLines calls fn for each live verb line in f, passing the line's
+// arguments. If fn returns false, the line is marked for removal
+// by the next call to (*modfile.File).Cleanup.
+func directiveLines(f *modfile.File, verb string, fn func(line *modfile.Line, args []string) (keep bool)) {
+	remove := func(line *modfile.Line) {
+		line.Token = nil
+		line.Comments.Suffix = nil
+	}
+	for _, stmt := range f.Syntax.Stmt {
+		switch stmt := stmt.(type) {
+		case *modfile.Line:
+			if len(stmt.Token) > 0 && stmt.Token[0] == verb && !fn(stmt, stmt.Token[1:]) {
+				remove(stmt)
+			}
+		case *modfile.LineBlock:
+			if len(stmt.Token) == 1 && stmt.Token[0] == verb {
+				for _, l := range stmt.Line {
+					if l.Token != nil && !fn(l, l.Token) {
+						remove(l)
+					}
+				}
+			}
+		}
+	}
+}
+
+// addDirectiveLine adds a line with the given tokens to f, after the last
+// existing line for the same verb (tokens[0]) or else at the end of the file.
+func addDirectiveLine(f *modfile.File, tokens ...string) {
+	stmts := f.Syntax.Stmt
+	for i := len(stmts) - 1; i >= 0; i-- {
+		switch stmt := stmts[i].(type) {
+		case *modfile.Line:
+			if len(stmt.Token) > 0 && stmt.Token[0] == tokens[0] {
+				// Convert line to line block.
+				stmt.InBlock = true
+				block := &modfile.LineBlock{Token: stmt.Token[:1], Line: []*modfile.Line{stmt}}
+				stmt.Token = stmt.Token[1:]
+				block.Line = append(block.Line, &modfile.Line{Token: tokens[1:], InBlock: true})
+				stmts[i] = block
+				return
+			}
+		case *modfile.LineBlock:
+			if len(stmt.Token) == 1 && stmt.Token[0] == tokens[0] {
+				stmt.Line = append(stmt.Line, &modfile.Line{Token: tokens[1:], InBlock: true})
+				return
+			}
+		}
+	}
+	f.Syntax.Stmt = append(stmts, &modfile.Line{Token: tokens})
+}
+
+// ModFileTools returns the package paths named by tool directives in f,
+// in the order in which they appear.
+func ModFileTools(f *modfile.File) []string {
+	var paths []string
+	directiveLines(f, "tool", func(_ *modfile.Line, args []string) bool {
+		if len(args) == 1 {
+			if path, err := parseString(args[0]); err == nil {
+				paths = append(paths, path)
+			}
+		}
+		return true
+	})
+	return paths
+}
+
+// addModFileTool adds a tool directive for path to f,
+// if one is not already present.
+func addModFileTool(f *modfile.File, path string) {
+	for _, p := range ModFileTools(f) {
+		if p == path {
+			return
+		}
+	}
+	addDirectiveLine(f, "tool", modfile.AutoQuote(path))
+}
+
+// dropModFileTool removes the tool directives for path, if any, from f.
+func dropModFileTool(f *modfile.File, path string) {
+	directiveLines(f, "tool", func(_ *modfile.Line, args []string) bool {
+		if len(args) != 1 {
+			return true
+		}
+		p, err := parseString(args[0])
+		return err != nil || p != path
+	})
+}
+
+// A Godebug is a single godebug key=value line in a go.mod file.
+type Godebug struct {
+	Key    string
+	Value  string
+	Syntax *modfile.Line
+}
+
+// ModFileGodebugs returns the godebug lines in f,
+// in the order in which they appear.
+func ModFileGodebugs(f *modfile.File) []*Godebug {
+	var list []*Godebug
+	directiveLines(f, "godebug", func(line *modfile.Line, args []string) bool {
+		if len(args) == 1 {
+			if i := strings.Index(args[0], "="); i >= 0 {
+				list = append(list, &Godebug{Key: args[0][:i], Value: args[0][i+1:], Syntax: line})
+			}
+		}
+		return true
+	})
+	return list
+}
+
+// AddModFileGodebug sets the first godebug line for key in f to value,
+// preserving any existing comments for that line and removing all
+// other godebug lines for key.
+//
+// If no line currently exists for key, AddModFileGodebug adds a new line
+// at the end of the last godebug block.
+func AddModFileGodebug(f *modfile.File, key, value string) {
+	found := false
+	directiveLines(f, "godebug", func(line *modfile.Line, args []string) bool {
+		if len(args) != 1 || !strings.HasPrefix(args[0], key+"=") {
+			return true
+		}
+		if found {
+			return false
+		}
+		found = true
+		line.Token[len(line.Token)-1] = key + "=" + value
+		return true
+	})
+	if !found {
+		addDirectiveLine(f, "godebug", key+"="+value)
+	}
+}
+
+// DropModFileGodebug removes all the godebug lines for key from f.
+func DropModFileGodebug(f *modfile.File, key string) {
+	directiveLines(f, "godebug", func(_ *modfile.Line, args []string) bool {
+		return len(args) != 1 || !strings.HasPrefix(args[0], key+"=")
+	})
+}
@@ -57,6 +57,33 @@ To add, upgrade, downgrade, or remove a specific module requirement, use
 'go get'. For details, see 'go help module-get' or
 https://golang.org/ref/mod#go-get.
 
+A tool directive records a command that the module uses as a tool,
+such as a code generator, by its package path:
+
+	tool golang.org/x/tools/cmd/stringer
+
+The tool's module is required like any other dependency, and the
+tool's packages are part of the "all" pattern, so 'go mod tidy' keeps
+them. Use 'go get -tool' to add or remove tool directives, 'go tool'
+to run a tool, and the "tool" pattern (as in 'go list tool') to name
+all of the tools.
+
+A godebug line sets the default value of a GODEBUG setting for the
+main packages built in the module, overriding the default implied by
+the go line, which restores the behavior of the Go release it names:
+
+	godebug http2client=0
+
+Several settings may be grouped in a block, and 'godebug default=go1.N'
+selects the defaults of Go 1.N instead of those of the go line.
+A main package can change the defaults further with //go:debug
+directives before its package clause, such as
+
+	//go:debug http2client=0
+
+The GODEBUG environment variable overrides all of these at run time.
+Use 'go list -f {{.DefaultGODEBUG}}' to see the defaults for a package.
+
 To make other changes or to parse go.mod as JSON for use by other tools,
 use 'go mod edit'. See 'go help mod edit' or
 https://golang.org/ref/mod#go-mod-edit.
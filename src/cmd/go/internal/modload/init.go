@@ -427,9 +427,9 @@ func loadModFile(ctx context.Context) (rs *Requirements, needCommit bool) {
 	}
 
 	var fixed bool
-	f, err := modfile.Parse(gomod, data, fixVersion(ctx, &fixed))
+	f, err := ParseGoMod(gomod, data, fixVersion(ctx, &fixed))
 	if err != nil {
-		// Errors returned by modfile.Parse begin with file:line.
+		// Errors returned by ParseGoMod begin with file:line.
 		base.Fatalf("go: errors parsing go.mod:\n%s\n", err)
 	}
 	if f.Module == nil {
@@ -448,6 +448,12 @@ func loadModFile(ctx context.Context) (rs *Requirements, needCommit bool) {
 		base.Fatalf("go: %v", err)
 	}
 
+	for _, g := range ModFileGodebugs(f) {
+		if err := CheckGodebug("godebug", g.Key, g.Value); err != nil {
+			base.Fatalf("go: errors parsing go.mod:\n%s:%d: invalid godebug: %v\n", gomod, g.Syntax.Start.Line, err)
+		}
+	}
+
 	setDefaultBuildMod() // possibly enable automatic vendoring
 	rs = requirementsFromModFile()
 	if cfg.BuildMod == "vendor" {
@@ -307,6 +307,8 @@ func LoadPackages(ctx context.Context, opts PackageOpts, patterns ...string) (ma
 					// loadFromRoots will expand that to "all".
 					m.Errs = m.Errs[:0]
 					matchPackages(ctx, m, opts.Tags, omitStd, []module.Version{Target})
+					// Tools of the main module are also in "all".
+					m.Pkgs = append(m.Pkgs, toolPackages()...)
 				} else {
 					// Starting with the packages in the main module,
 					// enumerate the full list of "all".
@@ -318,6 +320,9 @@ func LoadPackages(ctx context.Context, opts PackageOpts, patterns ...string) (ma
 					m.MatchPackages() // Locate the packages within GOROOT/src.
 				}
 
+			case m.Pattern() == "tool":
+				m.Pkgs = toolPackages()
+
 			default:
 				panic(fmt.Sprintf("internal error: modload missing case for pattern %s", m.Pattern()))
 			}
@@ -1204,6 +1209,14 @@ func (ld *loader) updateRequirements(ctx context.Context) (changed bool, err err
 		}
 	}
 
+	// Modules providing tools of the main module are direct dependencies
+	// too, as if the main module imported the tool packages.
+	for _, pkg := range ld.pkgs {
+		if pkg.fromExternalModule() && !pkg.isTest() && isTool(pkg.path) {
+			direct[pkg.mod.Path] = true
+		}
+	}
+
 	var addRoots []module.Version
 	if ld.Tidy {
 		// When we are tidying a lazy module, we may need to add roots to preserve
@@ -1593,7 +1606,7 @@ func (ld *loader) load(ctx context.Context, pkg *loadPkg) {
 	if pkg.dir == "" {
 		return
 	}
-	if pkg.mod == Target {
+	if pkg.mod == Target || isTool(pkg.path) {
 		// Go ahead and mark pkg as in "all". This provides the invariant that a
 		// package that is *only* imported by other packages in "all" is always
 		// marked as such before loading its imports.
@@ -8,8 +8,10 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"internal/godebugs"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 	"unicode"
@@ -90,6 +92,7 @@ type modFileIndex struct {
 	replace         map[module.Version]module.Version
 	highestReplaced map[string]string // highest replaced version of each module path; empty string for wildcard-only replacements
 	exclude         map[module.Version]bool
+	tool            map[string]bool
 }
 
 // index is the index of the go.mod file as of when it was last read or written.
@@ -379,6 +382,12 @@ func indexModFile(data []byte, modFile *modfile.File, needsFix bool) *modFileInd
 		i.exclude[x.Mod] = true
 	}
 
+	tools := ModFileTools(modFile)
+	i.tool = make(map[string]bool, len(tools))
+	for _, path := range tools {
+		i.tool[path] = true
+	}
+
 	return i
 }
 
@@ -417,9 +426,11 @@ func (i *modFileIndex) modFileIsDirty(modFile *modfile.File) bool {
 		}
 	}
 
+	tools := ModFileTools(modFile)
 	if len(modFile.Require) != len(i.require) ||
 		len(modFile.Replace) != len(i.replace) ||
-		len(modFile.Exclude) != len(i.exclude) {
+		len(modFile.Exclude) != len(i.exclude) ||
+		len(tools) != len(i.tool) {
 		return true
 	}
 
@@ -450,9 +461,101 @@ func (i *modFileIndex) modFileIsDirty(modFile *modfile.File) bool {
 		}
 	}
 
+	for _, path := range tools {
+		if !i.tool[path] {
+			return true
+		}
+	}
+
 	return false
 }
 
+// Tools returns the package paths named by tool directives in the
+// main module's go.mod file, in sorted order.
+func Tools(ctx context.Context) []string {
+	LoadModFile(ctx)
+	return toolPackages()
+}
+
+// toolPackages is like Tools, but assumes that the go.mod file
+// has already been loaded.
+func toolPackages() []string {
+	pkgs := []string{}
+	if modFile == nil {
+		return pkgs
+	}
+	pkgs = append(pkgs, ModFileTools(modFile)...)
+	sort.Strings(pkgs)
+	return pkgs
+}
+
+// isTool reports whether path is named by a tool directive
+// in the main module's go.mod file.
+func isTool(path string) bool {
+	if modFile == nil {
+		return false
+	}
+	for _, p := range ModFileTools(modFile) {
+		if p == path {
+			return true
+		}
+	}
+	return false
+}
+
+// AddTool adds a tool directive for the package path to the main module's
+// go.mod file. The change is written by the next call to WriteGoMod.
+func AddTool(path string) {
+	addModFileTool(ModFile(), path)
+}
+
+// DropTool removes the tool directive for the package path, if any, from
+// the main module's go.mod file. The change is written by the next call
+// to WriteGoMod.
+func DropTool(path string) {
+	dropModFileTool(ModFile(), path)
+}
+
+// MainModuleGoVersion returns the Go version declared by the main module's
+// go.mod file, or the latest Go version if there is no main module.
+func MainModuleGoVersion() string {
+	return modFileGoVersion()
+}
+
+// Godebugs returns the godebug lines in the main module's go.mod file,
+// in the order in which they appear.
+func Godebugs() []*Godebug {
+	if modFile == nil {
+		return nil
+	}
+	return ModFileGodebugs(modFile)
+}
+
+// CheckGodebug checks that the setting k=v, found in a godebug line of
+// a go.mod file or in a //go:debug directive (as described by verb),
+// names a known GODEBUG setting or is a valid default=goVERSION line.
+func CheckGodebug(verb, k, v string) error {
+	if strings.ContainsAny(k, " \t,") {
+		return fmt.Errorf("key contains space or comma")
+	}
+	if strings.ContainsAny(v, " \t,") {
+		return fmt.Errorf("value contains space or comma")
+	}
+	if k == "default" {
+		if !strings.HasPrefix(v, "go") || !modfile.GoVersionRE.MatchString(v[len("go"):]) {
+			return fmt.Errorf("value for default= must be goVERSION")
+		}
+		if semver.Compare("v"+v[len("go"):], "v"+LatestGoVersion()) > 0 {
+			return fmt.Errorf("default=%s too new (toolchain is go%s)", v, LatestGoVersion())
+		}
+		return nil
+	}
+	if godebugs.Lookup(k) == nil {
+		return fmt.Errorf("unknown %s %q", verb, k)
+	}
+	return nil
+}
+
 // rawGoVersion records the Go version parsed from each module's go.mod file.
 //
 // If a module is replaced, the version of the replacement is keyed by the
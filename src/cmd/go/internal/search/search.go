@@ -59,14 +59,14 @@ func (m *Match) IsLocal() bool {
 }
 
 // Meta reports whether the pattern is a “meta-package” keyword that represents
-// multiple packages, such as "std", "cmd", or "all".
+// multiple packages, such as "std", "cmd", "all", or "tool".
 func (m *Match) IsMeta() bool {
 	return IsMetaPackage(m.pattern)
 }
 
 // IsMetaPackage checks if name is a reserved package name that expands to multiple packages.
 func IsMetaPackage(name string) bool {
-	return name == "std" || name == "cmd" || name == "all"
+	return name == "std" || name == "cmd" || name == "all" || name == "tool"
 }
 
 // A MatchError indicates an error that occurred while attempting to match a
@@ -106,6 +106,11 @@ func (m *Match) MatchPackages() {
 		return
 	}
 
+	if m.pattern == "tool" {
+		m.AddError(fmt.Errorf("the \"tool\" pattern is only meaningful in module-aware mode"))
+		return
+	}
+
 	match := func(string) bool { return true }
 	treeCanMatch := func(string) bool { return true }
 	if !m.IsMeta() {
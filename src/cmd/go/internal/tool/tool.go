@@ -7,15 +7,22 @@ package tool
 
 import (
 	"context"
+	"crypto/sha256"
 	"fmt"
 	exec "internal/execabs"
 	"os"
 	"os/signal"
+	"path"
+	"path/filepath"
 	"sort"
 	"strings"
 
 	"cmd/go/internal/base"
+	"cmd/go/internal/cache"
 	"cmd/go/internal/cfg"
+	"cmd/go/internal/load"
+	"cmd/go/internal/modload"
+	"cmd/go/internal/work"
 )
 
 var CmdTool = &base.Command{
@@ -30,6 +37,15 @@ The -n flag causes tool to print the command that would be
 executed but not execute it.
 
 For more about each tool command, see 'go doc cmd/<command>'.
+
+In module-aware mode, the command may also name a tool of the main
+module, recorded by a tool directive in go.mod (see 'go help get' for
+adding one with 'go get -tool'). Such a tool may be named by its full
+package path or, if no Go distribution tool has that name, by the last
+element of its path (ignoring a major version suffix such as /v2). The
+go command builds the tool using the main module's requirements,
+caches the binary in the build cache directory, and runs it. The binary
+is rebuilt only when the tool or its dependencies change.
 `,
 }
 
@@ -52,10 +68,16 @@ func init() {
 
 func runTool(ctx context.Context, cmd *base.Command, args []string) {
 	if len(args) == 0 {
-		listTools()
+		listTools(ctx)
 		return
 	}
 	toolName := args[0]
+	if !isBuiltinTool(toolName) {
+		if pkgPath := moduleTool(ctx, toolName); pkgPath != "" {
+			runModuleTool(ctx, toolName, pkgPath, args)
+			return
+		}
+	}
 	// The tool name must be lower-case letters, numbers or underscores.
 	for _, c := range toolName {
 		switch {
@@ -70,6 +92,12 @@ func runTool(ctx context.Context, cmd *base.Command, args []string) {
 	if toolPath == "" {
 		return
 	}
+	runToolBinary(toolName, toolPath, args)
+}
+
+// runToolBinary runs the tool binary at toolPath with the given arguments,
+// of which args[0] is the tool name.
+func runToolBinary(toolName, toolPath string, args []string) {
 	if toolN {
 		cmd := toolPath
 		if len(args) > 1 {
@@ -113,8 +141,97 @@ func runTool(ctx context.Context, cmd *base.Command, args []string) {
 	}
 }
 
-// listTools prints a list of the available tools in the tools directory.
-func listTools() {
+// isBuiltinTool reports whether toolName names a tool in the tool directory.
+func isBuiltinTool(toolName string) bool {
+	if strings.ContainsAny(toolName, "/\\.") {
+		return false
+	}
+	toolPath := filepath.Join(base.ToolDir, toolName)
+	if base.ToolIsWindows {
+		toolPath += base.ToolWindowsExtension
+	}
+	_, err := os.Stat(toolPath)
+	return err == nil
+}
+
+// moduleTool returns the package path of the tool of the main module
+// named by toolName, or "" if there is none.
+func moduleTool(ctx context.Context, toolName string) string {
+	if !modload.WillBeEnabled() {
+		return ""
+	}
+	modload.Init()
+	if !modload.HasModRoot() {
+		return ""
+	}
+	var match []string
+	for _, pkgPath := range modload.Tools(ctx) {
+		if pkgPath == toolName {
+			return pkgPath
+		}
+		if exeName(pkgPath) == toolName {
+			match = append(match, pkgPath)
+		}
+	}
+	if len(match) > 1 {
+		base.Fatalf("go tool: %s is ambiguous; use one of:\n\t%s", toolName, strings.Join(match, "\n\t"))
+	}
+	if len(match) == 1 {
+		return match[0]
+	}
+	return ""
+}
+
+// exeName returns the name of the executable for the package pkgPath,
+// as 'go install' would choose it.
+func exeName(pkgPath string) string {
+	elem := path.Base(pkgPath)
+	if elem != pkgPath && len(elem) > 1 && elem[0] == 'v' && strings.Trim(elem[1:], "0123456789") == "" {
+		elem = path.Base(path.Dir(pkgPath))
+	}
+	return elem
+}
+
+// runModuleTool builds the tool of the main module with package path pkgPath
+// into the build cache directory, if it is not already up to date there, and
+// runs it with the given arguments.
+func runModuleTool(ctx context.Context, toolName, pkgPath string, args []string) {
+	work.BuildInit()
+	pkgs := load.PackagesAndErrors(ctx, load.PackageOpts{MainOnly: true}, []string{pkgPath})
+	load.CheckPackageErrors(pkgs)
+	p := pkgs[0]
+
+	// Install the tool to a directory of the build cache specific to the
+	// main module and the tool, so that the build ID check in the linker
+	// action can reuse the binary from an earlier run.
+	if cache.Default() == nil {
+		base.Fatalf("go tool %s: build cache is required", toolName)
+	}
+	key := sha256.Sum256([]byte(modload.ModRoot() + "\n" + p.ImportPath))
+	p.Internal.ExeName = exeName(p.ImportPath)
+	p.Target = filepath.Join(cache.DefaultDir(), "tool", fmt.Sprintf("%x", key[:8]), p.Internal.ExeName+cfg.ExeSuffix)
+	if toolN {
+		cmd := p.Target
+		if len(args) > 1 {
+			cmd += " " + strings.Join(args[1:], " ")
+		}
+		fmt.Printf("%s\n", cmd)
+		return
+	}
+
+	var b work.Builder
+	b.Init()
+	a := b.AutoAction(work.ModeInstall, work.ModeBuild, p)
+	b.Do(ctx, a)
+	base.ExitIfErrors()
+
+	args[0] = p.Target
+	runToolBinary(toolName, p.Target, args)
+}
+
+// listTools prints a list of the available tools in the tools directory,
+// followed by the tools of the main module.
+func listTools(ctx context.Context) {
 	f, err := os.Open(base.ToolDir)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "go tool: no tool directory: %s\n", err)
@@ -144,4 +261,13 @@ func listTools() {
 		}
 		fmt.Println(name)
 	}
+
+	if modload.WillBeEnabled() {
+		modload.Init()
+		if modload.HasModRoot() {
+			for _, pkgPath := range modload.Tools(ctx) {
+				fmt.Println(pkgPath)
+			}
+		}
+	}
 }
@@ -0,0 +1,449 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package version
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// buildInfo is the build information embedded in a Go executable.
+// Its fields follow runtime/debug.BuildInfo, with the addition of the
+// Go version used to build the executable.
+type buildInfo struct {
+	GoVersion string
+	Path      string
+	Main      module
+	Deps      []*module      `json:",omitempty"`
+	Settings  []buildSetting `json:",omitempty"`
+}
+
+// module is a module recorded in the build information.
+type module struct {
+	Path    string
+	Version string
+	Sum     string  `json:",omitempty"`
+	Replace *module `json:",omitempty"`
+}
+
+// buildSetting is a single "build" line recorded in the build information.
+type buildSetting struct {
+	Key, Value string
+}
+
+// parseBuildInfo parses the module information mod found in an
+// executable built with Go version vers.
+// It is the reverse of cmd/go/internal/modload.PackageBuildInfo.
+func parseBuildInfo(vers, mod string) (*buildInfo, error) {
+	const (
+		pathLine  = "path\t"
+		modLine   = "mod\t"
+		depLine   = "dep\t"
+		repLine   = "=>\t"
+		buildLine = "build\t"
+	)
+
+	readEntry := func(line string) (*module, error) {
+		elem := strings.Split(line, "\t")
+		if len(elem) != 2 && len(elem) != 3 {
+			return nil, fmt.Errorf("malformed module line %q", line)
+		}
+		m := &module{Path: elem[0], Version: elem[1]}
+		if len(elem) == 3 {
+			m.Sum = elem[2]
+		}
+		return m, nil
+	}
+
+	info := &buildInfo{GoVersion: vers}
+	var last *module
+	for _, line := range strings.Split(strings.TrimSuffix(mod, "\n"), "\n") {
+		switch {
+		case strings.HasPrefix(line, pathLine):
+			info.Path = line[len(pathLine):]
+		case strings.HasPrefix(line, modLine):
+			m, err := readEntry(line[len(modLine):])
+			if err != nil {
+				return nil, err
+			}
+			info.Main = *m
+			last = &info.Main
+		case strings.HasPrefix(line, depLine):
+			m, err := readEntry(line[len(depLine):])
+			if err != nil {
+				return nil, err
+			}
+			info.Deps = append(info.Deps, m)
+			last = m
+		case strings.HasPrefix(line, repLine):
+			if last == nil {
+				return nil, fmt.Errorf("replacement %q without module", line)
+			}
+			m, err := readEntry(line[len(repLine):])
+			if err != nil {
+				return nil, err
+			}
+			last.Replace = m
+			last = nil
+		case strings.HasPrefix(line, buildLine):
+			kv := line[len(buildLine):]
+			i := strings.IndexByte(kv, '=')
+			if i < 1 {
+				return nil, fmt.Errorf("malformed build setting %q", line)
+			}
+			info.Settings = append(info.Settings, buildSetting{Key: kv[:i], Value: kv[i+1:]})
+		}
+	}
+	if info.Path == "" {
+		return nil, errors.New("missing main package path")
+	}
+	return info, nil
+}
+
+// setting returns the value of the build setting key, or "" if unset.
+func (info *buildInfo) setting(key string) string {
+	for _, s := range info.Settings {
+		if s.Key == key {
+			return s.Value
+		}
+	}
+	return ""
+}
+
+// created returns the time to record as the creation time of an SBOM
+// for info. The commit time of the main module is used when known,
+// so that the document is reproducible for a given checkout.
+func (info *buildInfo) created() string {
+	if t, err := time.Parse(time.RFC3339Nano, info.setting("vcs.time")); err == nil {
+		return t.UTC().Format(time.RFC3339)
+	}
+	return time.Now().UTC().Format(time.RFC3339)
+}
+
+// digest returns a hash identifying the contents of info.
+func (info *buildInfo) digest() [sha256.Size]byte {
+	js, _ := json.Marshal(info)
+	return sha256.Sum256(js)
+}
+
+// effective returns the module that provided the code for m:
+// its replacement, if any, and m itself otherwise.
+func (m *module) effective() *module {
+	if m.Replace != nil {
+		return m.Replace
+	}
+	return m
+}
+
+// purl returns the package URL for m, or "" if m has no version
+// (for example, the main module or a directory replacement).
+func (m *module) purl() string {
+	if m.Version == "" || m.Version == "(devel)" {
+		return ""
+	}
+	return goPURL(m.Path, m.Version)
+}
+
+// goPURL returns the package URL for version of the module or
+// pseudo-module path, following the golang purl type.
+func goPURL(path, version string) string {
+	elem := strings.Split(path, "/")
+	for i, e := range elem {
+		elem[i] = purlEscape(e)
+	}
+	return "pkg:golang/" + strings.Join(elem, "/") + "@" + purlEscape(version)
+}
+
+// purlEscape percent-encodes s for use in a package URL component.
+// Unlike url.PathEscape, it also encodes '+', which purl reserves.
+func purlEscape(s string) string {
+	return strings.ReplaceAll(url.PathEscape(s), "+", "%2B")
+}
+
+// replaceComment describes the replacement of m, if any.
+func (m *module) replaceComment() string {
+	if m.Replace == nil {
+		return ""
+	}
+	if m.Replace.Version == "" {
+		return "replaced by " + m.Replace.Path
+	}
+	return "replaced by " + m.Replace.Path + " " + m.Replace.Version
+}
+
+// vcsComment describes the version control settings in info, if any.
+func (info *buildInfo) vcsComment() string {
+	vcs := info.setting("vcs")
+	if vcs == "" {
+		return ""
+	}
+	s := "built from " + vcs
+	if rev := info.setting("vcs.revision"); rev != "" {
+		s += " revision " + rev
+	}
+	if t := info.setting("vcs.time"); t != "" {
+		s += " committed at " + t
+	}
+	if info.setting("vcs.modified") == "true" {
+		s += " with uncommitted changes"
+	}
+	return s
+}
+
+// SPDX document, restricted to the fields written by go version.
+// See https://spdx.github.io/spdx-spec/v2.3/.
+
+type spdxDocument struct {
+	SPDXVersion       string             `json:"spdxVersion"`
+	DataLicense       string             `json:"dataLicense"`
+	SPDXID            string             `json:"SPDXID"`
+	Name              string             `json:"name"`
+	DocumentNamespace string             `json:"documentNamespace"`
+	CreationInfo      spdxCreationInfo   `json:"creationInfo"`
+	Packages          []spdxPackage      `json:"packages"`
+	Relationships     []spdxRelationship `json:"relationships"`
+}
+
+type spdxCreationInfo struct {
+	Created  string   `json:"created"`
+	Creators []string `json:"creators"`
+}
+
+type spdxPackage struct {
+	Name             string            `json:"name"`
+	SPDXID           string            `json:"SPDXID"`
+	VersionInfo      string            `json:"versionInfo,omitempty"`
+	DownloadLocation string            `json:"downloadLocation"`
+	FilesAnalyzed    bool              `json:"filesAnalyzed"`
+	PrimaryPurpose   string            `json:"primaryPackagePurpose,omitempty"`
+	SourceInfo       string            `json:"sourceInfo,omitempty"`
+	Comment          string            `json:"comment,omitempty"`
+	ExternalRefs     []spdxExternalRef `json:"externalRefs,omitempty"`
+	Annotations      []spdxAnnotation  `json:"annotations,omitempty"`
+	LicenseConcluded string            `json:"licenseConcluded"`
+	LicenseDeclared  string            `json:"licenseDeclared"`
+	CopyrightText    string            `json:"copyrightText"`
+}
+
+type spdxAnnotation struct {
+	Date      string `json:"annotationDate"`
+	Type      string `json:"annotationType"`
+	Annotator string `json:"annotator"`
+	Comment   string `json:"comment"`
+}
+
+type spdxExternalRef struct {
+	ReferenceCategory string `json:"referenceCategory"`
+	ReferenceType     string `json:"referenceType"`
+	ReferenceLocator  string `json:"referenceLocator"`
+}
+
+type spdxRelationship struct {
+	Element string `json:"spdxElementId"`
+	Type    string `json:"relationshipType"`
+	Related string `json:"relatedSpdxElement"`
+}
+
+// spdxPkg returns the SPDX package describing m with identifier id.
+// The go.sum hash of m, which is not a checksum of any file SPDX
+// knows about, is recorded in an annotation dated created.
+func spdxPkg(id string, m *module, created string) spdxPackage {
+	eff := m.effective()
+	p := spdxPackage{
+		Name:             m.Path,
+		SPDXID:           id,
+		VersionInfo:      eff.Version,
+		DownloadLocation: "NOASSERTION",
+		Comment:          m.replaceComment(),
+		LicenseConcluded: "NOASSERTION",
+		LicenseDeclared:  "NOASSERTION",
+		CopyrightText:    "NOASSERTION",
+	}
+	if purl := eff.purl(); purl != "" {
+		p.ExternalRefs = []spdxExternalRef{{ReferenceCategory: "PACKAGE-MANAGER", ReferenceType: "purl", ReferenceLocator: purl}}
+	}
+	if eff.Sum != "" {
+		p.Annotations = []spdxAnnotation{{
+			Date:      created,
+			Type:      "OTHER",
+			Annotator: "Tool: go-" + runtime.Version(),
+			Comment:   "go.sum hash " + eff.Sum,
+		}}
+	}
+	return p
+}
+
+// spdx returns an SPDX 2.3 document describing info.
+func (info *buildInfo) spdx() *spdxDocument {
+	digest := info.digest()
+	created := info.created()
+	doc := &spdxDocument{
+		SPDXVersion:       "SPDX-2.3",
+		DataLicense:       "CC0-1.0",
+		SPDXID:            "SPDXRef-DOCUMENT",
+		Name:              info.Path,
+		DocumentNamespace: "https://spdx.org/spdxdocs/" + purlEscape(info.Path) + "-" + hex.EncodeToString(digest[:]),
+		CreationInfo: spdxCreationInfo{
+			Created:  created,
+			Creators: []string{"Tool: go-" + runtime.Version()},
+		},
+	}
+
+	const mainID = "SPDXRef-Package-main"
+	mainPkg := spdxPkg(mainID, &info.Main, created)
+	mainPkg.PrimaryPurpose = "APPLICATION"
+	mainPkg.SourceInfo = info.vcsComment()
+	doc.Packages = append(doc.Packages, mainPkg)
+	doc.Relationships = append(doc.Relationships, spdxRelationship{"SPDXRef-DOCUMENT", "DESCRIBES", mainID})
+
+	stdlib := spdxPackage{
+		Name:             "stdlib",
+		SPDXID:           "SPDXRef-Package-stdlib",
+		VersionInfo:      info.GoVersion,
+		DownloadLocation: "https://go.dev/dl/",
+		LicenseConcluded: "NOASSERTION",
+		LicenseDeclared:  "NOASSERTION",
+		CopyrightText:    "NOASSERTION",
+		ExternalRefs: []spdxExternalRef{{
+			ReferenceCategory: "PACKAGE-MANAGER",
+			ReferenceType:     "purl",
+			ReferenceLocator:  goPURL("stdlib", info.GoVersion),
+		}},
+	}
+	doc.Packages = append(doc.Packages, stdlib)
+	doc.Relationships = append(doc.Relationships, spdxRelationship{mainID, "DEPENDS_ON", stdlib.SPDXID})
+
+	for i, m := range info.Deps {
+		id := fmt.Sprintf("SPDXRef-Package-%d", i+1)
+		doc.Packages = append(doc.Packages, spdxPkg(id, m, created))
+		doc.Relationships = append(doc.Relationships, spdxRelationship{mainID, "DEPENDS_ON", id})
+	}
+	return doc
+}
+
+// CycloneDX document, restricted to the fields written by go version.
+// See https://cyclonedx.org/docs/1.4/json/.
+
+type cdxDocument struct {
+	BOMFormat    string          `json:"bomFormat"`
+	SpecVersion  string          `json:"specVersion"`
+	SerialNumber string          `json:"serialNumber"`
+	Version      int             `json:"version"`
+	Metadata     cdxMetadata     `json:"metadata"`
+	Components   []cdxComponent  `json:"components"`
+	Dependencies []cdxDependency `json:"dependencies"`
+}
+
+type cdxMetadata struct {
+	Timestamp string       `json:"timestamp"`
+	Tools     []cdxTool    `json:"tools"`
+	Component cdxComponent `json:"component"`
+}
+
+type cdxTool struct {
+	Vendor  string `json:"vendor"`
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type cdxComponent struct {
+	BOMRef     string        `json:"bom-ref"`
+	Type       string        `json:"type"`
+	Name       string        `json:"name"`
+	Version    string        `json:"version,omitempty"`
+	PURL       string        `json:"purl,omitempty"`
+	Properties []cdxProperty `json:"properties,omitempty"`
+}
+
+type cdxProperty struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type cdxDependency struct {
+	Ref       string   `json:"ref"`
+	DependsOn []string `json:"dependsOn,omitempty"`
+}
+
+// cdxComp returns the CycloneDX component of the given type describing m.
+func cdxComp(typ string, m *module) cdxComponent {
+	eff := m.effective()
+	c := cdxComponent{
+		BOMRef:  eff.purl(),
+		Type:    typ,
+		Name:    m.Path,
+		Version: eff.Version,
+		PURL:    eff.purl(),
+	}
+	if c.BOMRef == "" {
+		c.BOMRef = m.Path
+	}
+	if eff.Sum != "" {
+		// The go.sum hash is not a hash of any file, so it is
+		// recorded as a property rather than in the hashes list.
+		c.Properties = append(c.Properties, cdxProperty{"go:module:sum", eff.Sum})
+	}
+	if m.Replace != nil {
+		c.Properties = append(c.Properties,
+			cdxProperty{"go:module:replace:path", m.Replace.Path})
+		if m.Replace.Version != "" {
+			c.Properties = append(c.Properties,
+				cdxProperty{"go:module:replace:version", m.Replace.Version})
+		}
+	}
+	return c
+}
+
+// cyclonedx returns a CycloneDX 1.4 document describing info.
+func (info *buildInfo) cyclonedx() *cdxDocument {
+	digest := info.digest()
+	u := digest[:16]
+	u[6] = u[6]&0x0f | 0x50 // version 5 (name-based SHA)
+	u[8] = u[8]&0x3f | 0x80 // RFC 4122 variant
+	h := hex.EncodeToString(u)
+	uuid := h[0:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:32]
+
+	mainComp := cdxComp("application", &info.Main)
+	mainComp.Properties = append(mainComp.Properties, cdxProperty{"go:package:path", info.Path})
+	for _, s := range info.Settings {
+		mainComp.Properties = append(mainComp.Properties, cdxProperty{"go:build:" + s.Key, s.Value})
+	}
+
+	doc := &cdxDocument{
+		BOMFormat:    "CycloneDX",
+		SpecVersion:  "1.4",
+		SerialNumber: "urn:uuid:" + uuid,
+		Version:      1,
+		Metadata: cdxMetadata{
+			Timestamp: info.created(),
+			Tools:     []cdxTool{{Vendor: "Go", Name: "go", Version: runtime.Version()}},
+			Component: mainComp,
+		},
+	}
+
+	stdlibPURL := goPURL("stdlib", info.GoVersion)
+	doc.Components = append(doc.Components, cdxComponent{
+		BOMRef:  stdlibPURL,
+		Type:    "library",
+		Name:    "stdlib",
+		Version: info.GoVersion,
+		PURL:    stdlibPURL,
+	})
+	mainDeps := cdxDependency{Ref: mainComp.BOMRef, DependsOn: []string{stdlibPURL}}
+	for _, m := range info.Deps {
+		c := cdxComp("library", m)
+		doc.Components = append(doc.Components, c)
+		mainDeps.DependsOn = append(mainDeps.DependsOn, c.BOMRef)
+	}
+	doc.Dependencies = append(doc.Dependencies, mainDeps)
+	return doc
+}
@@ -9,6 +9,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/binary"
+	"encoding/json"
 	"fmt"
 	"io/fs"
 	"os"
@@ -20,7 +21,7 @@ import (
 )
 
 var CmdVersion = &base.Command{
-	UsageLine: "go version [-m] [-v] [file ...]",
+	UsageLine: "go version [-m] [-v] [-json] [-sbom=format] [file ...]",
 	Short:     "print Go version",
 	Long: `Version prints the build information for Go executables.
 
@@ -38,7 +39,23 @@ during a directory scan. The -v flag causes it to report unrecognized files.
 The -m flag causes go version to print each executable's embedded
 module version information, when available. In the output, the module
 information consists of multiple lines following the version line, each
-indented by a leading tab character.
+indented by a leading tab character. When the executable was built
+in a version control checkout, the module information also includes
+"build" lines recording the revision and commit time of the main module
+(see the -buildvcs build flag).
+
+The -json flag is similar to -m but outputs the build information
+as a JSON object for each executable, with the same fields as
+runtime/debug.BuildInfo plus a GoVersion field.
+
+The -sbom flag is similar to -m but outputs a software bill of materials
+for each executable, built from its embedded build information.
+The format may be "spdx" for an SPDX 2.3 JSON document or "cyclonedx"
+for a CycloneDX 1.4 JSON document. The bill of materials lists the
+main module, the Go standard library, and every dependency module,
+with package URLs (purls), the module hashes recorded in go.sum,
+any module replacements, and the version control metadata of the
+main module.
 
 See also: go doc runtime/debug.BuildInfo.
 `,
@@ -49,8 +66,10 @@ func init() {
 }
 
 var (
-	versionM = CmdVersion.Flag.Bool("m", false, "")
-	versionV = CmdVersion.Flag.Bool("v", false, "")
+	versionM    = CmdVersion.Flag.Bool("m", false, "")
+	versionV    = CmdVersion.Flag.Bool("v", false, "")
+	versionJSON = CmdVersion.Flag.Bool("json", false, "")
+	versionSBOM = CmdVersion.Flag.String("sbom", "", "")
 )
 
 func runVersion(ctx context.Context, cmd *base.Command, args []string) {
@@ -62,7 +81,8 @@ func runVersion(ctx context.Context, cmd *base.Command, args []string) {
 		// a reasonable use case. For example, imagine GOFLAGS=-v to
 		// turn "verbose mode" on for all Go commands, which should not
 		// break "go version".
-		if (!base.InGOFLAGS("-m") && *versionM) || (!base.InGOFLAGS("-v") && *versionV) {
+		if (!base.InGOFLAGS("-m") && *versionM) || (!base.InGOFLAGS("-v") && *versionV) ||
+			(!base.InGOFLAGS("-json") && *versionJSON) || (!base.InGOFLAGS("-sbom") && *versionSBOM != "") {
 			fmt.Fprintf(os.Stderr, "go version: flags can only be used with arguments\n")
 			base.SetExitStatus(2)
 			return
@@ -71,6 +91,19 @@ func runVersion(ctx context.Context, cmd *base.Command, args []string) {
 		return
 	}
 
+	switch *versionSBOM {
+	case "", "spdx", "cyclonedx":
+	default:
+		fmt.Fprintf(os.Stderr, "go version: unknown -sbom format %q (must be spdx or cyclonedx)\n", *versionSBOM)
+		base.SetExitStatus(2)
+		return
+	}
+	if *versionJSON && *versionSBOM != "" {
+		fmt.Fprintf(os.Stderr, "go version: -json and -sbom are mutually exclusive\n")
+		base.SetExitStatus(2)
+		return
+	}
+
 	for _, arg := range args {
 		info, err := os.Stat(arg)
 		if err != nil {
@@ -152,12 +185,48 @@ func scanFile(file string, info fs.FileInfo, mustPrint bool) {
 		return
 	}
 
+	if *versionJSON || *versionSBOM != "" {
+		printStructured(file, vers, mod)
+		return
+	}
+
 	fmt.Printf("%s: %s\n", file, vers)
 	if *versionM && mod != "" {
 		fmt.Printf("\t%s\n", strings.ReplaceAll(mod[:len(mod)-1], "\n", "\n\t"))
 	}
 }
 
+// printStructured prints the build information of file as JSON,
+// either directly (-json) or as a software bill of materials (-sbom).
+func printStructured(file, vers, mod string) {
+	if mod == "" {
+		fmt.Fprintf(os.Stderr, "%s: no module information\n", file)
+		base.SetExitStatus(1)
+		return
+	}
+	info, err := parseBuildInfo(vers, mod)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", file, err)
+		base.SetExitStatus(1)
+		return
+	}
+
+	var v interface{}
+	switch *versionSBOM {
+	case "spdx":
+		v = info.spdx()
+	case "cyclonedx":
+		v = info.cyclonedx()
+	default:
+		v = info
+	}
+	b, err := json.MarshalIndent(v, "", "\t")
+	if err != nil {
+		base.Fatalf("%s: %v", file, err)
+	}
+	fmt.Printf("%s\n", b)
+}
+
 // The build info blob left by the linker is identified by
 // a 16-byte header, consisting of buildInfoMagic (14 bytes),
 // the binary's pointer size (1 byte),
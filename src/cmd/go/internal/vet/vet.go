@@ -24,7 +24,7 @@ func init() {
 
 var CmdVet = &base.Command{
 	CustomFlags: true,
-	UsageLine:   "go vet [-n] [-x] [-vettool prog] [build flags] [vet flags] [packages]",
+	UsageLine:   "go vet [-n] [-x] [-json] [-vettool prog] [build flags] [vet flags] [packages]",
 	Short:       "report likely mistakes in packages",
 	Long: `
 Vet runs the Go vet command on the packages named by the import paths.
@@ -37,6 +37,12 @@ For details of a specific checker such as 'printf', see 'go tool vet help printf
 The -n flag prints commands that would be executed.
 The -x flag prints commands as they are executed.
 
+The -json flag is passed to the vet tool, which then reports its
+diagnostics as JSON on standard error and succeeds. It also makes
+go vet print the output and failures of the other actions it runs,
+including a vet tool that fails, for example on a type error, as JSON
+build events on standard output, as described in 'go help build'.
+
 The -vettool=prog flag selects a different analysis tool with alternative
 or additional checks.
 For example, the 'shadow' analyzer can be built and run using these commands:
@@ -72,6 +78,10 @@ func runVet(ctx context.Context, cmd *base.Command, args []string) {
 	ctx, span := trace.StartSpan(ctx, fmt.Sprint("Running ", cmd.Name(), " command"))
 	defer span.Done()
 
+	if f := CmdVet.Flag.Lookup("json"); f != nil && f.Value.String() == "true" {
+		cfg.BuildJSON = true
+	}
+
 	work.BuildInit()
 	work.VetFlags = vetFlags
 	if len(vetFlags) > 0 {
@@ -13,6 +13,7 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
 
 	"cmd/go/internal/base"
@@ -25,7 +26,7 @@ import (
 )
 
 var CmdBuild = &base.Command{
-	UsageLine: "go build [-o output] [build flags] [packages]",
+	UsageLine: "go build [-o output] [-json] [build flags] [packages]",
 	Short:     "compile packages and dependencies",
 	Long: `
 Build compiles the packages named by the import paths,
@@ -55,6 +56,33 @@ will be written to that directory.
 The -i flag installs the packages that are dependencies of the target.
 The -i flag is deprecated. Compiled packages are cached automatically.
 
+The -json flag prints build output and failures to standard output as
+a stream of JSON objects, one per line, instead of as plain text on
+standard error. Each object is a BuildEvent:
+
+	type BuildEvent struct {
+		Time    time.Time // encodes as an RFC3339-format string
+		Action  string    // "build-output" or "build-fail"
+		Package string    // import path of the package being built
+		Mode    string    // action that produced the event: "build", "link", "vet", and so on
+		Output  string    // compiler, linker or vet output
+		Errors  []struct {
+			File string
+			Line int
+			Col  int
+			Msg  string // message, including any indented lines that follow it
+		}
+	}
+
+A "build-output" event carries the output of one action, with
+the position of each diagnostic in it listed in Errors. A "build-fail"
+event reports that the action failed; its Output is empty if the
+reason was already reported by a "build-output" event. The Time, Action,
+Package and Output fields have the same meaning as in 'go test -json',
+so the two streams can be decoded the same way. Errors found before
+building begins, such as missing packages, are still printed to
+standard error, as are the outputs of the -n, -x and -v flags.
+
 The build flags are shared by the build, clean, get, install, list, run,
 and test commands:
 
@@ -87,6 +115,16 @@ and test commands:
 		arguments to pass on each go tool asm invocation.
 	-buildmode mode
 		build mode to use. See 'go help buildmode' for more.
+	-buildvcs
+		Whether to stamp binaries with version control information. By default,
+		version control information is stamped into a binary if the main package
+		and the main module containing it are in the repository containing the
+		current directory (if there is a repository). Use -buildvcs=false to
+		omit version control information. Only Git repositories are supported.
+		If the repository status cannot be determined, for example because git
+		is not installed, the information is omitted, unless -buildvcs=true was
+		given explicitly, in which case the build fails.
+		The stamped information can be printed with 'go version -m'.
 	-compiler name
 		name of compiler to use, as in runtime.Compiler (gccgo or gc).
 	-gccgoflags '[pattern=]arg list'
@@ -195,9 +233,11 @@ func init() {
 	CmdInstall.Run = runInstall
 
 	CmdBuild.Flag.BoolVar(&cfg.BuildI, "i", false, "")
+	CmdBuild.Flag.BoolVar(&cfg.BuildJSON, "json", false, "")
 	CmdBuild.Flag.StringVar(&cfg.BuildO, "o", "", "output file or directory")
 
 	CmdInstall.Flag.BoolVar(&cfg.BuildI, "i", false, "")
+	CmdInstall.Flag.BoolVar(&cfg.BuildJSON, "json", false, "")
 
 	AddBuildFlags(CmdBuild, DefaultBuildFlags)
 	AddBuildFlags(CmdInstall, DefaultBuildFlags)
@@ -270,6 +310,7 @@ func AddBuildFlags(cmd *base.Command, mask BuildFlagMask) {
 	cmd.Flag.Var(&load.BuildAsmflags, "asmflags", "")
 	cmd.Flag.Var(buildCompiler{}, "compiler", "")
 	cmd.Flag.StringVar(&cfg.BuildBuildmode, "buildmode", "default", "")
+	cmd.Flag.Var(buildvcsFlag{}, "buildvcs", "")
 	cmd.Flag.Var(&load.BuildGcflags, "gcflags", "")
 	cmd.Flag.Var(&load.BuildGccgoflags, "gccgoflags", "")
 	if mask&OmitModFlag == 0 {
@@ -322,6 +363,27 @@ func (v *tagsFlag) String() string {
 	return "<TagsFlag>"
 }
 
+// buildvcsFlag is the implementation of the -buildvcs flag.
+// It records whether the flag was set explicitly, since errors
+// obtaining the VCS status are only fatal in that case.
+type buildvcsFlag struct{}
+
+func (buildvcsFlag) IsBoolFlag() bool { return true }
+
+func (buildvcsFlag) Set(s string) error {
+	v, err := strconv.ParseBool(s)
+	if err != nil {
+		return err
+	}
+	cfg.BuildBuildvcs = v
+	cfg.BuildBuildvcsExplicit = true
+	return nil
+}
+
+func (buildvcsFlag) String() string {
+	return strconv.FormatBool(cfg.BuildBuildvcs)
+}
+
 // fileExtSplit expects a filename and returns the name
 // and ext (without the dot). If the file has no
 // extension, ext will be empty.
@@ -368,7 +430,7 @@ func runBuild(ctx context.Context, cmd *base.Command, args []string) {
 	var b Builder
 	b.Init()
 
-	pkgs := load.PackagesAndErrors(ctx, load.PackageOpts{}, args)
+	pkgs := load.PackagesAndErrors(ctx, load.PackageOpts{LoadVCS: true}, args)
 	load.CheckPackageErrors(pkgs)
 
 	explicitO := len(cfg.BuildO) > 0
@@ -460,7 +522,7 @@ func runBuild(ctx context.Context, cmd *base.Command, args []string) {
 }
 
 var CmdInstall = &base.Command{
-	UsageLine: "go install [build flags] [packages]",
+	UsageLine: "go install [-json] [build flags] [packages]",
 	Short:     "compile and install packages and dependencies",
 	Long: `
 Install compiles and installs the packages named by the import paths.
@@ -508,6 +570,8 @@ other packages are built and cached but not installed.
 The -i flag installs the dependencies of the named packages as well.
 The -i flag is deprecated. Compiled packages are cached automatically.
 
+The -json flag prints build output as JSON, as described in 'go help build'.
+
 For more about the build flags, see 'go help build'.
 For more about specifying packages, see 'go help packages'.
 
@@ -588,7 +652,7 @@ func runInstall(ctx context.Context, cmd *base.Command, args []string) {
 	}
 
 	BuildInit()
-	pkgs := load.PackagesAndErrors(ctx, load.PackageOpts{}, args)
+	pkgs := load.PackagesAndErrors(ctx, load.PackageOpts{LoadVCS: true}, args)
 	if cfg.ModulesEnabled && !modload.HasModRoot() {
 		haveErrors := false
 		allMissingErrors := true
@@ -0,0 +1,117 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package work
+
+import (
+	"encoding/json"
+	"internal/lazyregexp"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"cmd/go/internal/base"
+	"cmd/go/internal/cfg"
+)
+
+// A buildEvent is one line of the output of 'go build -json'.
+// Its Time, Action, Package and Output fields have the same meaning
+// as those of the events printed by 'go test -json' (see cmd/test2json),
+// so that tools can read both streams with a single decoder.
+type buildEvent struct {
+	Time    *time.Time `json:",omitempty"`
+	Action  string     // "build-output" or "build-fail"
+	Package string     `json:",omitempty"`
+	Mode    string     `json:",omitempty"` // action mode: "build", "link", "vet", and so on
+	Output  string     `json:",omitempty"`
+	Errors  []buildPos `json:",omitempty"`
+}
+
+// A buildPos is a diagnostic found in the Output of a buildEvent.
+type buildPos struct {
+	File string
+	Line int
+	Col  int `json:",omitempty"`
+	Msg  string
+}
+
+// printEvent writes ev to standard output as a single line of JSON.
+// The caller must hold b.output.
+func (b *Builder) printEvent(a *Action, ev *buildEvent) {
+	t := time.Now()
+	ev.Time = &t
+	if a != nil {
+		ev.Mode = a.Mode
+		if a.Package != nil {
+			ev.Package = a.Package.ImportPath
+		}
+	}
+	js, err := json.Marshal(ev)
+	if err != nil {
+		base.Fatalf("go: encoding build event: %v", err)
+	}
+	js = append(js, '\n')
+	os.Stdout.Write(js)
+}
+
+// printOutput prints output from action a, which may be nil.
+// With -json, the output is printed as a build-output event;
+// otherwise it is printed with b.Print.
+// The caller must hold b.output.
+func (b *Builder) printOutput(a *Action, out string) {
+	if out == "" {
+		return
+	}
+	if !cfg.BuildJSON {
+		b.Print(out)
+		return
+	}
+	b.printEvent(a, &buildEvent{
+		Action: "build-output",
+		Output: out,
+		Errors: parseBuildPos(out),
+	})
+}
+
+// printFail reports that action a failed, with the given error
+// message if it has not already been printed.
+func (b *Builder) printFail(a *Action, msg string) {
+	b.output.Lock()
+	defer b.output.Unlock()
+	b.printEvent(a, &buildEvent{
+		Action: "build-fail",
+		Output: msg,
+		Errors: parseBuildPos(msg),
+	})
+}
+
+// posLine matches a line of compiler or vet output that starts with a
+// file:line or file:line:col position. The vet tool prefixes the
+// positions of type errors with "vet: ".
+var posLine = lazyregexp.New(`^(?:vet: )?((?:[A-Za-z]:)?[^:\s][^:]*):([0-9]+)(?::([0-9]+))?: (.*)$`)
+
+// parseBuildPos returns the diagnostics in out, one per line that
+// starts with a position. Indented lines that follow such a line,
+// as in multi-line type errors, are added to its message.
+func parseBuildPos(out string) []buildPos {
+	var list []buildPos
+	cont := false
+	for _, line := range strings.Split(out, "\n") {
+		if m := posLine.FindStringSubmatch(line); m != nil {
+			p := buildPos{File: m[1], Msg: m[4]}
+			p.Line, _ = strconv.Atoi(m[2])
+			p.Col, _ = strconv.Atoi(m[3])
+			list = append(list, p)
+			cont = true
+			continue
+		}
+		if cont && strings.HasPrefix(line, "\t") {
+			list[len(list)-1].Msg += "\n" + line
+			continue
+		}
+		cont = false
+	}
+	return list
+}
@@ -149,6 +149,12 @@ func (b *Builder) Do(ctx context.Context, root *Action) {
 		if err != nil {
 			if err == errPrintedOutput {
 				base.SetExitStatus(2)
+				if cfg.BuildJSON {
+					b.printFail(a, "")
+				}
+			} else if cfg.BuildJSON {
+				base.SetExitStatus(1)
+				b.printFail(a, err.Error()+"\n")
 			} else {
 				base.Errorf("%s", err)
 			}
@@ -240,6 +246,9 @@ func (b *Builder) buildActionID(a *Action) cache.ActionID {
 	fmt.Fprintf(h, "goos %s goarch %s\n", cfg.Goos, cfg.Goarch)
 	fmt.Fprintf(h, "import %q\n", p.ImportPath)
 	fmt.Fprintf(h, "omitdebug %v standard %v local %v prefix %q\n", p.Internal.OmitDebug, p.Standard, p.Internal.Local, p.Internal.LocalPrefix)
+	if p.DefaultGODEBUG != "" {
+		fmt.Fprintf(h, "GODEBUG %q\n", p.DefaultGODEBUG)
+	}
 	if cfg.BuildTrimpath {
 		fmt.Fprintln(h, "trimpath")
 	}
@@ -1204,7 +1213,12 @@ func (b *Builder) vet(ctx context.Context, a *Action) error {
 	if tool == "" {
 		tool = base.Tool("vet")
 	}
-	runErr := b.run(a, p.Dir, p.ImportPath, env, cfg.BuildToolexec, tool, vetFlags, a.Objdir+"vet.cfg")
+	var runErr error
+	if cfg.BuildJSON {
+		runErr = b.runVetJSON(a, env, tool, vetFlags)
+	} else {
+		runErr = b.run(a, p.Dir, p.ImportPath, env, cfg.BuildToolexec, tool, vetFlags, a.Objdir+"vet.cfg")
+	}
 
 	// If vet wrote export data, save it for input to future vets.
 	if f, err := os.Open(vcfg.VetxOutput); err == nil {
@@ -1216,6 +1230,27 @@ func (b *Builder) vet(ctx context.Context, a *Action) error {
 	return runErr
 }
 
+// runVetJSON is like b.run for the vet action a under go vet -json.
+// The -json flag is passed on to the vet tool, which then reports its
+// diagnostics as JSON and succeeds. That report is printed unchanged;
+// only a failure of the tool, such as a type error, is reported as
+// build events.
+func (b *Builder) runVetJSON(a *Action, env []string, tool string, vetFlags []string) error {
+	p := a.Package
+	out, err := b.runOut(a, p.Dir, env, cfg.BuildToolexec, tool, vetFlags, a.Objdir+"vet.cfg")
+	if len(out) == 0 {
+		return err
+	}
+	if err != nil {
+		b.showOutput(a, p.Dir, p.ImportPath, b.processOutput(out))
+		return errPrintedOutput
+	}
+	b.output.Lock()
+	defer b.output.Unlock()
+	b.Print(b.fmtOutput(p.Dir, p.ImportPath, b.processOutput(out)))
+	return nil
+}
+
 // linkActionID computes the action ID for a link action.
 func (b *Builder) linkActionID(a *Action) cache.ActionID {
 	p := a.Package
@@ -1467,8 +1502,7 @@ func (b *Builder) getPkgConfigFlags(p *load.Package) (cflags, ldflags []string,
 		var out []byte
 		out, err = b.runOut(nil, p.Dir, nil, b.PkgconfigCmd(), "--cflags", pcflags, "--", pkgs)
 		if err != nil {
-			b.showOutput(nil, p.Dir, b.PkgconfigCmd()+" --cflags "+strings.Join(pcflags, " ")+" -- "+strings.Join(pkgs, " "), string(out))
-			b.Print(err.Error() + "\n")
+			b.showOutput(nil, p.Dir, b.PkgconfigCmd()+" --cflags "+strings.Join(pcflags, " ")+" -- "+strings.Join(pkgs, " "), string(out)+err.Error()+"\n")
 			return nil, nil, errPrintedOutput
 		}
 		if len(out) > 0 {
@@ -1482,8 +1516,7 @@ func (b *Builder) getPkgConfigFlags(p *load.Package) (cflags, ldflags []string,
 		}
 		out, err = b.runOut(nil, p.Dir, nil, b.PkgconfigCmd(), "--libs", pcflags, "--", pkgs)
 		if err != nil {
-			b.showOutput(nil, p.Dir, b.PkgconfigCmd()+" --libs "+strings.Join(pcflags, " ")+" -- "+strings.Join(pkgs, " "), string(out))
-			b.Print(err.Error() + "\n")
+			b.showOutput(nil, p.Dir, b.PkgconfigCmd()+" --libs "+strings.Join(pcflags, " ")+" -- "+strings.Join(pkgs, " "), string(out)+err.Error()+"\n")
 			return nil, nil, errPrintedOutput
 		}
 		if len(out) > 0 {
@@ -1979,23 +2012,29 @@ func (b *Builder) Showcmd(dir string, format string, args ...interface{}) {
 // printing to b.Print.
 //
 func (b *Builder) showOutput(a *Action, dir, desc, out string) {
-	prefix := "# " + desc
-	suffix := "\n" + out
-	if reldir := base.ShortPath(dir); reldir != dir {
-		suffix = strings.ReplaceAll(suffix, " "+dir, " "+reldir)
-		suffix = strings.ReplaceAll(suffix, "\n"+dir, "\n"+reldir)
-	}
-	suffix = strings.ReplaceAll(suffix, " "+b.WorkDir, " $WORK")
+	out = b.fmtOutput(dir, desc, out)
 
 	if a != nil && a.output != nil {
-		a.output = append(a.output, prefix...)
-		a.output = append(a.output, suffix...)
+		a.output = append(a.output, out...)
 		return
 	}
 
 	b.output.Lock()
 	defer b.output.Unlock()
-	b.Print(prefix, suffix)
+	b.printOutput(a, out)
+}
+
+// fmtOutput returns out, the output of the command described by desc
+// and run in dir, as printed by showOutput.
+func (b *Builder) fmtOutput(dir, desc, out string) string {
+	prefix := "# " + desc
+	suffix := "\n" + out
+	if reldir := base.ShortPath(dir); reldir != dir {
+		suffix = strings.ReplaceAll(suffix, " "+dir, " "+reldir)
+		suffix = strings.ReplaceAll(suffix, "\n"+dir, "\n"+reldir)
+	}
+	suffix = strings.ReplaceAll(suffix, " "+b.WorkDir, " $WORK")
+	return prefix + suffix
 }
 
 // errPrintedOutput is a special error indicating that a command failed
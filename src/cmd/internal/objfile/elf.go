@@ -12,10 +12,15 @@ import (
 	"encoding/binary"
 	"fmt"
 	"io"
+	"os"
 )
 
 type elfFile struct {
-	elf *elf.File
+	elf  *elf.File
+	path string // file name, to locate the separate debug file
+
+	debug       *elf.File // separate debug file, if any
+	debugLoaded bool
 }
 
 func openElf(r io.ReaderAt) (rawFile, error) {
@@ -23,11 +28,37 @@ func openElf(r io.ReaderAt) (rawFile, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &elfFile{f}, nil
+	ef := &elfFile{elf: f}
+	if osf, ok := r.(*os.File); ok {
+		ef.path = osf.Name()
+	}
+	return ef, nil
+}
+
+// debugFile returns the separate debug file of f, which holds the
+// symbol table and DWARF data that f may have been stripped of,
+// or nil if there is none.
+func (f *elfFile) debugFile() *elf.File {
+	if !f.debugLoaded {
+		f.debugLoaded = true
+		if f.path != "" {
+			f.debug, _ = f.elf.OpenDebugFile(f.path)
+		}
+	}
+	return f.debug
 }
 
 func (f *elfFile) symbols() ([]Sym, error) {
-	elfSyms, err := f.elf.Symbols()
+	ef := f.elf
+	elfSyms, err := ef.Symbols()
+	if err == elf.ErrNoSymbols {
+		if d := f.debugFile(); d != nil {
+			// The debug file has the same sections,
+			// as far as the symbols are concerned.
+			ef = d
+			elfSyms, err = ef.Symbols()
+		}
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -42,10 +73,10 @@ func (f *elfFile) symbols() ([]Sym, error) {
 			sym.Code = 'B'
 		default:
 			i := int(s.Section)
-			if i < 0 || i >= len(f.elf.Sections) {
+			if i < 0 || i >= len(ef.Sections) {
 				break
 			}
-			sect := f.elf.Sections[i]
+			sect := ef.Sections[i]
 			switch sect.Flags & (elf.SHF_WRITE | elf.SHF_ALLOC | elf.SHF_EXECINSTR) {
 			case elf.SHF_ALLOC | elf.SHF_EXECINSTR:
 				sym.Code = 'T'
@@ -122,5 +153,21 @@ func (f *elfFile) loadAddress() (uint64, error) {
 }
 
 func (f *elfFile) dwarf() (*dwarf.Data, error) {
+	if f.elf.Section(".debug_info") == nil && f.elf.Section(".zdebug_info") == nil {
+		if d := f.debugFile(); d != nil {
+			return d.DWARF()
+		}
+	}
 	return f.elf.DWARF()
 }
+
+func (f *elfFile) sections() ([]Section, error) {
+	var sects []Section
+	for _, s := range f.elf.Sections {
+		if s.Type == elf.SHT_NULL {
+			continue
+		}
+		sects = append(sects, Section{Name: s.Name, Size: s.Size})
+	}
+	return sects, nil
+}
@@ -344,3 +344,7 @@ func (f *goobjFile) loadAddress() (uint64, error) {
 func (f *goobjFile) dwarf() (*dwarf.Data, error) {
 	return nil, errors.New("no DWARF data in go object file")
 }
+
+func (f *goobjFile) sections() ([]Section, error) {
+	return nil, errors.New("no sections in go object file")
+}
@@ -134,3 +134,11 @@ func (f *machoFile) loadAddress() (uint64, error) {
 func (f *machoFile) dwarf() (*dwarf.Data, error) {
 	return f.macho.DWARF()
 }
+
+func (f *machoFile) sections() ([]Section, error) {
+	var sects []Section
+	for _, s := range f.macho.Sections {
+		sects = append(sects, Section{Name: s.Seg + "," + s.Name, Size: s.Size})
+	}
+	return sects, nil
+}
@@ -22,6 +22,7 @@ type rawFile interface {
 	goarch() string
 	loadAddress() (uint64, error)
 	dwarf() (*dwarf.Data, error)
+	sections() ([]Section, error)
 }
 
 // A File is an opened executable file.
@@ -45,6 +46,12 @@ type Sym struct {
 	Relocs []Reloc // in increasing Addr order
 }
 
+// A Section is a section of an executable file.
+type Section struct {
+	Name string // section name
+	Size uint64 // size in bytes
+}
+
 type Reloc struct {
 	Addr     uint64 // Address of first byte that reloc applies to.
 	Size     uint64 // Number of bytes
@@ -118,6 +125,10 @@ func (f *File) DWARF() (*dwarf.Data, error) {
 	return f.entries[0].DWARF()
 }
 
+func (f *File) Sections() ([]Section, error) {
+	return f.entries[0].Sections()
+}
+
 func (f *File) Disasm() (*Disasm, error) {
 	return f.entries[0].Disasm()
 }
@@ -175,3 +186,8 @@ func (e *Entry) LoadAddress() (uint64, error) {
 func (e *Entry) DWARF() (*dwarf.Data, error) {
 	return e.raw.dwarf()
 }
+
+// Sections returns the sections of the file, in file order.
+func (e *Entry) Sections() ([]Section, error) {
+	return e.raw.sections()
+}
@@ -203,3 +203,16 @@ func (f *peFile) loadAddress() (uint64, error) {
 func (f *peFile) dwarf() (*dwarf.Data, error) {
 	return f.pe.DWARF()
 }
+
+func (f *peFile) sections() ([]Section, error) {
+	var sects []Section
+	for _, s := range f.pe.Sections {
+		size := uint64(s.VirtualSize)
+		if size == 0 {
+			// Object files do not record a virtual size.
+			size = uint64(s.Size)
+		}
+		sects = append(sects, Section{Name: s.Name, Size: size})
+	}
+	return sects, nil
+}
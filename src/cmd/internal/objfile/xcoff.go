@@ -161,3 +161,11 @@ func (f *xcoffFile) loadAddress() (uint64, error) {
 func (f *xcoffFile) dwarf() (*dwarf.Data, error) {
 	return f.xcoff.DWARF()
 }
+
+func (f *xcoffFile) sections() ([]Section, error) {
+	var sects []Section
+	for _, s := range f.xcoff.Sections {
+		sects = append(sects, Section{Name: s.Name, Size: s.Size})
+	}
+	return sects, nil
+}
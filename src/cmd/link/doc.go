@@ -62,6 +62,11 @@ Flags:
 		The dynamic header is on by default, even without any
 		references to dynamic libraries, because many common
 		system tools now assume the presence of the header.
+	-debugfile file
+		Write the DWARF debug information, and the symbol table, to the
+		separate ELF debug file, and link the output to it with a GNU
+		build ID note and a .gnu_debuglink section. With -s, the symbol
+		table is only written to the debug file. Requires -B or -buildid.
 	-debugtramp int
 		Debug trampolines.
 	-dumpdep
@@ -112,6 +117,9 @@ Flags:
 		Set the ELF dynamic linker search path.
 	-race
 		Link with race detection libraries.
+	-reachreport file
+		Write to file a report of why each symbol in the output is
+		reachable, for use by "go tool size".
 	-s
 		Omit the symbol table and debug information.
 	-shared
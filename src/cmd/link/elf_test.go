@@ -411,3 +411,62 @@ func TestPIESize(t *testing.T) {
 		})
 	}
 }
+
+func TestDebugFile(t *testing.T) {
+	testenv.MustHaveGoBuild(t)
+	t.Parallel()
+
+	dir := t.TempDir()
+	src := filepath.Join(dir, "main.go")
+	if err := ioutil.WriteFile(src, []byte("package main\nfunc main() { println(\"hello\") }\n"), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, strip := range []bool{false, true} {
+		exe := filepath.Join(dir, "exe")
+		ldflags := "-ldflags=-debugfile=" + exe + ".debug"
+		if strip {
+			exe += "-s"
+			ldflags = "-ldflags=-s -debugfile=" + exe + ".debug"
+		}
+		cmd := exec.Command(testenv.GoToolPath(t), "build", ldflags, "-o", exe, src)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("%v: %v\n%s", cmd.Args, err, out)
+		}
+		if out, err := exec.Command(exe).CombinedOutput(); err != nil || string(out) != "hello\n" {
+			t.Fatalf("%s: %v\n%s", exe, err, out)
+		}
+
+		f, err := elf.Open(exe)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		if _, err := f.DWARF(); err == nil {
+			t.Errorf("%s: has DWARF data", exe)
+		}
+		if _, err := f.Symbols(); (err == nil) == strip {
+			t.Errorf("%s: Symbols() error = %v, want symbol table only without -s", exe, err)
+		}
+		if name, _, err := f.DebugLink(); err != nil || name != filepath.Base(exe)+".debug" {
+			t.Errorf("%s: DebugLink() = %q, %v", exe, name, err)
+		}
+
+		df, err := f.OpenDebugFile(exe)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer df.Close()
+		id, _ := f.BuildID()
+		did, _ := df.BuildID()
+		if len(id) == 0 || string(id) != string(did) {
+			t.Errorf("%s: build IDs %x and %x do not match", exe, id, did)
+		}
+		if _, err := df.DWARF(); err != nil {
+			t.Errorf("%s.debug: %v", exe, err)
+		}
+		if syms, err := df.Symbols(); err != nil || len(syms) == 0 {
+			t.Errorf("%s.debug: no symbols: %v", exe, err)
+		}
+	}
+}
@@ -5,6 +5,7 @@
 package ld
 
 import (
+	"bufio"
 	"cmd/internal/goobj"
 	"cmd/internal/objabi"
 	"cmd/internal/sys"
@@ -12,6 +13,7 @@ import (
 	"cmd/link/internal/sym"
 	"fmt"
 	"internal/buildcfg"
+	"os"
 	"unicode"
 )
 
@@ -33,7 +35,7 @@ type deadcodePass struct {
 func (d *deadcodePass) init() {
 	d.ldr.InitReachable()
 	d.ifaceMethod = make(map[methodsig]bool)
-	if buildcfg.Experiment.FieldTrack {
+	if buildcfg.Experiment.FieldTrack || *flagReachReport != "" {
 		d.ldr.Reachparent = make([]loader.Sym, d.ldr.NSym())
 	}
 	d.dynlink = d.ctxt.DynlinkingGo()
@@ -261,7 +263,7 @@ func (d *deadcodePass) mark(symIdx, parent loader.Sym) {
 	if symIdx != 0 && !d.ldr.AttrReachable(symIdx) {
 		d.wq.push(symIdx)
 		d.ldr.SetAttrReachable(symIdx, true)
-		if buildcfg.Experiment.FieldTrack && d.ldr.Reachparent[symIdx] == 0 {
+		if d.ldr.Reachparent != nil && d.ldr.Reachparent[symIdx] == 0 {
 			d.ldr.Reachparent[symIdx] = parent
 		}
 		if *flagDumpDep {
@@ -366,6 +368,38 @@ func deadcode(ctxt *Link) {
 	}
 }
 
+// reachreport writes the reachability report requested by -reachreport
+// to file. It has a line for each reachable symbol, giving the index of
+// the symbol, the index of the symbol that made it reachable (0 for the
+// roots of the dead code pass), its size, its kind, its package and its
+// name, separated by tabs. Following the parents from a symbol gives the
+// chain of references that kept it in the binary. DWARF symbols, which
+// are kept with the symbols they describe, are not listed.
+// "go tool size -why" and "go tool size -edges" interpret the report.
+func reachreport(ldr *loader.Loader, file string) {
+	f, err := os.Create(file)
+	if err != nil {
+		Exitf("cannot create %s: %v", file, err)
+	}
+	w := bufio.NewWriter(f)
+	fmt.Fprintf(w, "# reachability report\n")
+	for s := loader.Sym(1); s < loader.Sym(ldr.NSym()); s++ {
+		if !ldr.AttrReachable(s) {
+			continue
+		}
+		if t := ldr.SymType(s); t >= sym.SDWARFSECT && t <= sym.SDWARFLINES {
+			continue
+		}
+		fmt.Fprintf(w, "%d\t%d\t%d\t%v\t%s\t%s\n", s, ldr.Reachparent[s], ldr.SymSize(s), ldr.SymType(s), ldr.SymPkg(s), ldr.SymName(s))
+	}
+	if err := w.Flush(); err != nil {
+		Exitf("writing %s: %v", file, err)
+	}
+	if err := f.Close(); err != nil {
+		Exitf("writing %s: %v", file, err)
+	}
+}
+
 // methodsig is a typed method signature (name + type).
 type methodsig struct {
 	name string
@@ -0,0 +1,395 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ld
+
+import (
+	"bytes"
+	"debug/elf"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+// elfSplitDwarf moves the DWARF sections of the ELF executable exe to
+// the separate debug file dbg, as requested by -debugfile, and links exe
+// to it with a .gnu_debuglink section. The GNU build ID note of exe,
+// which both files keep, identifies the debug file too.
+//
+// Like the debug files made by "objcopy --only-keep-debug", dbg has the
+// section headers of exe, so that the indexes in its symbol table are
+// valid, but only the DWARF sections, the symbol table and the notes
+// have contents; the other sections are marked SHT_NOBITS.
+// If stripSymtab is set, the symbol table is removed from exe,
+// as by -s, and only kept in dbg.
+func elfSplitDwarf(exe, dbg string, stripSymtab bool) error {
+	data, err := ioutil.ReadFile(exe)
+	if err != nil {
+		return err
+	}
+	f, err := elf.NewFile(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	s := &elfSplitter{f: f, data: data, is64: f.Class == elf.ELFCLASS64}
+	if len(f.Sections) == 0 || len(f.Sections) >= int(elf.SHN_LORESERVE) {
+		return fmt.Errorf("%s: unsupported number of sections %d", exe, len(f.Sections))
+	}
+
+	debug, err := s.debugFile()
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(dbg, debug, 0666); err != nil {
+		return err
+	}
+	out, err := s.strip(filepath.Base(dbg), crc32.ChecksumIEEE(debug), stripSymtab)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(exe, out, 0777)
+}
+
+// An elfSplitter rewrites an ELF file held in memory.
+type elfSplitter struct {
+	f    *elf.File
+	data []byte
+	is64 bool
+}
+
+// isDwarfSection reports whether the named section holds debug information.
+func isDwarfSection(name string) bool {
+	return strings.HasPrefix(name, ".debug_") || strings.HasPrefix(name, ".zdebug_")
+}
+
+// debugFile returns the contents of the separate debug file.
+func (s *elfSplitter) debugFile() ([]byte, error) {
+	f := s.f
+	keep := make([]bool, len(f.Sections))
+	for i, sect := range f.Sections {
+		switch {
+		case isDwarfSection(sect.Name), sect.Type == elf.SHT_NOTE, i == int(s.shstrndx()):
+			keep[i] = true
+		case sect.Type == elf.SHT_SYMTAB:
+			keep[i] = true
+			if int(sect.Link) < len(keep) {
+				keep[sect.Link] = true
+			}
+		}
+	}
+
+	out := make([]byte, s.ehsize())
+	hdrs := make([]elf.SectionHeader, len(f.Sections))
+	for i, sect := range f.Sections {
+		hdrs[i] = sect.SectionHeader
+		if sect.Type == elf.SHT_NULL {
+			continue
+		}
+		if !keep[i] || sect.Type == elf.SHT_NOBITS {
+			hdrs[i].Type = elf.SHT_NOBITS
+			hdrs[i].Offset = uint64(len(out))
+			continue
+		}
+		out = s.appendSection(out, &hdrs[i], sect)
+	}
+
+	names := make([]uint32, len(f.Sections))
+	for i := range names {
+		names[i] = s.nameOffset(i)
+	}
+	return s.finish(out, hdrs, names, s.shstrndx(), false)
+}
+
+// strip returns the contents of the executable without its DWARF
+// sections, and without its symbol table if stripSymtab is set,
+// and with a .gnu_debuglink section naming the debug file.
+func (s *elfSplitter) strip(link string, crc uint32, stripSymtab bool) ([]byte, error) {
+	f := s.f
+	shstrndx := int(s.shstrndx())
+
+	remove := make([]bool, len(f.Sections))
+	for i, sect := range f.Sections {
+		if isDwarfSection(sect.Name) {
+			remove[i] = true
+		}
+		if stripSymtab && sect.Type == elf.SHT_SYMTAB {
+			remove[i] = true
+			if l := int(sect.Link); l < len(remove) && l != shstrndx {
+				remove[l] = true
+			}
+		}
+	}
+	for i, sect := range f.Sections {
+		// Relocations for removed sections go with them.
+		if (sect.Type == elf.SHT_REL || sect.Type == elf.SHT_RELA) && int(sect.Info) < len(remove) && remove[sect.Info] {
+			remove[i] = true
+		}
+	}
+	newIndex := make([]int, len(f.Sections))
+	n := 0
+	for i := range f.Sections {
+		if remove[i] {
+			newIndex[i] = -1
+			continue
+		}
+		newIndex[i] = n
+		n++
+	}
+
+	// The loaded segments, and the headers and sections within
+	// them, stay where they are. The other sections are moved up.
+	keepEnd := uint64(s.ehsize())
+	for _, p := range f.Progs {
+		if end := p.Off + p.Filesz; end > keepEnd {
+			keepEnd = end
+		}
+	}
+	if keepEnd > uint64(len(s.data)) {
+		return nil, fmt.Errorf("segment extends beyond end of file")
+	}
+	out := append([]byte(nil), s.data[:keepEnd]...)
+	if shoff, size := s.shoff(), uint64(len(f.Sections))*uint64(s.shentsize()); shoff+size <= keepEnd {
+		// Clear the old section headers, to be written again at the end.
+		for i := shoff; i < shoff+size; i++ {
+			out[i] = 0
+		}
+	}
+
+	var hdrs []elf.SectionHeader
+	var names []uint32
+	var shstrtab bytes.Buffer
+	shstrtab.WriteByte(0)
+	addName := func(name string) {
+		if name == "" {
+			names = append(names, 0)
+			return
+		}
+		names = append(names, uint32(shstrtab.Len()))
+		shstrtab.WriteString(name)
+		shstrtab.WriteByte(0)
+	}
+	for i, sect := range f.Sections {
+		if remove[i] {
+			continue
+		}
+		h := sect.SectionHeader
+		if h.Link != 0 {
+			if int(h.Link) >= len(newIndex) || newIndex[h.Link] < 0 {
+				h.Link = 0
+			} else {
+				h.Link = uint32(newIndex[h.Link])
+			}
+		}
+		if h.Type == elf.SHT_REL || h.Type == elf.SHT_RELA || h.Flags&elf.SHF_INFO_LINK != 0 {
+			if int(h.Info) < len(newIndex) && newIndex[h.Info] >= 0 {
+				h.Info = uint32(newIndex[h.Info])
+			}
+		}
+		switch {
+		case h.Type == elf.SHT_NULL, i == shstrndx:
+			// The section header string table is written below.
+		case h.Type == elf.SHT_NOBITS:
+			if h.Offset > uint64(len(out)) {
+				h.Offset = uint64(len(out))
+			}
+		case h.Offset+h.FileSize <= keepEnd:
+			// Already in place.
+		default:
+			out = s.appendSection(out, &h, sect)
+		}
+		if h.Type == elf.SHT_SYMTAB || h.Type == elf.SHT_DYNSYM {
+			if err := s.renumberSymbols(out[h.Offset:h.Offset+h.FileSize], sect, newIndex); err != nil {
+				return nil, err
+			}
+		}
+		hdrs = append(hdrs, h)
+		addName(sect.Name)
+	}
+
+	// The .gnu_debuglink section holds the name of the debug file,
+	// padded to 4 bytes, and its CRC-32 checksum.
+	debuglink := []byte(link)
+	debuglink = append(debuglink, make([]byte, 4-len(link)%4)...)
+	debuglink = append(debuglink, 0, 0, 0, 0)
+	s.f.ByteOrder.PutUint32(debuglink[len(debuglink)-4:], crc)
+	out = elfPad(out, 4)
+	hdrs = append(hdrs, elf.SectionHeader{
+		Type:      elf.SHT_PROGBITS,
+		Offset:    uint64(len(out)),
+		Size:      uint64(len(debuglink)),
+		FileSize:  uint64(len(debuglink)),
+		Addralign: 4,
+	})
+	addName(".gnu_debuglink")
+	out = append(out, debuglink...)
+
+	newShstrndx := newIndex[shstrndx]
+	hdrs[newShstrndx].Offset = uint64(len(out))
+	hdrs[newShstrndx].Size = uint64(shstrtab.Len())
+	hdrs[newShstrndx].FileSize = uint64(shstrtab.Len())
+	out = append(out, shstrtab.Bytes()...)
+
+	return s.finish(out, hdrs, names, uint16(newShstrndx), true)
+}
+
+// appendSection appends the contents of sect to out,
+// and sets the offset in h to where they were written.
+func (s *elfSplitter) appendSection(out []byte, h *elf.SectionHeader, sect *elf.Section) []byte {
+	if h.Addralign > 1 {
+		out = elfPad(out, int(h.Addralign))
+	}
+	h.Offset = uint64(len(out))
+	return append(out, s.data[sect.Offset:sect.Offset+sect.FileSize]...)
+}
+
+// renumberSymbols updates the section indexes in the symbol table syms,
+// which is the contents of sect, for the removal of sections.
+func (s *elfSplitter) renumberSymbols(syms []byte, sect *elf.Section, newIndex []int) error {
+	size, off := elf.Sym32Size, 14
+	if s.is64 {
+		size, off = elf.Sym64Size, 6
+	}
+	if sect.Entsize != 0 {
+		size = int(sect.Entsize)
+	}
+	for i := 0; i+size <= len(syms); i += size {
+		shndx := s.f.ByteOrder.Uint16(syms[i+off:])
+		if shndx == uint16(elf.SHN_UNDEF) || shndx >= uint16(elf.SHN_LORESERVE) {
+			continue
+		}
+		if int(shndx) >= len(newIndex) || newIndex[shndx] < 0 {
+			return fmt.Errorf("%s: symbol %d is in a removed section", sect.Name, i/size)
+		}
+		s.f.ByteOrder.PutUint16(syms[i+off:], uint16(newIndex[shndx]))
+	}
+	return nil
+}
+
+// finish writes the section headers hdrs, with the given name offsets,
+// at the end of out, and updates the ELF header. If keepProgs is not set,
+// the program headers are dropped.
+func (s *elfSplitter) finish(out []byte, hdrs []elf.SectionHeader, names []uint32, shstrndx uint16, keepProgs bool) ([]byte, error) {
+	order := s.f.ByteOrder
+	if s.is64 {
+		out = elfPad(out, 8)
+	} else {
+		out = elfPad(out, 4)
+	}
+	shoff := uint64(len(out))
+	var buf bytes.Buffer
+	for i, h := range hdrs {
+		size := h.Size
+		if h.Flags&elf.SHF_COMPRESSED != 0 {
+			size = h.FileSize
+		}
+		if s.is64 {
+			binary.Write(&buf, order, &elf.Section64{
+				Name:      names[i],
+				Type:      uint32(h.Type),
+				Flags:     uint64(h.Flags),
+				Addr:      h.Addr,
+				Off:       h.Offset,
+				Size:      size,
+				Link:      h.Link,
+				Info:      h.Info,
+				Addralign: h.Addralign,
+				Entsize:   h.Entsize,
+			})
+		} else {
+			binary.Write(&buf, order, &elf.Section32{
+				Name:      names[i],
+				Type:      uint32(h.Type),
+				Flags:     uint32(h.Flags),
+				Addr:      uint32(h.Addr),
+				Off:       uint32(h.Offset),
+				Size:      uint32(size),
+				Link:      h.Link,
+				Info:      h.Info,
+				Addralign: uint32(h.Addralign),
+				Entsize:   uint32(h.Entsize),
+			})
+		}
+	}
+	out = append(out, buf.Bytes()...)
+
+	buf.Reset()
+	r := bytes.NewReader(s.data)
+	if s.is64 {
+		var hdr elf.Header64
+		if err := binary.Read(r, order, &hdr); err != nil {
+			return nil, err
+		}
+		hdr.Shoff = shoff
+		hdr.Shnum = uint16(len(hdrs))
+		hdr.Shstrndx = shstrndx
+		if !keepProgs {
+			hdr.Phoff, hdr.Phnum = 0, 0
+		}
+		binary.Write(&buf, order, &hdr)
+	} else {
+		var hdr elf.Header32
+		if err := binary.Read(r, order, &hdr); err != nil {
+			return nil, err
+		}
+		hdr.Shoff = uint32(shoff)
+		hdr.Shnum = uint16(len(hdrs))
+		hdr.Shstrndx = shstrndx
+		if !keepProgs {
+			hdr.Phoff, hdr.Phnum = 0, 0
+		}
+		binary.Write(&buf, order, &hdr)
+	}
+	copy(out, buf.Bytes())
+	return out, nil
+}
+
+// ehsize returns the size of the ELF header.
+func (s *elfSplitter) ehsize() int {
+	if s.is64 {
+		return binary.Size(elf.Header64{})
+	}
+	return binary.Size(elf.Header32{})
+}
+
+// shentsize returns the size of a section header.
+func (s *elfSplitter) shentsize() int {
+	if s.is64 {
+		return binary.Size(elf.Section64{})
+	}
+	return binary.Size(elf.Section32{})
+}
+
+// shoff returns the file offset of the section headers.
+func (s *elfSplitter) shoff() uint64 {
+	if s.is64 {
+		return s.f.ByteOrder.Uint64(s.data[40:])
+	}
+	return uint64(s.f.ByteOrder.Uint32(s.data[32:]))
+}
+
+// shstrndx returns the index of the section header string table.
+func (s *elfSplitter) shstrndx() uint16 {
+	if s.is64 {
+		return s.f.ByteOrder.Uint16(s.data[62:])
+	}
+	return s.f.ByteOrder.Uint16(s.data[50:])
+}
+
+// nameOffset returns the offset of the name of section i
+// in the section header string table.
+func (s *elfSplitter) nameOffset(i int) uint32 {
+	off := s.shoff() + uint64(i*s.shentsize())
+	return s.f.ByteOrder.Uint32(s.data[off:])
+}
+
+// elfPad pads b with zeros to a multiple of align bytes.
+func elfPad(b []byte, align int) []byte {
+	for len(b)%align != 0 {
+		b = append(b, 0)
+	}
+	return b
+}
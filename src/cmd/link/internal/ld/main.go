@@ -36,6 +36,7 @@ import (
 	"cmd/internal/objabi"
 	"cmd/internal/sys"
 	"cmd/link/internal/benchmark"
+	"crypto/sha1"
 	"flag"
 	"internal/buildcfg"
 	"log"
@@ -49,6 +50,7 @@ var (
 	pkglistfornote []byte
 	windowsgui     bool // writes a "GUI binary" instead of a "console binary"
 	ownTmpDir      bool // set to true if tmp dir created by linker (e.g. no -tmpdir)
+	stripSymtab    bool // strip the symbol table after writing it to the -debugfile
 )
 
 func init() {
@@ -64,6 +66,8 @@ var (
 
 	flagInstallSuffix = flag.String("installsuffix", "", "set package directory `suffix`")
 	flagDumpDep       = flag.Bool("dumpdep", false, "dump symbol dependency graph")
+	flagDebugFile     = flag.String("debugfile", "", "write DWARF to separate ELF debug `file`")
+	flagReachReport   = flag.String("reachreport", "", "write report of why symbols are reachable to `file`")
 	flagRace          = flag.Bool("race", false, "enable race detector")
 	flagMsan          = flag.Bool("msan", false, "enable MSan interface")
 	flagAslr          = flag.Bool("aslr", true, "enable ASLR for buildmode=c-shared on windows")
@@ -170,6 +174,27 @@ func Main(arch *sys.Arch, theArch Arch) {
 
 	checkStrictDups = *FlagStrictDups
 
+	if *flagDebugFile != "" {
+		if *FlagW {
+			Errorf(nil, "-debugfile cannot be used with -w")
+			usage()
+		}
+		// The symbol table goes to the debug file even with -s,
+		// which then only strips it from the executable.
+		stripSymtab = *FlagS
+		*FlagS = false
+		if len(buildinfo) == 0 {
+			// The executable and its debug file are identified
+			// by a GNU build ID, by default derived from the Go one.
+			if *flagBuildid == "" {
+				Errorf(nil, "-debugfile requires -B or -buildid")
+				usage()
+			}
+			sum := sha1.Sum([]byte(*flagBuildid))
+			buildinfo = sum[:]
+		}
+	}
+
 	startProfile()
 	if ctxt.BuildMode == BuildModeUnset {
 		ctxt.BuildMode.Set("exe")
@@ -219,6 +244,9 @@ func Main(arch *sys.Arch, theArch Arch) {
 	if ctxt.linkShared && !ctxt.IsELF {
 		Exitf("-linkshared can only be used on elf systems")
 	}
+	if *flagDebugFile != "" && (!ctxt.IsELF || ctxt.BuildMode == BuildModeCArchive) {
+		Exitf("-debugfile can only be used for elf executables and shared objects")
+	}
 
 	if ctxt.Debugvlog != 0 {
 		ctxt.Logf("HEADER = -H%d -T0x%x -R0x%x\n", ctxt.HeadType, uint64(*FlagTextAddr), uint32(*FlagRound))
@@ -250,6 +278,10 @@ func Main(arch *sys.Arch, theArch Arch) {
 
 	bench.Start("deadcode")
 	deadcode(ctxt)
+	if *flagReachReport != "" {
+		bench.Start("reachreport")
+		reachreport(ctxt.loader, *flagReachReport)
+	}
 
 	bench.Start("linksetup")
 	ctxt.linksetup()
@@ -359,6 +391,12 @@ func Main(arch *sys.Arch, theArch Arch) {
 
 	bench.Start("hostlink")
 	ctxt.hostlink()
+	if *flagDebugFile != "" {
+		bench.Start("splitdwarf")
+		if err := elfSplitDwarf(*flagOutfile, *flagDebugFile, stripSymtab); err != nil {
+			Exitf("writing debug file: %v", err)
+		}
+	}
 	if ctxt.Debugvlog != 0 {
 		ctxt.Logf("%s", ctxt.loader.Stat())
 		ctxt.Logf("%d liveness data\n", liveness)
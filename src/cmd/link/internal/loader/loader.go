@@ -252,9 +252,10 @@ type Loader struct {
 
 	relocVariant map[relocId]sym.RelocVariant // stores variant relocs
 
-	// Used to implement field tracking; created during deadcode if
-	// field tracking is enabled. Reachparent[K] contains the index of
-	// the symbol that triggered the marking of symbol K as live.
+	// Used to implement field tracking and the reachability report;
+	// created during deadcode if field tracking is enabled or a report
+	// is requested. Reachparent[K] contains the index of the symbol
+	// that triggered the marking of symbol K as live.
 	Reachparent []Sym
 
 	// CgoExports records cgo-exported symbols by SymName.
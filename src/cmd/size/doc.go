@@ -0,0 +1,46 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Size reports what the space in a Go binary is used for.
+//
+// Usage:
+//	go tool size [options] binary
+//	go tool size [options] -diff old new
+//	go tool size -why symbol report
+//	go tool size -edges [-n count] report
+//
+// By default, size prints the total size of the symbols of each package
+// in the binary, largest first, along with its share of the total.
+// Symbols that belong to no Go package are attributed to pseudo-packages:
+// "(go)" for data generated by the toolchain, such as string literals,
+// "(types)" for descriptors of unnamed types, and "(other)" for
+// everything else, such as symbols from C code.
+//
+// The options are:
+//
+//	-by {package,module,section,symbol}
+//		aggregate sizes by package (the default), by module,
+//		by section of the binary, or not at all.
+//		The modules are those recorded in the binary by the go command.
+//	-diff
+//		compare two binaries, such as two builds of a program,
+//		printing the entries whose sizes differ,
+//		ordered by decreasing change in size.
+//	-n count
+//		print only the count largest entries
+//
+// Why a symbol is in a binary is recorded by the linker when given the
+// -reachreport flag, as in
+//
+//	go build -ldflags=-reachreport=report.txt
+//
+// The report lists, for each reachable symbol, the symbol whose reference
+// to it made it reachable. Given such a report, the -why flag prints the
+// chain of references from the entry point or another root of the linker's
+// dead code elimination to the symbol with the given name, and the -edges
+// flag attributes the size of each symbol to the package dependency edge
+// through which that chain first entered the symbol's package, telling
+// which imports are responsible for how much code.
+//
+package main
@@ -0,0 +1,191 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Names of the pseudo-packages holding symbols
+// that do not belong to a Go package.
+const (
+	goData  = "(go)"    // data generated by the toolchain, such as go.string.* and go.buildid
+	goTypes = "(types)" // descriptors of unnamed types
+	other   = "(other)" // symbols that are not Go symbols, such as those from C code
+)
+
+// symPackage returns the import path of the package
+// that the symbol with the given name belongs to.
+func symPackage(name string) string {
+	switch {
+	case strings.HasPrefix(name, "type..eq."):
+		return namePackage(name[len("type..eq."):])
+	case strings.HasPrefix(name, "type..hash."):
+		return namePackage(name[len("type..hash."):])
+	case strings.HasPrefix(name, "type..importpath."):
+		return unescape(strings.TrimSuffix(name[len("type..importpath."):], "."))
+	case strings.HasPrefix(name, "type.."):
+		return goTypes
+	case strings.HasPrefix(name, "type."):
+		return typePackage(name[len("type."):])
+	case strings.HasPrefix(name, "go.itab."):
+		// go.itab.T,I belongs to the package of the concrete type T.
+		name = name[len("go.itab."):]
+		if i := strings.LastIndex(name, ","); i >= 0 {
+			name = name[:i]
+		}
+		return typePackage(name)
+	case strings.HasPrefix(name, "go.importpath."):
+		return unescape(strings.TrimSuffix(name[len("go.importpath."):], "."))
+	case strings.HasPrefix(name, "go."):
+		return goData
+	}
+	return namePackage(name)
+}
+
+// typePackage returns the package of the type with the given name,
+// or goTypes if it is not a named type.
+func typePackage(name string) string {
+	name = strings.TrimLeft(name, "*")
+	if name == "" || strings.ContainsAny(name[:1], "[{(") ||
+		strings.HasPrefix(name, "map[") || strings.HasPrefix(name, "chan ") ||
+		strings.HasPrefix(name, "chan<-") || strings.HasPrefix(name, "<-chan") ||
+		strings.HasPrefix(name, "func(") || strings.HasPrefix(name, "struct {") ||
+		strings.HasPrefix(name, "interface {") || strings.HasPrefix(name, "noalg.") {
+		return goTypes
+	}
+	if !strings.Contains(name, ".") {
+		// A predeclared type, such as int.
+		return goTypes
+	}
+	return namePackage(name)
+}
+
+// namePackage returns the package of the Go function or variable
+// with the given name, which is qualified by its package path.
+func namePackage(name string) string {
+	// The package path ends at the first dot after its last slash.
+	// Only look for slashes before the parts of the name that may
+	// contain other names, such as the receiver of a method.
+	end := len(name)
+	if i := strings.IndexAny(name, "([{ ,"); i >= 0 {
+		end = i
+	}
+	slash := strings.LastIndex(name[:end], "/")
+	dot := strings.Index(name[slash+1:], ".")
+	if dot < 0 {
+		return other
+	}
+	return unescape(name[:slash+1+dot])
+}
+
+// unescape undoes the escaping of package paths in symbol names,
+// as done by cmd/internal/objabi.PathToPrefix.
+func unescape(path string) string {
+	if !strings.Contains(path, "%") {
+		return path
+	}
+	var b strings.Builder
+	for i := 0; i < len(path); i++ {
+		if path[i] == '%' && i+2 < len(path) {
+			if c, err := hex.DecodeString(path[i+1 : i+3]); err == nil {
+				b.WriteByte(c[0])
+				i += 2
+				continue
+			}
+		}
+		b.WriteByte(path[i])
+	}
+	return b.String()
+}
+
+// isStd reports whether the package path is that of a standard library
+// package, including the packages vendored into it.
+func isStd(path string) bool {
+	elem := path
+	if i := strings.Index(path, "/"); i >= 0 {
+		elem = path[:i]
+	}
+	return !strings.Contains(elem, ".") || strings.HasPrefix(path, "vendor/")
+}
+
+// The module information recorded in Go binaries is bracketed
+// by these markers; see cmd/go/internal/modload.
+var (
+	infoStart, _ = hex.DecodeString("3077af0c9274080241e1c107e6d618e6")
+	infoEnd, _   = hex.DecodeString("f932433186182072008242104116d8f2")
+)
+
+// modules maps the packages of a binary to the modules providing them.
+type modules struct {
+	main  string   // path of the main module
+	paths []string // paths of the modules, including the main module
+}
+
+// readModules reads the module information of the named binary.
+// A binary built outside module mode has none.
+func readModules(file string) (*modules, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	mods := new(modules)
+	for {
+		i := bytes.Index(data, infoStart)
+		if i < 0 {
+			return mods, nil
+		}
+		data = data[i+len(infoStart):]
+		if bytes.HasPrefix(data, []byte("path\t")) {
+			break
+		}
+	}
+	j := bytes.Index(data, infoEnd)
+	if j < 0 {
+		return nil, fmt.Errorf("reading %s: malformed module information", file)
+	}
+	for _, line := range strings.Split(string(data[:j]), "\n") {
+		f := strings.Split(line, "\t")
+		if len(f) < 2 {
+			continue
+		}
+		switch f[0] {
+		case "mod":
+			mods.main = f[1]
+			mods.paths = append(mods.paths, f[1])
+		case "dep":
+			mods.paths = append(mods.paths, f[1])
+		}
+	}
+	return mods, nil
+}
+
+// lookup returns the module providing the package pkg, "std" for the
+// standard library, or pkg itself for the pseudo-packages.
+func (m *modules) lookup(pkg string) string {
+	if strings.HasPrefix(pkg, "(") {
+		return pkg
+	}
+	if pkg == "main" && m.main != "" {
+		return m.main
+	}
+	best := ""
+	for _, path := range m.paths {
+		if (pkg == path || strings.HasPrefix(pkg, path+"/")) && len(path) > len(best) {
+			best = path
+		}
+	}
+	switch {
+	case best != "":
+		return best
+	case isStd(pkg):
+		return "std"
+	}
+	return "(unknown)"
+}
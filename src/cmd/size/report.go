@@ -0,0 +1,155 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// A report is a reachability report written by the linker
+// when given the -reachreport flag.
+type report struct {
+	syms   map[int]*reportSym
+	byName map[string][]int
+}
+
+// A reportSym is a reachable symbol listed in a report.
+type reportSym struct {
+	parent int // symbol that made it reachable, 0 for the roots
+	size   int64
+	kind   string
+	pkg    string // package as recorded by the linker
+	name   string
+}
+
+// readReport reads the named reachability report.
+func readReport(file string) (*report, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := &report{
+		syms:   make(map[int]*reportSym),
+		byName: make(map[string][]int),
+	}
+	s := bufio.NewScanner(f)
+	s.Buffer(nil, 1<<20)
+	for lineno := 1; s.Scan(); lineno++ {
+		line := s.Text()
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		f := strings.SplitN(line, "\t", 6)
+		if len(f) != 6 {
+			return nil, fmt.Errorf("%s:%d: malformed line", file, lineno)
+		}
+		id, err1 := strconv.Atoi(f[0])
+		parent, err2 := strconv.Atoi(f[1])
+		size, err3 := strconv.ParseInt(f[2], 10, 64)
+		if err1 != nil || err2 != nil || err3 != nil {
+			return nil, fmt.Errorf("%s:%d: malformed line", file, lineno)
+		}
+		r.syms[id] = &reportSym{parent: parent, size: size, kind: f[3], pkg: f[4], name: f[5]}
+		r.byName[f[5]] = append(r.byName[f[5]], id)
+	}
+	if err := s.Err(); err != nil {
+		return nil, fmt.Errorf("reading %s: %v", file, err)
+	}
+	return r, nil
+}
+
+// pkg returns the package of the symbol id. Symbols generated by the
+// toolchain are attributed to the package the linker found them in.
+func (r *report) pkg(id int) string {
+	s := r.syms[id]
+	pkg := symPackage(s.name)
+	if strings.HasPrefix(pkg, "(") && s.pkg != "" {
+		return s.pkg
+	}
+	return pkg
+}
+
+// parent returns the parent of the symbol id, or 0 if it has none.
+func (r *report) parent(id int) int {
+	if s := r.syms[id]; s != nil {
+		if _, ok := r.syms[s.parent]; ok {
+			return s.parent
+		}
+	}
+	return 0
+}
+
+// why returns the chains of references, starting at a root, that made
+// the symbols with the given name reachable.
+func (r *report) why(name string) [][]string {
+	var chains [][]string
+	for _, id := range r.byName[name] {
+		var chain []string
+		seen := make(map[int]bool)
+		for ; id != 0 && !seen[id]; id = r.parent(id) {
+			seen[id] = true
+			chain = append(chain, r.syms[id].name)
+		}
+		for i, j := 0, len(chain)-1; i < j; i, j = i+1, j-1 {
+			chain[i], chain[j] = chain[j], chain[i]
+		}
+		chains = append(chains, chain)
+	}
+	return chains
+}
+
+// edges attributes the size of each symbol to the package dependency
+// edge through which the chain of references keeping it first entered
+// its package. For example, if main.main calls fmt.Println, which calls
+// fmt.Fprintln, the sizes of both fmt functions are attributed to the
+// edge "main -> fmt".
+func (r *report) edges() map[string]int64 {
+	edge := make(map[int]string, len(r.syms))
+	find := func(id int) string {
+		// Walk up to the first symbol whose edge is known, or whose
+		// parent is in another package, then fill in the symbols below.
+		var path []int
+		e := ""
+		for {
+			if known, ok := edge[id]; ok {
+				e = known
+				if e == "" {
+					// A cycle, through a root marked again.
+					e = "(root) -> " + r.pkg(id)
+				}
+				break
+			}
+			edge[id] = "" // cut cycles
+			path = append(path, id)
+			pkg := r.pkg(id)
+			parent := r.parent(id)
+			if parent == 0 {
+				e = "(root) -> " + pkg
+				break
+			}
+			if ppkg := r.pkg(parent); ppkg != pkg {
+				e = ppkg + " -> " + pkg
+				break
+			}
+			id = parent
+		}
+		for _, id := range path {
+			edge[id] = e
+		}
+		return e
+	}
+
+	m := make(map[string]int64)
+	for id, s := range r.syms {
+		m[find(id)] += s.size
+	}
+	return m
+}
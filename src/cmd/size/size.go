@@ -0,0 +1,252 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+
+	"cmd/internal/objfile"
+)
+
+const helpText = `usage: go tool size [options] binary
+       go tool size [options] -diff old new
+       go tool size -why symbol report
+       go tool size -edges [-n count] report
+  -by {package,module,section,symbol}
+      aggregate sizes by package (default), module, section or symbol
+  -diff
+      compare two binaries, ordering by the change in size
+  -edges
+      attribute the sizes in a linker reachability report
+      to the package dependency edges that kept them
+  -n count
+      print only the count largest entries
+  -why symbol
+      print the chain of references that kept symbol
+      in the binary, according to a linker reachability report
+`
+
+func usage() {
+	fmt.Fprintf(os.Stderr, helpText)
+	os.Exit(2)
+}
+
+var (
+	byFlag    = flag.String("by", "package", "")
+	diffFlag  = flag.Bool("diff", false, "")
+	edgesFlag = flag.Bool("edges", false, "")
+	nFlag     = flag.Int("n", 0, "")
+	whyFlag   = flag.String("why", "", "")
+)
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("size: ")
+	flag.Usage = usage
+	flag.Parse()
+
+	switch *byFlag {
+	case "package", "module", "section", "symbol":
+		// ok
+	default:
+		fmt.Fprintf(os.Stderr, "size: unknown aggregation %q\n", *byFlag)
+		os.Exit(2)
+	}
+
+	args := flag.Args()
+	w := bufio.NewWriter(os.Stdout)
+	defer w.Flush()
+
+	switch {
+	case *whyFlag != "" || *edgesFlag:
+		if len(args) != 1 || *whyFlag != "" && *edgesFlag {
+			usage()
+		}
+		r, err := readReport(args[0])
+		if err != nil {
+			log.Fatal(err)
+		}
+		if *edgesFlag {
+			printSizes(w, r.edges())
+			break
+		}
+		chains := r.why(*whyFlag)
+		if len(chains) == 0 {
+			w.Flush()
+			log.Fatalf("%s is not reachable according to %s", *whyFlag, args[0])
+		}
+		printChains(w, chains)
+
+	case *diffFlag:
+		if len(args) != 2 {
+			usage()
+		}
+		oldSizes, err := sizes(args[0], *byFlag)
+		if err != nil {
+			log.Fatal(err)
+		}
+		newSizes, err := sizes(args[1], *byFlag)
+		if err != nil {
+			log.Fatal(err)
+		}
+		printDiff(w, oldSizes, newSizes)
+
+	default:
+		if len(args) != 1 {
+			usage()
+		}
+		s, err := sizes(args[0], *byFlag)
+		if err != nil {
+			log.Fatal(err)
+		}
+		printSizes(w, s)
+	}
+}
+
+// sizes returns the sizes of the contents of the named binary,
+// aggregated as given by by.
+func sizes(file, by string) (map[string]int64, error) {
+	f, err := objfile.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	m := make(map[string]int64)
+	if by == "section" {
+		sects, err := f.Sections()
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %v", file, err)
+		}
+		for _, s := range sects {
+			m[s.Name] += int64(s.Size)
+		}
+		return m, nil
+	}
+
+	syms, err := f.Symbols()
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %v", file, err)
+	}
+	if len(syms) == 0 {
+		return nil, fmt.Errorf("reading %s: no symbols", file)
+	}
+	var mods *modules
+	if by == "module" {
+		if mods, err = readModules(file); err != nil {
+			return nil, err
+		}
+	}
+	for _, s := range syms {
+		if s.Code == 'U' || s.Size <= 0 {
+			continue
+		}
+		var key string
+		switch by {
+		case "package":
+			key = symPackage(s.Name)
+		case "module":
+			key = mods.lookup(symPackage(s.Name))
+		case "symbol":
+			key = s.Name
+		}
+		m[key] += s.Size
+	}
+	return m, nil
+}
+
+// An entry is a line of output.
+type entry struct {
+	name     string
+	old, new int64
+}
+
+// sortEntries sorts the entries by decreasing size, or by decreasing
+// change in size if diff is set, and trims them as requested by -n.
+func sortEntries(entries []entry, diff bool) []entry {
+	key := func(e entry) int64 {
+		if diff {
+			d := e.new - e.old
+			if d < 0 {
+				d = -d
+			}
+			return d
+		}
+		return e.new
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		ki, kj := key(entries[i]), key(entries[j])
+		if ki != kj {
+			return ki > kj
+		}
+		return entries[i].name < entries[j].name
+	})
+	if *nFlag > 0 && len(entries) > *nFlag {
+		entries = entries[:*nFlag]
+	}
+	return entries
+}
+
+func printSizes(w io.Writer, m map[string]int64) {
+	var entries []entry
+	var total int64
+	for name, size := range m {
+		entries = append(entries, entry{name: name, new: size})
+		total += size
+	}
+	entries = sortEntries(entries, false)
+	for _, e := range entries {
+		pct := 0.0
+		if total > 0 {
+			pct = 100 * float64(e.new) / float64(total)
+		}
+		fmt.Fprintf(w, "%10d %5.1f%% %s\n", e.new, pct, e.name)
+	}
+	fmt.Fprintf(w, "%10d %5.1f%% %s\n", total, 100.0, "total")
+}
+
+func printDiff(w io.Writer, oldSizes, newSizes map[string]int64) {
+	var entries []entry
+	var total entry
+	for name, size := range oldSizes {
+		entries = append(entries, entry{name: name, old: size, new: newSizes[name]})
+		total.old += size
+	}
+	for name, size := range newSizes {
+		if _, ok := oldSizes[name]; !ok {
+			entries = append(entries, entry{name: name, new: size})
+		}
+		total.new += size
+	}
+	entries = sortEntries(entries, true)
+	fmt.Fprintf(w, "%10s %10s %10s %s\n", "old", "new", "delta", "name")
+	for _, e := range append(entries, entry{"total", total.old, total.new}) {
+		if e.old == e.new && e.name != "total" {
+			continue
+		}
+		fmt.Fprintf(w, "%10d %10d %+10d %s\n", e.old, e.new, e.new-e.old, e.name)
+	}
+}
+
+func printChains(w io.Writer, chains [][]string) {
+	for i, chain := range chains {
+		if i > 0 {
+			fmt.Fprintf(w, "\n")
+		}
+		for j, name := range chain {
+			if j == 0 {
+				fmt.Fprintf(w, "%s\n", name)
+			} else {
+				fmt.Fprintf(w, "\t-> %s\n", name)
+			}
+		}
+	}
+}
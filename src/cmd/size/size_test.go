@@ -0,0 +1,174 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"internal/testenv"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+var testsizepath string // path to size command created for testing purposes
+
+// The TestMain function creates a size command for testing purposes and
+// deletes it after the tests have been run.
+func TestMain(m *testing.M) {
+	os.Exit(testMain(m))
+}
+
+func testMain(m *testing.M) int {
+	if !testenv.HasGoBuild() {
+		return 0
+	}
+
+	tmpDir, err := os.MkdirTemp("", "TestSize")
+	if err != nil {
+		fmt.Println("TempDir failed:", err)
+		return 2
+	}
+	defer os.RemoveAll(tmpDir)
+
+	testsizepath = filepath.Join(tmpDir, "testsize.exe")
+	gotool, err := testenv.GoTool()
+	if err != nil {
+		fmt.Println("GoTool failed:", err)
+		return 2
+	}
+	out, err := exec.Command(gotool, "build", "-o", testsizepath, "cmd/size").CombinedOutput()
+	if err != nil {
+		fmt.Printf("go build -o %v cmd/size: %v\n%s", testsizepath, err, string(out))
+		return 2
+	}
+
+	return m.Run()
+}
+
+func TestSymPackage(t *testing.T) {
+	tests := []struct {
+		name, pkg string
+	}{
+		{"main.main", "main"},
+		{"runtime.mallocgc", "runtime"},
+		{"net/http.(*Server).Serve", "net/http"},
+		{"net/http.(*Server).Serve.func1", "net/http"},
+		{"gopkg.in/yaml%2ev2.Marshal", "gopkg.in/yaml.v2"},
+		{"example.com/m/v2/pkg.T.String", "example.com/m/v2/pkg"},
+		{"type.*net/http.Server", "net/http"},
+		{"type.net/url.URL", "net/url"},
+		{"type.[]string", goTypes},
+		{"type.map[string]int", goTypes},
+		{"type.int", goTypes},
+		{"type.func(*net/http.Request) error", goTypes},
+		{"type..eq.net/url.URL", "net/url"},
+		{"type..namedata.*func()", goTypes},
+		{"type..importpath.net/http.", "net/http"},
+		{"go.itab.*os.File,io.Writer", "os"},
+		{"go.itab.*net/http.http2Framer,io.Writer", "net/http"},
+		{"go.buildid", goData},
+		{"go.string.\"hello\"", goData},
+		{"x_cgo_init", other},
+		{"_rt0_amd64_linux", other},
+	}
+	for _, tt := range tests {
+		if pkg := symPackage(tt.name); pkg != tt.pkg {
+			t.Errorf("symPackage(%q) = %q, want %q", tt.name, pkg, tt.pkg)
+		}
+	}
+}
+
+func TestModulesLookup(t *testing.T) {
+	mods := &modules{
+		main:  "example.com/m",
+		paths: []string{"example.com/m", "example.com/dep", "example.com/dep/sub"},
+	}
+	tests := []struct {
+		pkg, mod string
+	}{
+		{"main", "example.com/m"},
+		{"example.com/m/internal/x", "example.com/m"},
+		{"example.com/dep", "example.com/dep"},
+		{"example.com/dep/sub/pkg", "example.com/dep/sub"},
+		{"example.com/depot", "(unknown)"},
+		{"fmt", "std"},
+		{"vendor/golang.org/x/net/idna", "std"},
+		{goData, goData},
+	}
+	for _, tt := range tests {
+		if mod := mods.lookup(tt.pkg); mod != tt.mod {
+			t.Errorf("lookup(%q) = %q, want %q", tt.pkg, mod, tt.mod)
+		}
+	}
+}
+
+const testprog = `
+package main
+
+import "fmt"
+
+func main() {
+	fmt.Println("hello")
+}
+`
+
+func TestSize(t *testing.T) {
+	testenv.MustHaveGoBuild(t)
+	t.Parallel()
+
+	tmpdir := t.TempDir()
+	src := filepath.Join(tmpdir, "main.go")
+	if err := os.WriteFile(src, []byte(testprog), 0666); err != nil {
+		t.Fatal(err)
+	}
+	exe := filepath.Join(tmpdir, "a.exe")
+	report := filepath.Join(tmpdir, "report.txt")
+	cmd := exec.Command(testenv.GoToolPath(t), "build", "-ldflags=-reachreport="+report, "-o", exe, src)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("go build: %v\n%s", err, out)
+	}
+
+	run := func(args ...string) string {
+		t.Helper()
+		out, err := exec.Command(testsizepath, args...).CombinedOutput()
+		if err != nil {
+			t.Fatalf("size %s: %v\n%s", strings.Join(args, " "), err, out)
+		}
+		return string(out)
+	}
+	contains := func(out string, want ...string) {
+		t.Helper()
+		for _, w := range want {
+			if !strings.Contains(out, w) {
+				t.Errorf("output does not contain %q:\n%s", w, out)
+			}
+		}
+	}
+
+	contains(run(exe), " runtime\n", " fmt\n", " total\n")
+	contains(run("-by", "module", exe), " std\n")
+	contains(run("-by", "symbol", exe), " main.main\n")
+	if runtime.GOOS == "linux" {
+		contains(run("-by", "section", exe), " .text\n")
+	}
+	if out := run("-n", "1", exe); strings.Count(out, "\n") != 2 {
+		t.Errorf("size -n 1 printed %d lines, want 2:\n%s", strings.Count(out, "\n"), out)
+	}
+
+	// A binary does not differ from itself, except for the total.
+	if out := run("-diff", exe, exe); strings.Count(out, "\n") != 2 {
+		t.Errorf("size -diff of identical binaries printed:\n%s", out)
+	}
+
+	contains(run("-why", "fmt.Fprintln", report), "\t-> main.main\n", "\t-> fmt.Fprintln\n")
+	contains(run("-edges", report), " main -> fmt\n", " (root) -> runtime\n")
+
+	if out, err := exec.Command(testsizepath, "-why", "no.such", report).CombinedOutput(); err == nil {
+		t.Errorf("size -why no.such succeeded:\n%s", out)
+	}
+}
@@ -291,7 +291,7 @@ func (t Tag) GoString() string {
 // Location expression operators.
 // The debug info encodes value locations like 8(R3)
 // as a sequence of these op codes.
-// This package does not implement full expressions;
+// EvalExpr evaluates such expressions; in addition,
 // the opPlusUconst operator is expected by the type parser.
 const (
 	opAddr       = 0x03 /* 1 op, const addr */
@@ -372,6 +372,11 @@ const (
 	opConvert         = 0xA8
 	opReinterpret     = 0xA9
 	/* 0xE0-0xFF reserved for user-specific */
+	opGNUPushTLSAddress  = 0xE0
+	opGNUImplicitPointer = 0xF2
+	opGNUEntryValue      = 0xF3
+	opGNUAddrIndex       = 0xFB
+	opGNUConstIndex      = 0xFC
 )
 
 // Basic type encodings -- the value for AttrEncoding in a TagBaseType Entry.
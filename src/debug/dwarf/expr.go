@@ -0,0 +1,698 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// DWARF location expression evaluation.
+// DWARF v5 section 2.5 and 2.6.
+
+package dwarf
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// A PieceKind is the kind of location of a piece of a value.
+type PieceKind int
+
+const (
+	// PieceMemory is a piece stored in memory at Addr.
+	PieceMemory PieceKind = iota
+	// PieceRegister is a piece stored in register Reg.
+	PieceRegister
+	// PieceValue is a piece that is not stored anywhere but
+	// whose value, Value, is known.
+	PieceValue
+	// PieceBytes is a piece that is not stored anywhere but
+	// whose contents, Bytes, are given by the expression.
+	PieceBytes
+	// PieceImplicitPointer is a pointer that has been optimized away.
+	// Its value is the address of the variable described by the
+	// entry at offset Ref, plus Offset.
+	PieceImplicitPointer
+	// PieceUnavailable is a piece whose value has been optimized away.
+	PieceUnavailable
+)
+
+var pieceKindNames = [...]string{
+	PieceMemory:          "PieceMemory",
+	PieceRegister:        "PieceRegister",
+	PieceValue:           "PieceValue",
+	PieceBytes:           "PieceBytes",
+	PieceImplicitPointer: "PieceImplicitPointer",
+	PieceUnavailable:     "PieceUnavailable",
+}
+
+func (k PieceKind) String() string {
+	if k >= 0 && int(k) < len(pieceKindNames) {
+		return pieceKindNames[k]
+	}
+	return "PieceKind(" + strconv.Itoa(int(k)) + ")"
+}
+
+// A Piece describes where a piece of a value is.
+// Only the fields relevant to its Kind are set.
+type Piece struct {
+	Kind   PieceKind
+	Addr   uint64 // PieceMemory
+	Reg    int    // PieceRegister
+	Value  uint64 // PieceValue
+	Bytes  []byte // PieceBytes
+	Ref    Offset // PieceImplicitPointer
+	Offset int64  // PieceImplicitPointer
+
+	// BitSize is the size of the piece in bits, or 0 if the
+	// value is made of a single piece. BitOffset is the offset in
+	// bits of the piece within its location, as given by
+	// DW_OP_bit_piece.
+	BitSize   int64
+	BitOffset int64
+}
+
+// An ExprEnv describes the state of the program in which a location
+// expression is evaluated.
+//
+// The functions supply the parts of that state which operators of
+// the expression refer to. They may be left nil, in which case the
+// evaluation of expressions using the corresponding operators fails.
+type ExprEnv struct {
+	// AddrSize is the size of a target address in bytes: 1, 2, 4 or 8.
+	// Zero means 8. Stack values are computed modulo 2^(8*AddrSize).
+	AddrSize int
+
+	// ByteOrder is the byte order of the target, used to read memory.
+	// Nil means little endian.
+	ByteOrder binary.ByteOrder
+
+	// Dwarf64 reports whether the expression comes from 64-bit DWARF,
+	// which determines the size of DIE references in the expression.
+	Dwarf64 bool
+
+	// Reg returns the contents of register reg in the current frame.
+	// Registers are numbered as by the DWARF ABI of the target.
+	Reg func(reg int) (uint64, error)
+
+	// ReadMemory reads len(buf) bytes of memory at addr into buf.
+	ReadMemory func(addr uint64, buf []byte) error
+
+	// FrameBase returns the frame base of the current function,
+	// as described by the AttrFrameBase attribute of its entry.
+	FrameBase func() (uint64, error)
+
+	// CFA returns the canonical frame address of the current frame,
+	// as described by call frame information.
+	CFA func() (uint64, error)
+
+	// EntryReg returns the contents register reg had on entry to the
+	// current function. It is needed by DW_OP_entry_value.
+	EntryReg func(reg int) (uint64, error)
+
+	// ObjectAddr returns the address of the object being described,
+	// for DW_OP_push_object_address.
+	ObjectAddr func() (uint64, error)
+
+	// TLSAddr returns the address of the thread-local variable at
+	// offset off in the thread-local storage of the current module
+	// and thread, for DW_OP_form_tls_address.
+	TLSAddr func(off uint64) (uint64, error)
+
+	// Addrx returns the address at index idx in the .debug_addr
+	// section for the unit of the expression, for DW_OP_addrx and
+	// DW_OP_constx. Data.ExprEnv sets it.
+	Addrx func(idx uint64) (uint64, error)
+}
+
+// ExprEnv returns an ExprEnv for the location expressions of
+// entry e, with the fields that depend on the debugging information
+// set. The caller must set the functions describing the state of the
+// program.
+func (d *Data) ExprEnv(e *Entry) (*ExprEnv, error) {
+	i := d.offsetToUnit(e.Offset)
+	if i == -1 {
+		return nil, errors.New("no unit for entry")
+	}
+	u := &d.unit[i]
+	cu, _, err := d.baseAddressForEntry(e)
+	if err != nil {
+		return nil, err
+	}
+	addrBase, _ := cu.Val(AttrAddrBase).(int64)
+	dwarf64, _ := u.dwarf64()
+	return &ExprEnv{
+		AddrSize:  u.addrsize(),
+		ByteOrder: d.order,
+		Dwarf64:   dwarf64,
+		Addrx: func(idx uint64) (uint64, error) {
+			return d.debugAddr(u, uint64(addrBase), idx)
+		},
+	}, nil
+}
+
+// EvalExpr evaluates the DWARF location expression expr in env
+// and returns the pieces of the described value. If the value is a
+// single piece, its BitSize is 0. An empty expression describes a
+// value that is unavailable.
+//
+// Typed stack values, calls to other entries, and address spaces
+// (DW_OP_xderef) are not supported.
+func EvalExpr(expr []byte, env *ExprEnv) ([]Piece, error) {
+	ev := newEvaluator(expr, env)
+	return ev.run()
+}
+
+// An ExprError reports a location expression that could not be
+// evaluated.
+type ExprError struct {
+	Offset int // offset of the operator in the expression
+	Op     uint8
+	Err    string
+}
+
+func (e *ExprError) Error() string {
+	return "dwarf: evaluating location expression at offset " + strconv.Itoa(e.Offset) +
+		", operator 0x" + strconv.FormatUint(uint64(e.Op), 16) + ": " + e.Err
+}
+
+// Limits on the evaluation of an expression, so that malformed or
+// hostile debug information cannot make EvalExpr loop or grow its
+// stack without bound.
+const (
+	maxExprOps   = 1 << 16 // operators executed, including by DW_OP_entry_value
+	maxExprStack = 1 << 10 // entries on the DWARF stack
+)
+
+// An evaluator holds the state of the evaluation of an expression.
+type evaluator struct {
+	env   *ExprEnv
+	order binary.ByteOrder
+	bits  uint   // bits in an address
+	mask  uint64 // mask of the bits in an address
+
+	expr  []byte
+	pos   int // offset of the next operator
+	opPos int // offset of the current operator
+	op    uint8
+	ops   int // operators executed so far
+	err   error
+
+	stack  []uint64
+	loc    *Piece // location given by the current operator, if not memory
+	pieces []Piece
+}
+
+func newEvaluator(expr []byte, env *ExprEnv) *evaluator {
+	ev := &evaluator{env: env, expr: expr, order: env.ByteOrder}
+	size := env.AddrSize
+	if size <= 0 || size > 8 {
+		size = 8
+	}
+	ev.bits = uint(size) * 8
+	ev.mask = ^uint64(0) >> (64 - ev.bits)
+	if ev.order == nil {
+		ev.order = binary.LittleEndian
+	}
+	return ev
+}
+
+func (ev *evaluator) error(format string, args ...interface{}) {
+	if ev.err == nil {
+		ev.err = &ExprError{Offset: ev.opPos, Op: ev.op, Err: fmt.Sprintf(format, args...)}
+	}
+}
+
+// check records err, if it is not nil, as the error of the evaluation.
+func (ev *evaluator) check(err error) {
+	if err != nil && ev.err == nil {
+		ev.err = &ExprError{Offset: ev.opPos, Op: ev.op, Err: err.Error()}
+	}
+}
+
+// need reports whether ok, recording an error that what is not
+// available if not.
+func (ev *evaluator) need(ok bool, what string) bool {
+	if !ok {
+		ev.error("%s not available", what)
+	}
+	return ok
+}
+
+// Operand decoding.
+
+func (ev *evaluator) bytes(n uint64) []byte {
+	if n > uint64(len(ev.expr)-ev.pos) {
+		ev.error("truncated operand")
+		ev.pos = len(ev.expr)
+		return nil
+	}
+	b := ev.expr[ev.pos : ev.pos+int(n)]
+	ev.pos += int(n)
+	return b
+}
+
+func (ev *evaluator) fixed(n int) uint64 {
+	b := ev.bytes(uint64(n))
+	switch len(b) {
+	case 1:
+		return uint64(b[0])
+	case 2:
+		return uint64(ev.order.Uint16(b))
+	case 4:
+		return uint64(ev.order.Uint32(b))
+	case 8:
+		return ev.order.Uint64(b)
+	}
+	return 0
+}
+
+func (ev *evaluator) uleb() uint64 {
+	var x uint64
+	var s uint
+	for ev.pos < len(ev.expr) {
+		b := ev.expr[ev.pos]
+		ev.pos++
+		if s < 64 {
+			x |= uint64(b&0x7f) << s
+		}
+		s += 7
+		if b&0x80 == 0 {
+			return x
+		}
+	}
+	ev.error("truncated operand")
+	return 0
+}
+
+func (ev *evaluator) sleb() int64 {
+	var x int64
+	var s uint
+	for ev.pos < len(ev.expr) {
+		b := ev.expr[ev.pos]
+		ev.pos++
+		if s < 64 {
+			x |= int64(b&0x7f) << s
+		}
+		s += 7
+		if b&0x80 == 0 {
+			if s < 64 && b&0x40 != 0 {
+				x |= -1 << s
+			}
+			return x
+		}
+	}
+	ev.error("truncated operand")
+	return 0
+}
+
+// Stack operations.
+
+func (ev *evaluator) push(x uint64) {
+	if len(ev.stack) >= maxExprStack {
+		ev.error("stack overflow")
+		return
+	}
+	ev.stack = append(ev.stack, x&ev.mask)
+}
+
+func (ev *evaluator) pop() uint64 {
+	if len(ev.stack) == 0 {
+		ev.error("stack underflow")
+		return 0
+	}
+	x := ev.stack[len(ev.stack)-1]
+	ev.stack = ev.stack[:len(ev.stack)-1]
+	return x
+}
+
+// signed returns x, an address-sized value, sign-extended.
+func (ev *evaluator) signed(x uint64) int64 {
+	return int64(x<<(64-ev.bits)) >> (64 - ev.bits)
+}
+
+func (ev *evaluator) readMemory(addr uint64, size uint64) uint64 {
+	if size == 0 || size > 8 {
+		ev.error("invalid size %d", size)
+		return 0
+	}
+	if !ev.need(ev.env.ReadMemory != nil, "memory") {
+		return 0
+	}
+	var buf [8]byte
+	b := buf[:size]
+	if err := ev.env.ReadMemory(addr, b); err != nil {
+		ev.check(err)
+		return 0
+	}
+	var x uint64
+	for i := range b {
+		if ev.order == binary.BigEndian {
+			x = x<<8 | uint64(b[i])
+		} else {
+			x |= uint64(b[i]) << (8 * uint(i))
+		}
+	}
+	return x
+}
+
+func (ev *evaluator) reg(r uint64) uint64 {
+	if !ev.need(ev.env.Reg != nil, "registers") {
+		return 0
+	}
+	x, err := ev.env.Reg(int(r))
+	ev.check(err)
+	return x
+}
+
+// setLoc sets the location of the current piece to one that is not
+// in memory. Only a piece operator may follow.
+func (ev *evaluator) setLoc(p Piece) {
+	ev.loc = &p
+	if ev.pos < len(ev.expr) {
+		switch ev.expr[ev.pos] {
+		case opPiece, opBitPiece:
+		default:
+			ev.error("location operator not followed by piece operator")
+		}
+	}
+}
+
+// piece ends the current piece.
+func (ev *evaluator) piece(bitSize, bitOffset int64) {
+	var p Piece
+	switch {
+	case ev.loc != nil:
+		p = *ev.loc
+	case len(ev.stack) > 0:
+		p = Piece{Kind: PieceMemory, Addr: ev.stack[len(ev.stack)-1]}
+	default:
+		p = Piece{Kind: PieceUnavailable}
+	}
+	p.BitSize = bitSize
+	p.BitOffset = bitOffset
+	ev.pieces = append(ev.pieces, p)
+	ev.loc = nil
+	ev.stack = ev.stack[:0]
+}
+
+func (ev *evaluator) run() ([]Piece, error) {
+	for ev.pos < len(ev.expr) && ev.err == nil {
+		ev.opPos = ev.pos
+		ev.op = ev.expr[ev.pos]
+		ev.pos++
+		if ev.ops++; ev.ops > maxExprOps {
+			ev.error("too many operations")
+			break
+		}
+		ev.step()
+	}
+	if ev.err != nil {
+		return nil, ev.err
+	}
+	switch {
+	case ev.pieces == nil:
+		ev.piece(0, 0)
+	case ev.loc != nil || len(ev.stack) > 0:
+		ev.opPos = len(ev.expr)
+		ev.op = 0
+		ev.error("missing piece operator after last location")
+		return nil, ev.err
+	}
+	return ev.pieces, nil
+}
+
+// step executes the operator ev.op.
+func (ev *evaluator) step() {
+	op := ev.op
+	switch {
+	case op >= opLit0 && op < opLit0+32:
+		ev.push(uint64(op - opLit0))
+		return
+	case op >= opReg0 && op < opReg0+32:
+		ev.setLoc(Piece{Kind: PieceRegister, Reg: int(op - opReg0)})
+		return
+	case op >= opBreg0 && op < opBreg0+32:
+		off := ev.sleb()
+		ev.push(ev.reg(uint64(op-opBreg0)) + uint64(off))
+		return
+	}
+
+	switch op {
+	case opAddr:
+		ev.push(ev.fixed(int(ev.bits / 8)))
+	case opAddrx, opConstx, opGNUAddrIndex, opGNUConstIndex:
+		idx := ev.uleb()
+		if ev.need(ev.env.Addrx != nil, ".debug_addr") {
+			x, err := ev.env.Addrx(idx)
+			ev.check(err)
+			ev.push(x)
+		}
+	case opConst1u:
+		ev.push(ev.fixed(1))
+	case opConst1s:
+		ev.push(uint64(int8(ev.fixed(1))))
+	case opConst2u:
+		ev.push(ev.fixed(2))
+	case opConst2s:
+		ev.push(uint64(int16(ev.fixed(2))))
+	case opConst4u:
+		ev.push(ev.fixed(4))
+	case opConst4s:
+		ev.push(uint64(int32(ev.fixed(4))))
+	case opConst8u, opConst8s:
+		ev.push(ev.fixed(8))
+	case opConstu:
+		ev.push(ev.uleb())
+	case opConsts:
+		ev.push(uint64(ev.sleb()))
+
+	case opDup:
+		x := ev.pop()
+		ev.push(x)
+		ev.push(x)
+	case opDrop:
+		ev.pop()
+	case opOver:
+		ev.pick(1)
+	case opPick:
+		ev.pick(int(ev.fixed(1)))
+	case opSwap:
+		x, y := ev.pop(), ev.pop()
+		ev.push(x)
+		ev.push(y)
+	case opRot:
+		x, y, z := ev.pop(), ev.pop(), ev.pop()
+		ev.push(x)
+		ev.push(z)
+		ev.push(y)
+
+	case opDeref:
+		ev.push(ev.readMemory(ev.pop(), uint64(ev.bits/8)))
+	case opDerefSize:
+		size := ev.fixed(1)
+		ev.push(ev.readMemory(ev.pop(), size))
+
+	case opAbs:
+		x := ev.signed(ev.pop())
+		if x < 0 {
+			x = -x
+		}
+		ev.push(uint64(x))
+	case opNeg:
+		ev.push(uint64(-ev.signed(ev.pop())))
+	case opNot:
+		ev.push(^ev.pop())
+	case opPlusUconst:
+		ev.push(ev.pop() + ev.uleb())
+	case opAnd, opDiv, opMinus, opMod, opMul, opOr, opPlus, opShl, opShr, opShra, opXor,
+		opEq, opGe, opGt, opLe, opLt, opNe:
+		y, x := ev.pop(), ev.pop()
+		ev.push(ev.binary(op, x, y))
+
+	case opSkip:
+		off := int16(ev.fixed(2))
+		ev.jump(off)
+	case opBra:
+		off := int16(ev.fixed(2))
+		if ev.pop() != 0 {
+			ev.jump(off)
+		}
+
+	case opRegx:
+		ev.setLoc(Piece{Kind: PieceRegister, Reg: int(ev.uleb())})
+	case opFbreg:
+		off := ev.sleb()
+		if ev.need(ev.env.FrameBase != nil, "frame base") {
+			x, err := ev.env.FrameBase()
+			ev.check(err)
+			ev.push(x + uint64(off))
+		}
+	case opBregx:
+		r := ev.uleb()
+		off := ev.sleb()
+		ev.push(ev.reg(r) + uint64(off))
+
+	case opPiece:
+		size := ev.uleb()
+		ev.piece(int64(size)*8, 0)
+	case opBitPiece:
+		size := ev.uleb()
+		off := ev.uleb()
+		ev.piece(int64(size), int64(off))
+
+	case opNop:
+
+	case opPushObjAddr:
+		if ev.need(ev.env.ObjectAddr != nil, "object address") {
+			x, err := ev.env.ObjectAddr()
+			ev.check(err)
+			ev.push(x)
+		}
+	case opFormTLSAddress, opGNUPushTLSAddress:
+		off := ev.pop()
+		if ev.need(ev.env.TLSAddr != nil, "thread-local storage") {
+			x, err := ev.env.TLSAddr(off)
+			ev.check(err)
+			ev.push(x)
+		}
+	case opCallFrameCFA:
+		if ev.need(ev.env.CFA != nil, "canonical frame address") {
+			x, err := ev.env.CFA()
+			ev.check(err)
+			ev.push(x)
+		}
+
+	case opImplicitValue:
+		n := ev.uleb()
+		ev.setLoc(Piece{Kind: PieceBytes, Bytes: ev.bytes(n)})
+	case opStackValue:
+		ev.setLoc(Piece{Kind: PieceValue, Value: ev.pop()})
+	case opImplicitPointer, opGNUImplicitPointer:
+		size := 4
+		if ev.env.Dwarf64 {
+			size = 8
+		}
+		ref := Offset(ev.fixed(size))
+		off := ev.sleb()
+		ev.setLoc(Piece{Kind: PieceImplicitPointer, Ref: ref, Offset: off})
+
+	case opEntryValue, opGNUEntryValue:
+		n := ev.uleb()
+		ev.push(ev.entryValue(ev.bytes(n)))
+
+	default:
+		ev.error("unsupported operator")
+	}
+}
+
+func (ev *evaluator) pick(i int) {
+	if i >= len(ev.stack) {
+		ev.error("stack underflow")
+		return
+	}
+	ev.push(ev.stack[len(ev.stack)-1-i])
+}
+
+func (ev *evaluator) jump(off int16) {
+	pos := ev.pos + int(off)
+	if pos < 0 || pos > len(ev.expr) {
+		ev.error("branch out of range")
+		return
+	}
+	ev.pos = pos
+}
+
+func (ev *evaluator) binary(op uint8, x, y uint64) uint64 {
+	b := func(c bool) uint64 {
+		if c {
+			return 1
+		}
+		return 0
+	}
+	sx, sy := ev.signed(x), ev.signed(y)
+	switch op {
+	case opAnd:
+		return x & y
+	case opOr:
+		return x | y
+	case opXor:
+		return x ^ y
+	case opPlus:
+		return x + y
+	case opMinus:
+		return x - y
+	case opMul:
+		return x * y
+	case opDiv:
+		if y == 0 {
+			ev.error("division by zero")
+			return 0
+		}
+		return uint64(sx / sy)
+	case opMod:
+		if y == 0 {
+			ev.error("division by zero")
+			return 0
+		}
+		return x % y
+	case opShl:
+		return x << y
+	case opShr:
+		return x >> y
+	case opShra:
+		if y >= 64 {
+			y = 63
+		}
+		return uint64(sx >> y)
+	case opEq:
+		return b(sx == sy)
+	case opGe:
+		return b(sx >= sy)
+	case opGt:
+		return b(sx > sy)
+	case opLe:
+		return b(sx <= sy)
+	case opLt:
+		return b(sx < sy)
+	case opNe:
+		return b(sx != sy)
+	}
+	return 0
+}
+
+// entryValue returns the value that expr, the operand of
+// DW_OP_entry_value, had on entry to the current function.
+// Expr is either a register location description or an expression
+// evaluated with the registers as they were on entry.
+func (ev *evaluator) entryValue(expr []byte) uint64 {
+	if !ev.need(ev.env.EntryReg != nil, "entry values") {
+		return 0
+	}
+	env := *ev.env
+	env.Reg = env.EntryReg
+	inner := newEvaluator(expr, &env)
+	inner.ops = ev.ops // share the operation budget
+	pieces, err := inner.run()
+	ev.ops = inner.ops
+	if err != nil {
+		ev.check(err)
+		return 0
+	}
+	if len(pieces) != 1 {
+		ev.error("DW_OP_entry_value operand has pieces")
+		return 0
+	}
+	switch p := pieces[0]; p.Kind {
+	case PieceRegister:
+		x, err := env.EntryReg(p.Reg)
+		ev.check(err)
+		return x
+	case PieceMemory:
+		// An expression leaves its value on the stack.
+		return p.Addr
+	case PieceValue:
+		return p.Value
+	}
+	ev.error("invalid DW_OP_entry_value operand")
+	return 0
+}
@@ -0,0 +1,184 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dwarf_test
+
+import (
+	. "debug/dwarf"
+	"encoding/binary"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// testExprEnv returns an environment in which register r holds
+// 0x1000*r and the memory at address a holds the byte a&0xff.
+func testExprEnv() *ExprEnv {
+	return &ExprEnv{
+		AddrSize: 8,
+		Reg: func(reg int) (uint64, error) {
+			return 0x1000 * uint64(reg), nil
+		},
+		ReadMemory: func(addr uint64, buf []byte) error {
+			for i := range buf {
+				buf[i] = byte(addr + uint64(i))
+			}
+			return nil
+		},
+		FrameBase: func() (uint64, error) { return 0x7f00, nil },
+		CFA:       func() (uint64, error) { return 0x8000, nil },
+		EntryReg: func(reg int) (uint64, error) {
+			return 0x2000 * uint64(reg), nil
+		},
+		TLSAddr: func(off uint64) (uint64, error) { return 0x9000 + off, nil },
+		Addrx: func(idx uint64) (uint64, error) {
+			return 0x400000 + 8*idx, nil
+		},
+	}
+}
+
+var exprTests = []struct {
+	name string
+	expr []byte
+	want []Piece
+}{
+	{"empty", nil, []Piece{{Kind: PieceUnavailable}}},
+	{"addr", []byte{0x03, 0x80, 0x10, 0x60, 0, 0, 0, 0, 0}, []Piece{{Kind: PieceMemory, Addr: 0x601080}}},
+	{"addrx", []byte{0xa1, 0x02}, []Piece{{Kind: PieceMemory, Addr: 0x400010}}},
+	{"reg", []byte{0x55}, []Piece{{Kind: PieceRegister, Reg: 5}}},
+	{"regx", []byte{0x90, 0x21}, []Piece{{Kind: PieceRegister, Reg: 33}}},
+	{"breg", []byte{0x77, 0x78}, []Piece{{Kind: PieceMemory, Addr: 0x7000 - 8}}},
+	{"bregx", []byte{0x92, 0x02, 0x10}, []Piece{{Kind: PieceMemory, Addr: 0x2010}}},
+	{"fbreg", []byte{0x91, 0x70}, []Piece{{Kind: PieceMemory, Addr: 0x7f00 - 16}}},
+	{"cfa", []byte{0x9c, 0x23, 0x08}, []Piece{{Kind: PieceMemory, Addr: 0x8008}}},
+	{"tls", []byte{0x0c, 0x10, 0, 0, 0, 0xe0}, []Piece{{Kind: PieceMemory, Addr: 0x9010}}},
+	{"stack value", []byte{0x11, 0x7f, 0x9f}, []Piece{{Kind: PieceValue, Value: ^uint64(0)}}},
+	{"implicit value", []byte{0x9e, 0x02, 0xab, 0xcd}, []Piece{{Kind: PieceBytes, Bytes: []byte{0xab, 0xcd}}}},
+	{"implicit pointer", []byte{0xa0, 0x2a, 0, 0, 0, 0x04}, []Piece{{Kind: PieceImplicitPointer, Ref: 0x2a, Offset: 4}}},
+	{"deref", []byte{0x0a, 0x10, 0x20, 0x06, 0x9f}, []Piece{{Kind: PieceValue, Value: 0x1716151413121110}}},
+	{"deref size", []byte{0x0a, 0x10, 0x20, 0x94, 0x02, 0x9f}, []Piece{{Kind: PieceValue, Value: 0x1110}}},
+	{"arithmetic", []byte{
+		0x35,       // lit5
+		0x33,       // lit3
+		0x1c,       // minus: 2
+		0x3a,       // lit10
+		0x1e,       // mul: 20
+		0x37,       // lit7
+		0x1d,       // mod: 6
+		0x09, 0xfe, // const1s -2
+		0x1b, // div: -3
+		0x19, // abs: 3
+		0x31, // lit1
+		0x24, // shl: 6
+		0x9f,
+	}, []Piece{{Kind: PieceValue, Value: 6}}},
+	{"stack ops", []byte{
+		0x31, 0x32, 0x33, // 1 2 3
+		0x17,       // rot: 3 1 2
+		0x16,       // swap: 3 2 1
+		0x14,       // over: 3 2 1 2
+		0x15, 0x03, // pick 3: 3 2 1 2 3
+		0x22,       // plus: 3 2 1 5
+		0x22,       // plus: 3 2 6
+		0x13,       // drop: 3 2
+		0x1c,       // minus: 1
+		0x12, 0x22, // dup, plus: 2
+		0x9f,
+	}, []Piece{{Kind: PieceValue, Value: 2}}},
+	{"branch", []byte{
+		0x30,             // lit0
+		0x28, 0x03, 0x00, // bra +3: not taken
+		0x31,             // lit1
+		0x28, 0x01, 0x00, // bra +1: taken
+		0x3f,             // lit15: skipped
+		0x3a,             // lit10
+		0x2f, 0x01, 0x00, // skip +1
+		0x96, // nop: skipped
+		0x39, // lit9
+		0x2b, // gt: 1
+		0x9f,
+	}, []Piece{{Kind: PieceValue, Value: 1}}},
+	{"entry value reg", []byte{0xa3, 0x01, 0x55, 0x9f}, []Piece{{Kind: PieceValue, Value: 0xa000}}},
+	{"entry value expr", []byte{0xf3, 0x02, 0x75, 0x04, 0x9f}, []Piece{{Kind: PieceValue, Value: 0xa004}}},
+	{"pieces", []byte{
+		0x50, 0x93, 0x04, // reg0, piece 4
+		0x93, 0x02, // piece 2: optimized out
+		0x91, 0x00, 0x93, 0x02, // fbreg 0, piece 2
+		0x3c, 0x9f, 0x9d, 0x03, 0x01, // lit12, stack value, bit piece 3 1
+	}, []Piece{
+		{Kind: PieceRegister, Reg: 0, BitSize: 32},
+		{Kind: PieceUnavailable, BitSize: 16},
+		{Kind: PieceMemory, Addr: 0x7f00, BitSize: 16},
+		{Kind: PieceValue, Value: 12, BitSize: 3, BitOffset: 1},
+	}},
+}
+
+func TestEvalExpr(t *testing.T) {
+	env := testExprEnv()
+	for _, tt := range exprTests {
+		got, err := EvalExpr(tt.expr, env)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestEvalExprAddrSize(t *testing.T) {
+	env := testExprEnv()
+	env.AddrSize = 4
+	env.ByteOrder = binary.BigEndian
+
+	got, err := EvalExpr([]byte{0x03, 0x00, 0x60, 0x10, 0x80, 0x11, 0x7f, 0x22, 0x9f}, env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []Piece{{Kind: PieceValue, Value: 0x60107f}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	got, err = EvalExpr([]byte{0x0a, 0x10, 0x20, 0x06}, env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []Piece{{Kind: PieceMemory, Addr: 0x20212223}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestEvalExprErrors(t *testing.T) {
+	env := testExprEnv()
+	memErr := errors.New("memory not mapped")
+	env.ReadMemory = func(uint64, []byte) error { return memErr }
+	env.ObjectAddr = nil
+
+	tests := []struct {
+		name string
+		expr []byte
+		err  string
+	}{
+		{"underflow", []byte{0x22}, "dwarf: evaluating location expression at offset 0, operator 0x22: stack underflow"},
+		{"truncated", []byte{0x30, 0x0c, 0x01}, "dwarf: evaluating location expression at offset 1, operator 0xc: truncated operand"},
+		{"unavailable", []byte{0x97}, "dwarf: evaluating location expression at offset 0, operator 0x97: object address not available"},
+		{"memory", []byte{0x30, 0x06}, "dwarf: evaluating location expression at offset 1, operator 0x6: memory not mapped"},
+		{"reg not last", []byte{0x50, 0x30}, "dwarf: evaluating location expression at offset 0, operator 0x50: location operator not followed by piece operator"},
+		{"missing piece", []byte{0x50, 0x93, 0x04, 0x30}, "dwarf: evaluating location expression at offset 4, operator 0x0: missing piece operator after last location"},
+		{"division", []byte{0x31, 0x30, 0x1b}, "dwarf: evaluating location expression at offset 2, operator 0x1b: division by zero"},
+		{"unsupported", []byte{0x98, 0, 0}, "dwarf: evaluating location expression at offset 0, operator 0x98: unsupported operator"},
+		{"loop", []byte{0x2f, 0xfd, 0xff}, "dwarf: evaluating location expression at offset 0, operator 0x2f: too many operations"},
+		{"overflow", []byte{0x30, 0x12, 0x2f, 0xfc, 0xff}, "dwarf: evaluating location expression at offset 1, operator 0x12: stack overflow"},
+	}
+	for _, tt := range tests {
+		_, err := EvalExpr(tt.expr, env)
+		if err == nil || err.Error() != tt.err {
+			t.Errorf("%s: got error %v, want %s", tt.name, err, tt.err)
+		}
+		if _, ok := err.(*ExprError); !ok {
+			t.Errorf("%s: got error of type %T, want *ExprError", tt.name, err)
+		}
+	}
+}
@@ -0,0 +1,234 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// DWARF location lists.
+// DWARF v4 section 2.6.2 and DWARF v5 section 2.6.2.
+
+package dwarf
+
+import (
+	"errors"
+	"strconv"
+)
+
+// A LocListEntry is an entry of a location list. It gives the location
+// expression describing a value while the PC is in [Low, High).
+// A default entry, which has Low and High zero, gives the expression for
+// the PCs not covered by any other entry.
+type LocListEntry struct {
+	Low, High uint64
+	Default   bool
+	Expr      []byte
+}
+
+// LocationList returns the location list given by the attribute attr
+// of e, such as AttrLocation or AttrFrameBase. If the attribute holds a
+// single location expression rather than a reference to a location list,
+// LocationList returns a list with a default entry for it. If e has no
+// such attribute, LocationList returns nil with no error.
+//
+// The .debug_loc section is used for DWARF 2 through 4 and the
+// .debug_loclists section for DWARF 5; see Data.AddSection.
+func (d *Data) LocationList(e *Entry, attr Attr) ([]LocListEntry, error) {
+	field := e.AttrField(attr)
+	if field == nil {
+		return nil, nil
+	}
+	var u *unit
+	if i := d.offsetToUnit(e.Offset); i >= 0 && i < len(d.unit) {
+		u = &d.unit[i]
+	}
+	if u == nil {
+		return nil, errors.New("no unit for entry")
+	}
+
+	switch field.Class {
+	case ClassExprLoc, ClassBlock:
+		expr, _ := field.Val.([]byte)
+		return []LocListEntry{{Default: true, Expr: expr}}, nil
+
+	case ClassLocListPtr:
+		off, ok := field.Val.(int64)
+		if !ok {
+			return nil, nil
+		}
+		cu, base, err := d.baseAddressForEntry(e)
+		if err != nil {
+			return nil, err
+		}
+		if u.vers >= 5 {
+			return d.dwarf5LocList(u, cu, base, off)
+		}
+		return d.dwarf2LocList(u, base, off)
+
+	case ClassLocList:
+		idx, ok := field.Val.(uint64)
+		if !ok {
+			return nil, nil
+		}
+		cu, base, err := d.baseAddressForEntry(e)
+		if err != nil {
+			return nil, err
+		}
+		off, err := d.resolveLoclistx(u, cu, idx)
+		if err != nil {
+			return nil, err
+		}
+		return d.dwarf5LocList(u, cu, base, off)
+	}
+	return nil, nil
+}
+
+// LocationExpr returns the location expression given by the attribute
+// attr of e that is valid when the PC is pc. It returns nil with no
+// error if there is none, in which case the value is not available.
+func (d *Data) LocationExpr(e *Entry, attr Attr, pc uint64) ([]byte, error) {
+	list, err := d.LocationList(e, attr)
+	if err != nil {
+		return nil, err
+	}
+	var expr []byte
+	for _, l := range list {
+		if l.Default {
+			expr = l.Expr
+		} else if l.Low <= pc && pc < l.High {
+			return l.Expr, nil
+		}
+	}
+	return expr, nil
+}
+
+// dwarf2LocList reads the location list at offset off in .debug_loc.
+func (d *Data) dwarf2LocList(u *unit, base uint64, off int64) ([]LocListEntry, error) {
+	if d.loc == nil {
+		return nil, errors.New("missing .debug_loc section")
+	}
+	if off < 0 || off > int64(len(d.loc)) {
+		return nil, errors.New("invalid .debug_loc offset " + strconv.FormatInt(off, 10))
+	}
+	var ret []LocListEntry
+	buf := makeBuf(d, u, "loc", Offset(off), d.loc[off:])
+	for buf.err == nil {
+		low := buf.addr()
+		high := buf.addr()
+
+		if low == 0 && high == 0 {
+			break
+		}
+
+		if low == ^uint64(0)>>uint((8-u.addrsize())*8) {
+			base = high
+			continue
+		}
+		n := buf.uint16()
+		expr := buf.bytes(int(n))
+		ret = append(ret, LocListEntry{Low: base + low, High: base + high, Expr: expr})
+	}
+	if buf.err != nil {
+		return nil, buf.err
+	}
+	return ret, nil
+}
+
+// dwarf5LocList reads the location list at offset off in
+// .debug_loclists, see DWARFv5 section 2.6.2.
+func (d *Data) dwarf5LocList(u *unit, cu *Entry, base uint64, off int64) ([]LocListEntry, error) {
+	if d.locLists == nil {
+		return nil, errors.New("missing .debug_loclists section")
+	}
+	if off < 0 || off > int64(len(d.locLists)) {
+		return nil, errors.New("invalid .debug_loclists offset " + strconv.FormatInt(off, 10))
+	}
+	var addrBase int64
+	if cu != nil {
+		addrBase, _ = cu.Val(AttrAddrBase).(int64)
+	}
+	var err error
+	addrx := func(idx uint64) uint64 {
+		var a uint64
+		if err == nil {
+			a, err = d.debugAddr(u, uint64(addrBase), idx)
+		}
+		return a
+	}
+
+	var ret []LocListEntry
+	buf := makeBuf(d, u, "loclists", Offset(off), d.locLists[off:])
+	for buf.err == nil && err == nil {
+		var l LocListEntry
+		switch opcode := buf.uint8(); opcode {
+		case lleEndOfList:
+			return ret, buf.err
+
+		case lleBaseAddressx:
+			base = addrx(buf.uint())
+			continue
+
+		case lleBaseAddress:
+			base = buf.addr()
+			continue
+
+		case lleStartxEndx:
+			l.Low = addrx(buf.uint())
+			l.High = addrx(buf.uint())
+
+		case lleStartxLength:
+			l.Low = addrx(buf.uint())
+			l.High = l.Low + buf.uint()
+
+		case lleOffsetPair:
+			l.Low = base + buf.uint()
+			l.High = base + buf.uint()
+
+		case lleDefaultLocation:
+			l.Default = true
+
+		case lleStartEnd:
+			l.Low = buf.addr()
+			l.High = buf.addr()
+
+		case lleStartLength:
+			l.Low = buf.addr()
+			l.High = l.Low + buf.uint()
+
+		default:
+			buf.error("unknown location list entry 0x" + strconv.FormatUint(uint64(opcode), 16))
+			continue
+		}
+		l.Expr = buf.bytes(int(buf.uint()))
+		ret = append(ret, l)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return nil, buf.err
+}
+
+// resolveLoclistx returns the .debug_loclists offset of the location
+// list at index idx of the unit of cu, whose offsets table starts at
+// the unit's loclists_base.
+func (d *Data) resolveLoclistx(u *unit, cu *Entry, idx uint64) (int64, error) {
+	base, _ := cu.Val(AttrLoclistsBase).(int64)
+	is64, _ := u.dwarf64()
+	size := uint64(4)
+	if is64 {
+		size = 8
+	}
+	off := uint64(base) + idx*size
+	if uint64(int(off)) != off {
+		return 0, errors.New("DW_FORM_loclistx offset out of range")
+	}
+	b := makeBuf(d, u, "loclists", 0, d.locLists)
+	b.skip(int(off))
+	var rel uint64
+	if is64 {
+		rel = b.uint64()
+	} else {
+		rel = uint64(b.uint32())
+	}
+	if b.err != nil {
+		return 0, b.err
+	}
+	return base + int64(rel), nil
+}
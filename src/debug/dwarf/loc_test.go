@@ -0,0 +1,107 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dwarf_test
+
+import (
+	. "debug/dwarf"
+	"reflect"
+	"testing"
+)
+
+// findEntry returns the first entry of d with the given tag and name.
+func findEntry(t *testing.T, d *Data, tag Tag, name string) *Entry {
+	r := d.Reader()
+	for {
+		e, err := r.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if e == nil {
+			t.Fatalf("no %v named %s", tag, name)
+		}
+		if e.Tag == tag && e.Val(AttrName) == name {
+			return e
+		}
+	}
+}
+
+func TestLocationList(t *testing.T) {
+	tests := []struct {
+		file string
+		name string
+		want []LocListEntry
+	}{
+		{
+			// DWARF 4, .debug_loc
+			"testdata/ranges.elf", "argc",
+			[]LocListEntry{
+				{Low: 0x400400, High: 0x400404, Expr: []byte{0x55}},
+				{Low: 0x400404, High: 0x400408, Expr: []byte{0xf3, 0x01, 0x55, 0x9f}},
+			},
+		},
+		{
+			// DWARF 5, .debug_loclists indexed by DW_FORM_loclistx
+			"testdata/rnglistx.elf", "s",
+			[]LocListEntry{
+				{Low: 0x401000, High: 0x401016, Expr: []byte{0x11, 0x00, 0x9f}},
+				{Low: 0x40101a, High: 0x40101d, Expr: []byte{0x11, 0x00, 0x9f}},
+			},
+		},
+		{
+			"testdata/rnglistx.elf", "argv",
+			[]LocListEntry{{Default: true, Expr: []byte{0x54}}},
+		},
+	}
+	for _, tt := range tests {
+		d := elfData(t, tt.file)
+		tag := TagVariable
+		if tt.name != "s" {
+			tag = TagFormalParameter
+		}
+		e := findEntry(t, d, tag, tt.name)
+		got, err := d.LocationList(e, AttrLocation)
+		if err != nil {
+			t.Errorf("%s: %s: %v", tt.file, tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: %s: got %v, want %v", tt.file, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestLocationExpr(t *testing.T) {
+	d := elfData(t, "testdata/ranges.elf")
+	e := findEntry(t, d, TagFormalParameter, "argc")
+	env, err := d.ExprEnv(e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	env.EntryReg = func(reg int) (uint64, error) {
+		return 0x100 + uint64(reg), nil
+	}
+
+	tests := []struct {
+		pc   uint64
+		want []Piece
+	}{
+		{0x400400, []Piece{{Kind: PieceRegister, Reg: 5}}},
+		{0x400405, []Piece{{Kind: PieceValue, Value: 0x105}}},
+		{0x400408, []Piece{{Kind: PieceUnavailable}}},
+	}
+	for _, tt := range tests {
+		expr, err := d.LocationExpr(e, AttrLocation, tt.pc)
+		if err != nil {
+			t.Fatalf("LocationExpr(%#x): %v", tt.pc, err)
+		}
+		got, err := EvalExpr(expr, env)
+		if err != nil {
+			t.Fatalf("EvalExpr(%#x): %v", tt.pc, err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("at %#x: got %+v, want %+v", tt.pc, got, tt.want)
+		}
+	}
+}
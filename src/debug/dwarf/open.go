@@ -30,6 +30,8 @@ type Data struct {
 	lineStr    []byte
 	strOffsets []byte
 	rngLists   []byte
+	loc        []byte
+	locLists   []byte
 
 	// parsed data
 	abbrevCache map[uint64]abbrevTable
@@ -124,6 +126,10 @@ func (d *Data) AddSection(name string, contents []byte) error {
 		d.strOffsets = contents
 	case ".debug_rnglists":
 		d.rngLists = contents
+	case ".debug_loc":
+		d.loc = contents
+	case ".debug_loclists":
+		d.locLists = contents
 	}
 	// Just ignore names that we don't yet support.
 	return err
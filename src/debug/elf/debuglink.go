@@ -0,0 +1,138 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Separate debug information files.
+// See https://sourceware.org/gdb/onlinedocs/gdb/Separate-Debug-Files.html.
+
+package elf
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io/ioutil"
+	"path/filepath"
+)
+
+// The note type of a GNU build ID.
+const ntGNUBuildID = 3
+
+// debugDir is the global directory searched for separate debug files.
+var debugDir = "/usr/lib/debug"
+
+// BuildID returns the GNU build ID of the file, which the linker records
+// in a note of type NT_GNU_BUILD_ID. It returns nil if the file has none.
+func (f *File) BuildID() ([]byte, error) {
+	for _, s := range f.Sections {
+		if s.Type != SHT_NOTE {
+			continue
+		}
+		data, err := s.Data()
+		if err != nil {
+			return nil, err
+		}
+		for len(data) >= 12 {
+			namesz := uint64(f.ByteOrder.Uint32(data))
+			descsz := uint64(f.ByteOrder.Uint32(data[4:]))
+			typ := f.ByteOrder.Uint32(data[8:])
+			data = data[12:]
+			descOff := (namesz + 3) &^ 3
+			next := descOff + (descsz+3)&^3
+			if descOff+descsz > uint64(len(data)) {
+				return nil, &FormatError{int64(s.Offset), "truncated note", nil}
+			}
+			if typ == ntGNUBuildID && string(data[:namesz]) == "GNU\x00" {
+				id := make([]byte, descsz)
+				copy(id, data[descOff:])
+				return id, nil
+			}
+			if next >= uint64(len(data)) {
+				break
+			}
+			data = data[next:]
+		}
+	}
+	return nil, nil
+}
+
+// DebugLink returns the name of the separate debug file of the file,
+// and the CRC-32 checksum of the debug file, as recorded in the
+// .gnu_debuglink section. It returns an empty name if there is no
+// such section.
+func (f *File) DebugLink() (name string, crc uint32, err error) {
+	s := f.Section(".gnu_debuglink")
+	if s == nil {
+		return "", 0, nil
+	}
+	data, err := s.Data()
+	if err != nil {
+		return "", 0, err
+	}
+	i := bytes.IndexByte(data, 0)
+	if i <= 0 {
+		return "", 0, &FormatError{int64(s.Offset), "malformed .gnu_debuglink section", nil}
+	}
+	name = string(data[:i])
+	off := (i + 4) &^ 3
+	if off+4 > len(data) {
+		return "", 0, &FormatError{int64(s.Offset), "malformed .gnu_debuglink section", nil}
+	}
+	return name, f.ByteOrder.Uint32(data[off:]), nil
+}
+
+// OpenDebugFile opens the separate debug file of the file f,
+// which was read from path. Such a file holds the debug information,
+// and often the symbol table, that the file was stripped of.
+//
+// The debug file is looked for by build ID, as
+// /usr/lib/debug/.build-id/xx/yyyy.debug, where xxyyyy is the build ID
+// in hexadecimal, and then by the name recorded in the .gnu_debuglink
+// section, in the directory of path, in the .debug subdirectory of that
+// directory, and in the same directory under /usr/lib/debug.
+// A debug file is only used if its build ID or checksum matches.
+func (f *File) OpenDebugFile(path string) (*File, error) {
+	id, err := f.BuildID()
+	if err != nil {
+		return nil, err
+	}
+	if len(id) > 1 {
+		hexid := fmt.Sprintf("%x", id)
+		name := filepath.Join(debugDir, ".build-id", hexid[:2], hexid[2:]+".debug")
+		if df, err := Open(name); err == nil {
+			if did, err := df.BuildID(); err == nil && bytes.Equal(did, id) {
+				return df, nil
+			}
+			df.Close()
+		}
+	}
+
+	link, crc, err := f.DebugLink()
+	if err != nil {
+		return nil, err
+	}
+	if link != "" {
+		path, err := filepath.Abs(path)
+		if err != nil {
+			return nil, err
+		}
+		dir := filepath.Dir(path)
+		for _, d := range []string{dir, filepath.Join(dir, ".debug"), filepath.Join(debugDir, dir)} {
+			name := filepath.Join(d, link)
+			if name == path {
+				continue
+			}
+			data, err := ioutil.ReadFile(name)
+			if err != nil || crc32.ChecksumIEEE(data) != crc {
+				continue
+			}
+			df, err := NewFile(bytes.NewReader(data))
+			if err != nil {
+				return nil, err
+			}
+			return df, nil
+		}
+	}
+	return nil, errors.New("elf: separate debug file not found")
+}
@@ -0,0 +1,185 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package elf
+
+import (
+	"bytes"
+	"debug/dwarf"
+	"encoding/hex"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// The debuglink test files were made from hello.c with
+//	gcc -g -Os -no-pie -Wl,--build-id=sha1 -o debuglink-amd64-linux-exec hello.c
+//	objcopy --only-keep-debug debuglink-amd64-linux-exec debuglink-amd64-linux-exec.debug
+//	objcopy --strip-all --add-gnu-debuglink=debuglink-amd64-linux-exec.debug debuglink-amd64-linux-exec
+const (
+	debugLinkExec    = "testdata/debuglink-amd64-linux-exec"
+	debugLinkBuildID = "b0230298c3d49699c86c6ff7f49cf462974747c7"
+)
+
+func TestBuildID(t *testing.T) {
+	for _, file := range []string{debugLinkExec, debugLinkExec + ".debug"} {
+		f, err := Open(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		id, err := f.BuildID()
+		f.Close()
+		if err != nil {
+			t.Errorf("%s: %v", file, err)
+		} else if got := hex.EncodeToString(id); got != debugLinkBuildID {
+			t.Errorf("%s: build ID %s, want %s", file, got, debugLinkBuildID)
+		}
+	}
+
+	f, err := Open("testdata/gcc-amd64-linux-exec")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if id, err := f.BuildID(); id != nil || err != nil {
+		t.Errorf("gcc-amd64-linux-exec: BuildID() = %x, %v, want nil, nil", id, err)
+	}
+}
+
+func TestDebugLink(t *testing.T) {
+	f, err := Open(debugLinkExec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	name, crc, err := f.DebugLink()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Base(debugLinkExec) + ".debug"; name != want {
+		t.Errorf("debug link name %q, want %q", name, want)
+	}
+	data, err := os.ReadFile(debugLinkExec + ".debug")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := crc32.ChecksumIEEE(data); crc != want {
+		t.Errorf("debug link checksum %#x, want %#x", crc, want)
+	}
+}
+
+func TestOpenDebugFile(t *testing.T) {
+	exec, err := os.ReadFile(debugLinkExec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	debug, err := os.ReadFile(debugLinkExec + ".debug")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	defer func(old string) { debugDir = old }(debugDir)
+	debugDir = filepath.Join(dir, "usr", "lib", "debug")
+
+	tests := []struct {
+		name  string
+		files map[string][]byte
+		found bool
+	}{
+		{
+			name:  "same directory",
+			files: map[string][]byte{"a/exec": exec, "a/debuglink-amd64-linux-exec.debug": debug},
+			found: true,
+		},
+		{
+			name:  ".debug directory",
+			files: map[string][]byte{"b/exec": exec, "b/.debug/debuglink-amd64-linux-exec.debug": debug},
+			found: true,
+		},
+		{
+			name: "build ID",
+			files: map[string][]byte{
+				"c/exec": exec,
+				"usr/lib/debug/.build-id/" + debugLinkBuildID[:2] + "/" + debugLinkBuildID[2:] + ".debug": debug,
+			},
+			found: true,
+		},
+		{
+			name:  "checksum mismatch",
+			files: map[string][]byte{"d/exec": exec, "d/debuglink-amd64-linux-exec.debug": exec},
+		},
+		{
+			name:  "missing",
+			files: map[string][]byte{"e/exec": exec},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var path string
+			for name, data := range tt.files {
+				name = filepath.Join(dir, filepath.FromSlash(name))
+				if err := os.MkdirAll(filepath.Dir(name), 0777); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(name, data, 0666); err != nil {
+					t.Fatal(err)
+				}
+				if filepath.Base(name) == "exec" {
+					path = name
+				}
+			}
+			defer os.RemoveAll(debugDir)
+
+			f, err := Open(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+			if _, err := f.DWARF(); err == nil {
+				t.Fatal("stripped file has DWARF data")
+			}
+			df, err := f.OpenDebugFile(path)
+			if !tt.found {
+				if err == nil {
+					df.Close()
+					t.Fatal("OpenDebugFile succeeded, want error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer df.Close()
+			if id, _ := df.BuildID(); !bytes.Equal(id, mustDecodeHex(t, debugLinkBuildID)) {
+				t.Errorf("debug file build ID %x, want %s", id, debugLinkBuildID)
+			}
+			d, err := df.DWARF()
+			if err != nil {
+				t.Fatal(err)
+			}
+			r := d.Reader()
+			for {
+				e, err := r.Next()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if e == nil {
+					t.Fatal("no DWARF entry for main")
+				}
+				if e.Tag == dwarf.TagSubprogram && e.Val(dwarf.AttrName) == "main" {
+					break
+				}
+			}
+		})
+	}
+}
+
+func mustDecodeHex(t *testing.T, s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
@@ -7,6 +7,7 @@ package binary
 import (
 	"bytes"
 	"fmt"
+	"internal/race"
 	"io"
 	"math"
 	"reflect"
@@ -135,6 +136,32 @@ func testWrite(t *testing.T, order ByteOrder, b []byte, s1 interface{}) {
 	checkResult(t, "Write", order, err, buf.Bytes(), b)
 }
 
+func testDecode(t *testing.T, order ByteOrder, b []byte, s1 interface{}) {
+	var s2 Struct
+	n, err := Decode(b, order, &s2)
+	if err == nil && n != len(b) {
+		t.Errorf("Decode %v: consumed %d bytes, want %d", order, n, len(b))
+	}
+	checkResult(t, "Decode", order, err, s2, s1)
+}
+
+func testEncode(t *testing.T, order ByteOrder, b []byte, s1 interface{}) {
+	buf := make([]byte, len(b))
+	n, err := Encode(buf, order, s1)
+	if err == nil && n != len(b) {
+		t.Errorf("Encode %v: wrote %d bytes, want %d", order, n, len(b))
+	}
+	checkResult(t, "Encode", order, err, buf, b)
+}
+
+func testAppend(t *testing.T, order ByteOrder, b []byte, s1 interface{}) {
+	buf, err := Append([]byte{0xff}, order, s1)
+	if err == nil && buf[0] != 0xff {
+		t.Errorf("Append %v: overwrote prefix", order)
+	}
+	checkResult(t, "Append", order, err, buf[1:], b)
+}
+
 func TestLittleEndianRead(t *testing.T)     { testRead(t, LittleEndian, little, s) }
 func TestLittleEndianWrite(t *testing.T)    { testWrite(t, LittleEndian, little, s) }
 func TestLittleEndianPtrWrite(t *testing.T) { testWrite(t, LittleEndian, little, &s) }
@@ -143,6 +170,64 @@ func TestBigEndianRead(t *testing.T)     { testRead(t, BigEndian, big, s) }
 func TestBigEndianWrite(t *testing.T)    { testWrite(t, BigEndian, big, s) }
 func TestBigEndianPtrWrite(t *testing.T) { testWrite(t, BigEndian, big, &s) }
 
+func TestLittleEndianDecode(t *testing.T) { testDecode(t, LittleEndian, little, s) }
+func TestLittleEndianEncode(t *testing.T) { testEncode(t, LittleEndian, little, s) }
+func TestLittleEndianAppend(t *testing.T) { testAppend(t, LittleEndian, little, &s) }
+
+func TestBigEndianDecode(t *testing.T) { testDecode(t, BigEndian, big, s) }
+func TestBigEndianEncode(t *testing.T) { testEncode(t, BigEndian, big, &s) }
+func TestBigEndianAppend(t *testing.T) { testAppend(t, BigEndian, big, s) }
+
+func TestDecodeEncodeSlice(t *testing.T) {
+	slice := make([]int32, 2)
+	n, err := Decode(src, BigEndian, slice)
+	if err == nil && n != len(src) {
+		t.Errorf("Decode: consumed %d bytes, want %d", n, len(src))
+	}
+	checkResult(t, "DecodeSlice", BigEndian, err, slice, res)
+
+	buf := make([]byte, len(src))
+	_, err = Encode(buf, BigEndian, res)
+	checkResult(t, "EncodeSlice", BigEndian, err, buf, src)
+
+	b, err := Append(nil, BigEndian, res)
+	checkResult(t, "AppendSlice", BigEndian, err, b, src)
+}
+
+func TestBufferTooSmallFixed(t *testing.T) {
+	buf := make([]byte, len(big)-1)
+	var s2 Struct
+	for _, data := range []interface{}{s, &s2, uint64(0), res} {
+		if _, err := Encode(buf[:3], BigEndian, data); err != errBufferTooSmall {
+			t.Errorf("Encode(%T) = %v, want %v", data, err, errBufferTooSmall)
+		}
+	}
+	if _, err := Encode(buf, BigEndian, s); err != errBufferTooSmall {
+		t.Errorf("Encode(Struct) = %v, want %v", err, errBufferTooSmall)
+	}
+	if _, err := Decode(buf, BigEndian, &s2); err != errBufferTooSmall {
+		t.Errorf("Decode(*Struct) = %v, want %v", err, errBufferTooSmall)
+	}
+	var x uint64
+	if _, err := Decode(buf[:7], BigEndian, &x); err != errBufferTooSmall {
+		t.Errorf("Decode(*uint64) = %v, want %v", err, errBufferTooSmall)
+	}
+}
+
+func TestAppendAllocs(t *testing.T) {
+	if race.Enabled {
+		t.Skip("skipping malloc count under race detector")
+	}
+	buf := make([]byte, 0, 2*len(big))
+	allocs := testing.AllocsPerRun(100, func() {
+		buf, _ = Append(buf[:0], BigEndian, uint32(1))
+		Encode(buf[:cap(buf)], BigEndian, &s)
+	})
+	if allocs != 0 {
+		t.Errorf("Append and Encode allocated %v times, want 0", allocs)
+	}
+}
+
 func TestReadSlice(t *testing.T) {
 	slice := make([]int32, 2)
 	err := Read(bytes.NewReader(src), BigEndian, slice)
@@ -300,11 +385,11 @@ func TestBlankFields(t *testing.T) {
 
 func TestSizeStructCache(t *testing.T) {
 	// Reset the cache, otherwise multiple test runs fail.
-	structSize = sync.Map{}
+	structPlans = sync.Map{}
 
 	count := func() int {
 		var i int
-		structSize.Range(func(_, _ interface{}) bool {
+		structPlans.Range(func(_, _ interface{}) bool {
 			i++
 			return true
 		})
@@ -527,6 +612,29 @@ func BenchmarkWriteStruct(b *testing.B) {
 	}
 }
 
+func BenchmarkDecodeStruct(b *testing.B) {
+	buf, _ := Append(nil, BigEndian, &s)
+	b.SetBytes(int64(len(buf)))
+	t := s
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Decode(buf, BigEndian, &t)
+	}
+	b.StopTimer()
+	if b.N > 0 && !reflect.DeepEqual(s, t) {
+		b.Fatalf("struct doesn't match:\ngot  %v;\nwant %v", t, s)
+	}
+}
+
+func BenchmarkAppendStruct(b *testing.B) {
+	buf := make([]byte, 0, Size(&s))
+	b.SetBytes(int64(cap(buf)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Append(buf[:0], BigEndian, &s)
+	}
+}
+
 func BenchmarkReadInts(b *testing.B) {
 	var ls Struct
 	bsr := &byteSliceReader{}
@@ -39,6 +39,34 @@ func ExampleWrite_multi() {
 	// Output: beefcafe
 }
 
+func ExampleAppend() {
+	var header struct {
+		Magic   uint32
+		Version uint16
+		Flags   uint16
+	}
+	header.Magic = 0xcafebabe
+	header.Version = 2
+	buf, err := binary.Append(nil, binary.BigEndian, &header)
+	if err != nil {
+		fmt.Println("binary.Append failed:", err)
+	}
+	buf = binary.AppendUvarint(buf, 300)
+	fmt.Printf("% x", buf)
+	// Output: ca fe ba be 00 02 00 00 ac 02
+}
+
+func ExampleDecode() {
+	b := []byte{0x18, 0x2d, 0x44, 0x54, 0xfb, 0x21, 0x09, 0x40, 0xff}
+	var pi float64
+	n, err := binary.Decode(b, binary.LittleEndian, &pi)
+	if err != nil {
+		fmt.Println("binary.Decode failed:", err)
+	}
+	fmt.Println(pi, n)
+	// Output: 3.141592653589793 8
+}
+
 func ExampleRead() {
 	var pi float64
 	b := []byte{0x18, 0x2d, 0x44, 0x54, 0xfb, 0x21, 0x09, 0x40}
@@ -43,6 +43,11 @@ func testVarint(t *testing.T, x int64) {
 	if x != y {
 		t.Errorf("ReadVarint(%d): got %d", x, y)
 	}
+
+	prefix := []byte{0xff}
+	if b := AppendVarint(prefix, x); !bytes.Equal(b[1:], buf[:n]) || b[0] != 0xff {
+		t.Errorf("AppendVarint(%d): got %x; want ff%x", x, b, buf[:n])
+	}
 }
 
 func testUvarint(t *testing.T, x uint64) {
@@ -63,6 +68,11 @@ func testUvarint(t *testing.T, x uint64) {
 	if x != y {
 		t.Errorf("ReadUvarint(%d): got %d", x, y)
 	}
+
+	prefix := []byte{0xff}
+	if b := AppendUvarint(prefix, x); !bytes.Equal(b[1:], buf[:n]) || b[0] != 0xff {
+		t.Errorf("AppendUvarint(%d): got %x; want ff%x", x, b, buf[:n])
+	}
 }
 
 var tests = []int64{
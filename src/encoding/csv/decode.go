@@ -0,0 +1,227 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package csv
+
+import (
+	"bytes"
+	"encoding"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Unmarshal parses CSV data and stores its records in the slice pointed
+// to by v, whose elements must be structs or pointers to structs. The
+// first record of data is a header naming the column of each field.
+// Unmarshal replaces the contents of the slice with one element for each
+// following record.
+//
+// Columns are matched to struct fields by the names described for
+// Marshal, preferring an exact match but also accepting a case-insensitive
+// one. Columns without a matching field are ignored, and fields without a
+// matching column keep their zero value.
+//
+// Values implementing encoding.TextUnmarshaler are decoded with their
+// UnmarshalText method. Otherwise, strings, booleans, integers and
+// floating point numbers are supported, as are pointers to these. An
+// empty field stores the zero value, such as a nil pointer, without
+// calling UnmarshalText.
+//
+// If a field cannot be stored, Unmarshal returns a *ParseError with its
+// line and column.
+func Unmarshal(data []byte, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("csv: Unmarshal of non-slice-pointer type %T", v)
+	}
+	slice := rv.Elem()
+	elemType := slice.Type().Elem()
+	t := structType(elemType)
+	if t == nil {
+		return fmt.Errorf("csv: Unmarshal of slice of non-struct type %v", elemType)
+	}
+	slice.SetLen(0)
+	d := NewDecoder(NewReader(bytes.NewReader(data)))
+	for {
+		elem := reflect.New(t)
+		if err := d.decode(elem.Elem()); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if elemType.Kind() != reflect.Ptr {
+			elem = elem.Elem()
+		}
+		slice.Set(reflect.Append(slice, elem))
+	}
+}
+
+// A Decoder reads CSV records from a Reader into structs.
+type Decoder struct {
+	r      *Reader
+	header []string
+
+	// columns holds the field of typ that each column maps to,
+	// or nil if it maps to none.
+	typ     reflect.Type
+	columns []*field
+}
+
+// NewDecoder returns a new decoder that reads from r.
+func NewDecoder(r *Reader) *Decoder {
+	return &Decoder{r: r}
+}
+
+// Header returns the header record, reading it from the Reader
+// if it has not been read yet. A leading byte order mark is removed
+// from the first column name.
+func (d *Decoder) Header() ([]string, error) {
+	if d.header != nil {
+		return d.header, nil
+	}
+	header, err := d.r.Read()
+	if err != nil {
+		return nil, err
+	}
+	d.header = append([]string(nil), header...)
+	if len(d.header) > 0 {
+		d.header[0] = strings.TrimPrefix(d.header[0], "\ufeff")
+	}
+	return d.header, nil
+}
+
+// Decode reads the next record and stores it in the struct pointed to
+// by v, converting fields as described for Unmarshal. Before the first
+// record, Decode reads the header. At the end of the input, Decode
+// returns io.EOF.
+func (d *Decoder) Decode(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("csv: Decode of non-struct-pointer type %T", v)
+	}
+	return d.decode(rv.Elem())
+}
+
+// decode reads the next record into the struct v.
+func (d *Decoder) decode(v reflect.Value) error {
+	if _, err := d.Header(); err != nil {
+		return err
+	}
+	if v.Type() != d.typ {
+		if err := d.mapColumns(v.Type()); err != nil {
+			return err
+		}
+	}
+	record, err := d.r.Read()
+	if err != nil {
+		return err
+	}
+	for i, s := range record {
+		if i >= len(d.columns) || d.columns[i] == nil {
+			continue
+		}
+		f := d.columns[i]
+		fv, _ := fieldByIndex(v, f.index, true)
+		if err := setValue(fv, s); err != nil {
+			startLine, _ := d.r.FieldPos(0)
+			line, col := d.r.FieldPos(i)
+			return &ParseError{
+				StartLine: startLine,
+				Line:      line,
+				Column:    col,
+				Err:       fmt.Errorf("cannot unmarshal %q into field %s of type %v: %w", s, f.name, f.typ, err),
+			}
+		}
+	}
+	return nil
+}
+
+// mapColumns maps the columns of the header to the fields of t.
+func (d *Decoder) mapColumns(t reflect.Type) error {
+	fields, err := cachedFields(t)
+	if err != nil {
+		return err
+	}
+	d.typ = t
+	d.columns = make([]*field, len(d.header))
+	for i, name := range d.header {
+		var fold *field
+		for j := range fields {
+			f := &fields[j]
+			if f.name == name {
+				d.columns[i] = f
+				break
+			}
+			if fold == nil && strings.EqualFold(f.name, name) {
+				fold = f
+			}
+		}
+		if d.columns[i] == nil {
+			d.columns[i] = fold
+		}
+	}
+	return nil
+}
+
+// setValue stores the CSV field s in v.
+func setValue(v reflect.Value, s string) error {
+	if s == "" {
+		v.Set(reflect.Zero(v.Type()))
+		return nil
+	}
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+	if v.Addr().Type().Implements(textUnmarshalerType) {
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return numError(err)
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return numError(err)
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return numError(err)
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return numError(err)
+		}
+		v.SetFloat(n)
+	default:
+		return &UnsupportedTypeError{v.Type()}
+	}
+	return nil
+}
+
+// numError returns the underlying error of a *strconv.NumError,
+// whose message would repeat the field.
+func numError(err error) error {
+	var ne *strconv.NumError
+	if errors.As(err, &ne) {
+		return ne.Err
+	}
+	return err
+}
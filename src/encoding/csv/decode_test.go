@@ -0,0 +1,139 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package csv
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestUnmarshal(t *testing.T) {
+	var people []Person
+	if err := Unmarshal([]byte(marshalOutput), &people); err != nil {
+		t.Fatal(err)
+	}
+	want := make([]Person, len(marshalPeople))
+	copy(want, marshalPeople)
+	want[0].Ignored = ""
+	want[1].Extra = &Extra{} // allocated to hold the empty Note
+	if len(people) != len(want) {
+		t.Fatalf("got %d people; want %d", len(people), len(want))
+	}
+	for i := range want {
+		if !reflect.DeepEqual(people[i], want[i]) {
+			t.Errorf("person %d:\ngot  %+v\nwant %+v", i, people[i], want[i])
+		}
+	}
+
+	// Unmarshal replaces the contents of the slice.
+	in := "\ufeffCITY,zip,unknown\nOslo,150,x\n"
+	inner := []*Inner{{"Paris", 750}, {"Rome", 118}}
+	if err := Unmarshal([]byte(in), &inner); err != nil {
+		t.Fatal(err)
+	}
+	if len(inner) != 1 || *inner[0] != (Inner{"Oslo", 150}) {
+		t.Errorf("Unmarshal into []*Inner: got %v", inner)
+	}
+
+	// Empty input.
+	inner = nil
+	if err := Unmarshal(nil, &inner); err != nil || len(inner) != 0 {
+		t.Errorf("Unmarshal of empty input = %v, %v; want no elements", inner, err)
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	tests := []struct {
+		in   string
+		line int
+		col  int
+		msg  string
+		err  error
+	}{
+		{
+			in:   "name,age\nAnn,31\nBob,old\n",
+			line: 3, col: 5,
+			msg: `parse error on line 3, column 5: cannot unmarshal "old" into field age of type int: invalid syntax`,
+			err: strconv.ErrSyntax,
+		},
+		{
+			in:   "name,admin\n\"multi\nline\",maybe\n",
+			line: 3, col: 7,
+			msg: `record on line 2; parse error on line 3, column 7: cannot unmarshal "maybe" into field admin of type bool: invalid syntax`,
+		},
+		{
+			in:   "born\n2020-13-01\n",
+			line: 2, col: 1,
+		},
+		{
+			in:   "zip\n1,2\n",
+			line: 2, col: 1,
+			err: ErrFieldCount,
+		},
+	}
+	for _, tt := range tests {
+		var people []Person
+		err := Unmarshal([]byte(tt.in), &people)
+		var pe *ParseError
+		if !errors.As(err, &pe) {
+			t.Errorf("Unmarshal(%q): got %v; want ParseError", tt.in, err)
+			continue
+		}
+		if pe.Line != tt.line || pe.Column != tt.col {
+			t.Errorf("Unmarshal(%q): error at %d:%d; want %d:%d", tt.in, pe.Line, pe.Column, tt.line, tt.col)
+		}
+		if tt.msg != "" && err.Error() != tt.msg {
+			t.Errorf("Unmarshal(%q): error %q; want %q", tt.in, err, tt.msg)
+		}
+		if tt.err != nil && !errors.Is(err, tt.err) {
+			t.Errorf("Unmarshal(%q): error %v does not wrap %v", tt.in, err, tt.err)
+		}
+	}
+
+	var people []Person
+	if err := Unmarshal([]byte("name\n"), people); err == nil {
+		t.Error("Unmarshal into non-pointer succeeded; want error")
+	}
+	var ints []int
+	if err := Unmarshal([]byte("name\n"), &ints); err == nil {
+		t.Error("Unmarshal into []int succeeded; want error")
+	}
+}
+
+func TestDecoder(t *testing.T) {
+	r := NewReader(strings.NewReader("# comment\nzip;city\n150;Oslo\n;Rome\n"))
+	r.Comma = ';'
+	r.Comment = '#'
+	dec := NewDecoder(r)
+	header, err := dec.Header()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"zip", "city"}; !reflect.DeepEqual(header, want) {
+		t.Errorf("Header() = %q; want %q", header, want)
+	}
+	var got []Inner
+	for {
+		in := Inner{Zip: -1}
+		err := dec.Decode(&in)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, in)
+	}
+	if want := []Inner{{"Oslo", 150}, {"Rome", 0}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("decoded %v; want %v", got, want)
+	}
+	if err := dec.Decode(Inner{}); err == nil {
+		t.Error("Decode into non-pointer succeeded; want error")
+	}
+}
@@ -0,0 +1,343 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package csv
+
+import (
+	"bytes"
+	"encoding"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"sync"
+)
+
+// Marshal returns the CSV encoding of v, which must be a slice or array
+// of structs or of pointers to structs. The first record is a header
+// holding the column name of each field, followed by one record for each
+// element of v. A slice without elements is encoded as the header alone.
+//
+// Each exported struct field becomes a column, named after the field
+// unless its tag gives another name:
+//
+//	// Field appears in CSV as column "myName".
+//	Field int `csv:"myName"`
+//
+//	// Field is ignored by this package.
+//	Field int `csv:"-"`
+//
+// The fields of an untagged anonymous struct field are treated as if
+// they were fields of the outer struct, as in package encoding/json.
+//
+// Values implementing encoding.TextMarshaler are encoded with their
+// MarshalText method. Otherwise, strings, booleans, integers and floating
+// point numbers are supported, as are pointers to these; a nil pointer
+// is encoded as an empty field. Fields of other types make Marshal return
+// an UnsupportedTypeError.
+func Marshal(v interface{}) ([]byte, error) {
+	rv := reflect.ValueOf(v)
+	if k := rv.Kind(); k != reflect.Slice && k != reflect.Array {
+		return nil, fmt.Errorf("csv: Marshal of non-slice type %T", v)
+	}
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	e := NewEncoder(w)
+	if err := e.writeHeader(structType(rv.Type().Elem())); err != nil {
+		return nil, err
+	}
+	for i := 0; i < rv.Len(); i++ {
+		if err := e.encode(rv.Index(i)); err != nil {
+			return nil, err
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// An UnsupportedTypeError is returned when a struct field has a type
+// that cannot be converted to or from a CSV field.
+type UnsupportedTypeError struct {
+	Type reflect.Type
+}
+
+func (e *UnsupportedTypeError) Error() string {
+	return "csv: unsupported type: " + e.Type.String()
+}
+
+// An Encoder writes structs as CSV records to a Writer.
+type Encoder struct {
+	w      *Writer
+	typ    reflect.Type // type of the structs encoded so far
+	fields []field
+	record []string
+}
+
+// NewEncoder returns a new encoder that writes to w.
+func NewEncoder(w *Writer) *Encoder {
+	return &Encoder{w: w}
+}
+
+// Encode writes the CSV record of v, which must be a struct or a pointer
+// to a struct, to the Writer, converting fields as described for Marshal.
+// Before the first record, Encode writes a header holding the column
+// names. All values passed to Encode must have the same type.
+//
+// As with Writer.Write, the record is buffered; call Flush on the
+// Writer to ensure that it is written.
+func (e *Encoder) Encode(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if !rv.IsValid() {
+		return errors.New("csv: cannot encode nil")
+	}
+	if e.typ == nil {
+		if err := e.writeHeader(structType(rv.Type())); err != nil {
+			return err
+		}
+	}
+	return e.encode(rv)
+}
+
+// writeHeader sets the type of the structs to encode to t and writes
+// the header record.
+func (e *Encoder) writeHeader(t reflect.Type) error {
+	if t == nil || t.Kind() != reflect.Struct {
+		return errors.New("csv: can only encode structs or pointers to structs")
+	}
+	fields, err := cachedFields(t)
+	if err != nil {
+		return err
+	}
+	e.typ, e.fields = t, fields
+	e.record = make([]string, len(fields))
+	for i := range fields {
+		e.record[i] = fields[i].name
+	}
+	return e.w.Write(e.record)
+}
+
+// encode writes the record of v, a struct of type e.typ
+// or a pointer to one.
+func (e *Encoder) encode(v reflect.Value) error {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return errors.New("csv: cannot encode nil pointer")
+		}
+		v = v.Elem()
+	}
+	if v.Type() != e.typ {
+		return fmt.Errorf("csv: cannot encode %v after %v", v.Type(), e.typ)
+	}
+	if !v.CanAddr() {
+		// Make v addressable, so that methods with a pointer
+		// receiver can be called.
+		pv := reflect.New(v.Type())
+		pv.Elem().Set(v)
+		v = pv.Elem()
+	}
+	for i := range e.fields {
+		f := &e.fields[i]
+		fv, ok := fieldByIndex(v, f.index, false)
+		if !ok {
+			e.record[i] = ""
+			continue
+		}
+		s, err := formatValue(fv)
+		if err != nil {
+			return err
+		}
+		e.record[i] = s
+	}
+	return e.w.Write(e.record)
+}
+
+var (
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// formatValue returns the CSV field for v, which must be addressable.
+func formatValue(v reflect.Value) (string, error) {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return "", nil
+		}
+		v = v.Elem()
+	}
+	if v.Addr().Type().Implements(textMarshalerType) {
+		b, err := v.Addr().Interface().(encoding.TextMarshaler).MarshalText()
+		return string(b), err
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'g', -1, v.Type().Bits()), nil
+	}
+	return "", &UnsupportedTypeError{v.Type()}
+}
+
+// structType returns the struct type t or *t, or nil if t is neither.
+func structType(t reflect.Type) reflect.Type {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	return t
+}
+
+// A field is a struct field mapped to a CSV column.
+type field struct {
+	name  string // column name
+	index []int  // index sequence for reflect.Value.FieldByIndex
+	typ   reflect.Type
+}
+
+var fieldCache sync.Map // map[reflect.Type][]field
+
+// cachedFields is like typeFields but uses a cache to avoid repeated work.
+func cachedFields(t reflect.Type) ([]field, error) {
+	if f, ok := fieldCache.Load(t); ok {
+		return f.([]field), nil
+	}
+	fields, err := typeFields(t)
+	if err != nil {
+		return nil, err
+	}
+	f, _ := fieldCache.LoadOrStore(t, fields)
+	return f.([]field), nil
+}
+
+// typeFields returns the fields of the struct type t that map to
+// columns, in the order of their declaration. If two fields have the
+// same name, the one nested least deeply is used, or the first one if
+// they are equally deep.
+func typeFields(t reflect.Type) ([]field, error) {
+	var fields []field
+	if err := walkFields(t, nil, &fields, map[reflect.Type]bool{}); err != nil {
+		return nil, err
+	}
+	byName := make(map[string]int)
+	keep := make([]bool, len(fields))
+	for i := range fields {
+		j, dup := byName[fields[i].name]
+		if !dup {
+			byName[fields[i].name] = i
+			keep[i] = true
+		} else if len(fields[i].index) < len(fields[j].index) {
+			byName[fields[i].name] = i
+			keep[i], keep[j] = true, false
+		}
+	}
+	out := fields[:0]
+	for i := range fields {
+		if keep[i] {
+			out = append(out, fields[i])
+		}
+	}
+	return out, nil
+}
+
+func walkFields(t reflect.Type, index []int, fields *[]field, visited map[reflect.Type]bool) error {
+	if visited[t] {
+		return nil
+	}
+	visited[t] = true
+	defer delete(visited, t)
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag := sf.Tag.Get("csv")
+		if tag == "-" {
+			continue
+		}
+		idx := make([]int, len(index)+1)
+		copy(idx, index)
+		idx[len(index)] = i
+		if sf.Anonymous && tag == "" {
+			ft := sf.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct && !isText(ft) {
+				if !sf.IsExported() && sf.Type.Kind() == reflect.Ptr {
+					// Cannot allocate an unexported pointer.
+					continue
+				}
+				if err := walkFields(ft, idx, fields, visited); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		if !sf.IsExported() {
+			continue
+		}
+		if !supported(sf.Type) {
+			return &UnsupportedTypeError{sf.Type}
+		}
+		name := tag
+		if name == "" {
+			name = sf.Name
+		}
+		*fields = append(*fields, field{name: name, index: idx, typ: sf.Type})
+	}
+	return nil
+}
+
+// isText reports whether values of type t or *t implement
+// encoding.TextMarshaler or encoding.TextUnmarshaler.
+func isText(t reflect.Type) bool {
+	pt := reflect.PtrTo(t)
+	return pt.Implements(textMarshalerType) || pt.Implements(textUnmarshalerType)
+}
+
+// supported reports whether values of type t can be converted
+// to or from a CSV field.
+func supported(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if isText(t) {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// fieldByIndex returns the nested field of v with the given index
+// sequence. If a pointer to an embedded struct on the way is nil,
+// fieldByIndex allocates it if alloc is true, and otherwise
+// returns false.
+func fieldByIndex(v reflect.Value, index []int, alloc bool) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				if !alloc {
+					return reflect.Value{}, false
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
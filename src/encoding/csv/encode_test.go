@@ -0,0 +1,108 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package csv
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+type Inner struct {
+	City string `csv:"city"`
+	Zip  int    `csv:"zip"`
+}
+
+type Person struct {
+	Name    string    `csv:"name"`
+	Age     int       `csv:"age"`
+	Height  float64   `csv:"height"`
+	Admin   bool      `csv:"admin"`
+	Born    time.Time `csv:"born"`
+	Nick    *string   `csv:"nick"`
+	Ignored string    `csv:"-"`
+	private int
+	Inner
+	*Extra
+}
+
+type Extra struct {
+	Note string
+	Name string `csv:"name"` // shadowed by Person.Name
+}
+
+func strPtr(s string) *string { return &s }
+
+var born = time.Date(1990, 5, 17, 0, 0, 0, 0, time.UTC)
+
+var marshalPeople = []Person{
+	{Name: "Ann", Age: 31, Height: 1.7, Admin: true, Born: born, Nick: strPtr("annie"), Ignored: "x", Inner: Inner{"Oslo", 150}, Extra: &Extra{Note: "a, b"}},
+	{Name: "Bob", Age: 4, Height: 1.05, Born: born, Inner: Inner{"Rome", 118}},
+}
+
+const marshalOutput = `name,age,height,admin,born,nick,city,zip,Note
+Ann,31,1.7,true,1990-05-17T00:00:00Z,annie,Oslo,150,"a, b"
+Bob,4,1.05,false,1990-05-17T00:00:00Z,,Rome,118,
+`
+
+func TestMarshal(t *testing.T) {
+	out, err := Marshal(marshalPeople)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != marshalOutput {
+		t.Errorf("Marshal:\ngot:\n%s\nwant:\n%s", out, marshalOutput)
+	}
+
+	// Pointers to structs, and no elements.
+	out, err = Marshal([]*Inner{})
+	if err != nil || string(out) != "city,zip\n" {
+		t.Errorf("Marshal of empty slice = %q, %v; want header only", out, err)
+	}
+	out, err = Marshal([]*Inner{{"Oslo", 150}})
+	if err != nil || string(out) != "city,zip\nOslo,150\n" {
+		t.Errorf("Marshal of pointers = %q, %v", out, err)
+	}
+}
+
+func TestMarshalErrors(t *testing.T) {
+	type unsupported struct {
+		List []int
+	}
+	var ute *UnsupportedTypeError
+	if _, err := Marshal([]unsupported{{}}); !errors.As(err, &ute) {
+		t.Errorf("Marshal of unsupported field: got %v; want UnsupportedTypeError", err)
+	}
+	if _, err := Marshal(Inner{}); err == nil {
+		t.Error("Marshal of struct succeeded; want error")
+	}
+	if _, err := Marshal([]int{1}); err == nil {
+		t.Error("Marshal of []int succeeded; want error")
+	}
+	if _, err := Marshal([]*Inner{nil}); err == nil {
+		t.Error("Marshal of nil element succeeded; want error")
+	}
+}
+
+func TestEncoder(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	w.Comma = ';'
+	enc := NewEncoder(w)
+	for _, v := range []interface{}{Inner{"Oslo", 150}, &Inner{"Rome", 118}} {
+		if err := enc.Encode(v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := enc.Encode(Extra{}); err == nil || !strings.Contains(err.Error(), "after") {
+		t.Errorf("Encode of different type: got %v; want error", err)
+	}
+	w.Flush()
+	if want := "city;zip\nOslo;150\nRome;118\n"; buf.String() != want {
+		t.Errorf("Encoder wrote %q; want %q", buf.String(), want)
+	}
+}
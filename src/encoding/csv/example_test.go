@@ -129,3 +129,43 @@ func ExampleWriter_WriteAll() {
 	// Ken,Thompson,ken
 	// Robert,Griesemer,gri
 }
+
+func ExampleUnmarshal() {
+	in := `name,born,admin
+Rob,1956,true
+Ken,1943,false
+`
+	type User struct {
+		Name  string `csv:"name"`
+		Born  int    `csv:"born"`
+		Admin bool   `csv:"admin"`
+	}
+	var users []User
+	if err := csv.Unmarshal([]byte(in), &users); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("%+v\n", users)
+	// Output:
+	// [{Name:Rob Born:1956 Admin:true} {Name:Ken Born:1943 Admin:false}]
+}
+
+func ExampleEncoder() {
+	type Point struct {
+		X, Y int
+	}
+	w := csv.NewWriter(os.Stdout)
+	enc := csv.NewEncoder(w)
+	for _, p := range []Point{{1, 2}, {3, 4}} {
+		if err := enc.Encode(p); err != nil {
+			log.Fatal(err)
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		log.Fatal(err)
+	}
+	// Output:
+	// X,Y
+	// 1,2
+	// 3,4
+}
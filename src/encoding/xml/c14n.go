@@ -0,0 +1,389 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package xml
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// A C14NMethod is an XML canonicalization algorithm.
+type C14NMethod int
+
+const (
+	// C14N10 is Canonical XML 1.0,
+	// https://www.w3.org/TR/2001/REC-xml-c14n-20010315.
+	C14N10 C14NMethod = iota
+
+	// C14N11 is Canonical XML 1.1,
+	// https://www.w3.org/TR/2008/REC-xml-c14n11-20080502/.
+	C14N11
+
+	// ExcC14N10 is Exclusive XML Canonicalization 1.0,
+	// https://www.w3.org/TR/2002/REC-xml-exc-c14n-20020718/.
+	ExcC14N10
+)
+
+// A CanonicalEncoder writes a stream of XML tokens in canonical form.
+//
+// The tokens are those of a document, or of an element and its content,
+// as returned by Decoder.Token or Decoder.RawToken. Names are written with
+// the prefixes declared by the tokens, so RawToken, which keeps the prefixes
+// of the input even when Token would translate them ambiguously, is
+// usually the better choice. Entity references and CDATA sections are
+// expected to have been replaced by the Decoder.
+//
+// The canonical form of an element of a document, such as the one holding
+// an XML signature, depends on the name spaces and xml: attributes
+// inherited from its ancestors; these must be given in Context.
+//
+// Canonical XML 1.1 fixes up xml:base attributes inherited by an element
+// by resolving them against each other. CanonicalEncoder does not; it
+// ignores inherited xml:base attributes instead.
+type CanonicalEncoder struct {
+	// Comments causes comments to be written, as by the "WithComments"
+	// variants of the algorithms. By default they are removed.
+	Comments bool
+
+	// InclusivePrefixes lists the prefixes whose declarations ExcC14N10
+	// renders as the inclusive algorithms do, its InclusiveNamespaces
+	// PrefixList. The prefix "#default" denotes the default name space.
+	InclusivePrefixes []string
+
+	// Context holds the name space declarations and xml: attributes
+	// in scope at the start of the token stream, such as those of the
+	// ancestors of the element being canonicalized, outermost first.
+	Context []Attr
+
+	w      *bufio.Writer
+	method C14NMethod
+	ns     nsScope
+	stack  []c14nElement
+	root   int // 0 before the document element, 1 within it, 2 after it
+}
+
+// A c14nElement is an open element of a CanonicalEncoder.
+type c14nElement struct {
+	name  Name
+	qname string
+
+	// rendered maps the prefixes declared by the element or its
+	// ancestors in the output to their name spaces.
+	rendered map[string]string
+}
+
+// NewCanonicalEncoder returns a new encoder that writes to w
+// using the given canonicalization method.
+func NewCanonicalEncoder(w io.Writer, method C14NMethod) *CanonicalEncoder {
+	return &CanonicalEncoder{w: bufio.NewWriter(w), method: method}
+}
+
+// EncodeToken writes the canonical form of the given token.
+// It returns an error if StartElement and EndElement tokens are not
+// properly matched, or if a name uses a prefix that is not declared.
+//
+// The XML declaration and directives, such as the document type
+// declaration, are removed, as are character data, comments and
+// processing instructions outside the document element that the
+// canonical form does not keep.
+//
+// Callers need to call Flush when finished to ensure that the XML is
+// written to the underlying writer.
+func (enc *CanonicalEncoder) EncodeToken(t Token) error {
+	switch t := t.(type) {
+	case StartElement:
+		return enc.writeStart(&t)
+	case EndElement:
+		return enc.writeEnd(t.Name)
+	case CharData:
+		if enc.root == 1 {
+			enc.escape(t, false)
+		}
+	case Comment:
+		if !enc.Comments {
+			break
+		}
+		enc.beforeNode()
+		enc.w.WriteString("<!--")
+		enc.w.Write(t)
+		enc.w.WriteString("-->")
+		enc.afterNode()
+	case ProcInst:
+		if t.Target == "xml" {
+			break
+		}
+		enc.beforeNode()
+		enc.w.WriteString("<?")
+		enc.w.WriteString(t.Target)
+		if len(t.Inst) > 0 {
+			enc.w.WriteByte(' ')
+			enc.w.Write(t.Inst)
+		}
+		enc.w.WriteString("?>")
+		enc.afterNode()
+	case Directive:
+	default:
+		return fmt.Errorf("xml: EncodeToken of invalid token type")
+	}
+	return nil
+}
+
+// beforeNode and afterNode write the line breaks separating
+// the comments and processing instructions outside the document
+// element from the element.
+func (enc *CanonicalEncoder) beforeNode() {
+	if enc.root == 2 {
+		enc.w.WriteByte('\n')
+	}
+}
+
+func (enc *CanonicalEncoder) afterNode() {
+	if enc.root == 0 {
+		enc.w.WriteByte('\n')
+	}
+}
+
+// Flush flushes any buffered XML to the underlying writer.
+func (enc *CanonicalEncoder) Flush() error {
+	return enc.w.Flush()
+}
+
+// A c14nAttr is an attribute to be written by a CanonicalEncoder.
+type c14nAttr struct {
+	url   string // name space, for sorting
+	local string
+	qname string
+	value string
+}
+
+func (enc *CanonicalEncoder) writeStart(start *StartElement) error {
+	if start.Name.Local == "" {
+		return fmt.Errorf("xml: start tag with no name")
+	}
+	var parent map[string]string
+	attrs := start.Attr
+	if len(enc.stack) == 0 {
+		enc.ns.decls = enc.ns.decls[:0]
+		enc.ns.push(enc.Context, 0)
+		attrs = enc.inherit(attrs)
+	} else {
+		parent = enc.stack[len(enc.stack)-1].rendered
+	}
+	depth := len(enc.stack) + 1
+	enc.ns.push(attrs, depth)
+
+	prefix, err := enc.prefix(start.Name.Space, true)
+	if err != nil {
+		return err
+	}
+	qname := start.Name.Local
+	if prefix != "" {
+		qname = prefix + ":" + qname
+	}
+
+	// Collect the attributes, and the prefixes they use.
+	used := map[string]bool{prefix: true}
+	var out []c14nAttr
+	for _, a := range attrs {
+		if _, ok := nsDeclPrefix(a.Name); ok || a.Name.Local == "" {
+			continue
+		}
+		attr := c14nAttr{local: a.Name.Local, qname: a.Name.Local, value: a.Value}
+		if a.Name.Space != "" {
+			prefix, err := enc.prefix(a.Name.Space, false)
+			if err != nil {
+				return err
+			}
+			attr.qname = prefix + ":" + attr.qname
+			if prefix == xmlPrefix {
+				attr.url = xmlURL
+			} else {
+				attr.url, _ = enc.ns.lookup(prefix)
+				used[prefix] = true
+			}
+		}
+		out = append(out, attr)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].url != out[j].url {
+			return out[i].url < out[j].url
+		}
+		return out[i].local < out[j].local
+	})
+
+	// Decide which name space declarations to render: all of those
+	// in scope for the inclusive methods, and only those used by the
+	// element for the exclusive one, unless they are listed as
+	// inclusive prefixes. A declaration is left out if an ancestor in
+	// the output already made it.
+	candidates := used
+	if enc.method == ExcC14N10 {
+		for _, p := range enc.InclusivePrefixes {
+			if p == "#default" {
+				p = ""
+			}
+			candidates[p] = true
+		}
+	} else {
+		for _, d := range enc.ns.decls {
+			candidates[d.prefix] = true
+		}
+		candidates[""] = true
+	}
+	var decls []string
+	for p := range candidates {
+		url, ok := enc.ns.lookup(p)
+		if !ok && p != "" || parent[p] == url {
+			continue
+		}
+		decls = append(decls, p)
+	}
+	sort.Strings(decls)
+	rendered := parent
+	if len(decls) > 0 {
+		rendered = make(map[string]string, len(parent)+len(decls))
+		for p, url := range parent {
+			rendered[p] = url
+		}
+	}
+
+	enc.w.WriteByte('<')
+	enc.w.WriteString(qname)
+	for _, p := range decls {
+		url, _ := enc.ns.lookup(p)
+		rendered[p] = url
+		enc.w.WriteString(" xmlns")
+		if p != "" {
+			enc.w.WriteByte(':')
+			enc.w.WriteString(p)
+		}
+		enc.w.WriteString(`="`)
+		enc.escape([]byte(url), true)
+		enc.w.WriteByte('"')
+	}
+	for _, a := range out {
+		enc.w.WriteByte(' ')
+		enc.w.WriteString(a.qname)
+		enc.w.WriteString(`="`)
+		enc.escape([]byte(a.value), true)
+		enc.w.WriteByte('"')
+	}
+	enc.w.WriteByte('>')
+
+	enc.stack = append(enc.stack, c14nElement{start.Name, qname, rendered})
+	enc.root = 1
+	return nil
+}
+
+func (enc *CanonicalEncoder) writeEnd(name Name) error {
+	if len(enc.stack) == 0 {
+		return fmt.Errorf("xml: end tag </%s> without start tag", name.Local)
+	}
+	top := enc.stack[len(enc.stack)-1]
+	if top.name != name {
+		return fmt.Errorf("xml: end tag </%s> does not match start tag <%s>", name.Local, top.qname)
+	}
+	enc.ns.pop(len(enc.stack))
+	enc.stack = enc.stack[:len(enc.stack)-1]
+	enc.w.WriteString("</")
+	enc.w.WriteString(top.qname)
+	enc.w.WriteByte('>')
+	if len(enc.stack) == 0 {
+		enc.root = 2
+	}
+	return nil
+}
+
+// inherit returns the attributes of the first element, adding the xml:
+// attributes of the Context that the method lets it inherit.
+func (enc *CanonicalEncoder) inherit(attrs []Attr) []Attr {
+	if enc.method == ExcC14N10 {
+		return attrs
+	}
+	var inherited []Attr
+Context:
+	for _, a := range enc.Context {
+		if a.Name.Space != xmlPrefix && a.Name.Space != xmlURL {
+			continue
+		}
+		if enc.method == C14N11 && a.Name.Local != "lang" && a.Name.Local != "space" {
+			continue
+		}
+		for _, b := range attrs {
+			if (b.Name.Space == xmlPrefix || b.Name.Space == xmlURL) && b.Name.Local == a.Name.Local {
+				continue Context
+			}
+		}
+		for i, b := range inherited {
+			if b.Name.Local == a.Name.Local {
+				// An inner ancestor overrides an outer one.
+				inherited = append(inherited[:i], inherited[i+1:]...)
+				break
+			}
+		}
+		inherited = append(inherited, a)
+	}
+	if len(inherited) == 0 {
+		return attrs
+	}
+	return append(inherited, attrs...)
+}
+
+// prefix returns the prefix to write for a name in the name space space.
+func (enc *CanonicalEncoder) prefix(space string, isElementName bool) (string, error) {
+	if space == "" {
+		return "", nil
+	}
+	prefix, ok := enc.ns.qualify(space, isElementName)
+	if !ok {
+		return "", fmt.Errorf("xml: no prefix declared for name space %s", space)
+	}
+	return prefix, nil
+}
+
+// escape writes s escaped as character data, or as an attribute
+// value if attr is true.
+func (enc *CanonicalEncoder) escape(s []byte, attr bool) {
+	last := 0
+	for i, c := range s {
+		var esc string
+		switch c {
+		case '&':
+			esc = "&amp;"
+		case '<':
+			esc = "&lt;"
+		case '>':
+			if attr {
+				continue
+			}
+			esc = "&gt;"
+		case '"':
+			if !attr {
+				continue
+			}
+			esc = "&quot;"
+		case '\t':
+			if !attr {
+				continue
+			}
+			esc = "&#x9;"
+		case '\n':
+			if !attr {
+				continue
+			}
+			esc = "&#xA;"
+		case '\r':
+			esc = "&#xD;"
+		default:
+			continue
+		}
+		enc.w.Write(s[last:i])
+		enc.w.WriteString(esc)
+		last = i + 1
+	}
+	enc.w.Write(s[last:])
+}
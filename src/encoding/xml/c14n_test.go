@@ -0,0 +1,247 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package xml
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+// The documents of the examples in section 3 of Canonical XML 1.0,
+// without those parts that need a DTD.
+const (
+	c14nPIsCommentsOutside = `<?xml version="1.0"?>
+
+<?xml-stylesheet   href="doc.xsl"
+   type="text/xsl"   ?>
+
+<!DOCTYPE doc SYSTEM "doc.dtd">
+
+<doc>Hello, world!<!-- Comment 1 --></doc>
+
+<?pi-without-data     ?>
+
+<!-- Comment 2 -->
+
+<!-- Comment 3 -->`
+
+	c14nStartEndTags = `<!DOCTYPE doc [<!ATTLIST e9 attr CDATA "default">]>
+<doc>
+   <e1   />
+   <e2   ></e2>
+   <e3   name = "elem3"   id="elem3"   />
+   <e4   name="elem4"   id="elem4"   ></e4>
+   <e5 a:attr="out" b:attr="sorted" attr2="all" attr="I'm"
+      xmlns:b="http://www.ietf.org"
+      xmlns:a="http://www.w3.org"
+      xmlns="http://example.org"/>
+   <e6 xmlns="" xmlns:a="http://www.w3.org">
+      <e7 xmlns="http://www.ietf.org">
+         <e8 xmlns="" xmlns:a="http://www.w3.org">
+            <e9 xmlns="" xmlns:a="http://www.ietf.org"/>
+         </e8>
+      </e7>
+   </e6>
+</doc>`
+
+	c14nCharacters = `<doc>
+   <text>First line&#x0d;&#10;Second line</text>
+   <value>&#x32;</value>
+   <compute><![CDATA[value>"0" && value<"10" ?"valid":"error"]]></compute>
+   <compute expr='value>"0" &amp;&amp; value&lt;"10" ?"valid":"error"'>valid</compute>
+   <norm attr=' &apos;   &#x20;&#13;&#xa;&#9;   &apos; '/>
+</doc>`
+
+	// From section 2.2 of Exclusive XML Canonicalization 1.0.
+	c14nExclusive = `<n1:elem2 xmlns:n1="http://example.net" xml:lang="en"><n3:stuff xmlns:n3="ftp://example.org"/></n1:elem2>`
+)
+
+// c14nContext is the context of c14nExclusive in the example.
+var c14nContext = []Attr{
+	{Name{"xmlns", "n0"}, "foo:bar"},
+	{Name{"xmlns", "n3"}, "ftp://example.org"},
+	{Name{"xml", "lang"}, "fr"},
+	{Name{"xml", "id"}, "x"},
+}
+
+var c14nTests = []struct {
+	desc     string
+	in       string
+	method   C14NMethod
+	comments bool
+	context  []Attr
+	prefixes []string
+	want     string
+}{{
+	desc:   "PIs, comments and outside of document element",
+	in:     c14nPIsCommentsOutside,
+	method: C14N10,
+	want: `<?xml-stylesheet href="doc.xsl"
+   type="text/xsl"   ?>
+<doc>Hello, world!</doc>
+<?pi-without-data?>`,
+}, {
+	desc:     "PIs, comments and outside of document element, with comments",
+	in:       c14nPIsCommentsOutside,
+	method:   C14N10,
+	comments: true,
+	want: `<?xml-stylesheet href="doc.xsl"
+   type="text/xsl"   ?>
+<doc>Hello, world!<!-- Comment 1 --></doc>
+<?pi-without-data?>
+<!-- Comment 2 -->
+<!-- Comment 3 -->`,
+}, {
+	desc:   "start and end tags",
+	in:     c14nStartEndTags,
+	method: C14N10,
+	want: `<doc>
+   <e1></e1>
+   <e2></e2>
+   <e3 id="elem3" name="elem3"></e3>
+   <e4 id="elem4" name="elem4"></e4>
+   <e5 xmlns="http://example.org" xmlns:a="http://www.w3.org" xmlns:b="http://www.ietf.org" attr="I'm" attr2="all" b:attr="sorted" a:attr="out"></e5>
+   <e6 xmlns:a="http://www.w3.org">
+      <e7 xmlns="http://www.ietf.org">
+         <e8 xmlns="">
+            <e9 xmlns:a="http://www.ietf.org"></e9>
+         </e8>
+      </e7>
+   </e6>
+</doc>`,
+}, {
+	desc:   "start and end tags, exclusive",
+	in:     c14nStartEndTags,
+	method: ExcC14N10,
+	want: `<doc>
+   <e1></e1>
+   <e2></e2>
+   <e3 id="elem3" name="elem3"></e3>
+   <e4 id="elem4" name="elem4"></e4>
+   <e5 xmlns="http://example.org" xmlns:a="http://www.w3.org" xmlns:b="http://www.ietf.org" attr="I'm" attr2="all" b:attr="sorted" a:attr="out"></e5>
+   <e6>
+      <e7 xmlns="http://www.ietf.org">
+         <e8 xmlns="">
+            <e9></e9>
+         </e8>
+      </e7>
+   </e6>
+</doc>`,
+}, {
+	desc:   "character modifications",
+	in:     c14nCharacters,
+	method: C14N11,
+	want: `<doc>
+   <text>First line&#xD;
+Second line</text>
+   <value>2</value>
+   <compute>value&gt;"0" &amp;&amp; value&lt;"10" ?"valid":"error"</compute>
+   <compute expr="value>&quot;0&quot; &amp;&amp; value&lt;&quot;10&quot; ?&quot;valid&quot;:&quot;error&quot;">valid</compute>
+   <norm attr=" '    &#xD;&#xA;&#x9;   ' "></norm>
+</doc>`,
+}, {
+	desc:    "inclusive subtree",
+	in:      c14nExclusive,
+	method:  C14N10,
+	context: c14nContext,
+	want:    `<n1:elem2 xmlns:n0="foo:bar" xmlns:n1="http://example.net" xmlns:n3="ftp://example.org" xml:id="x" xml:lang="en"><n3:stuff></n3:stuff></n1:elem2>`,
+}, {
+	desc:    "inclusive 1.1 subtree",
+	in:      c14nExclusive,
+	method:  C14N11,
+	context: c14nContext,
+	want:    `<n1:elem2 xmlns:n0="foo:bar" xmlns:n1="http://example.net" xmlns:n3="ftp://example.org" xml:lang="en"><n3:stuff></n3:stuff></n1:elem2>`,
+}, {
+	desc:    "exclusive subtree",
+	in:      c14nExclusive,
+	method:  ExcC14N10,
+	context: c14nContext,
+	want:    `<n1:elem2 xmlns:n1="http://example.net" xml:lang="en"><n3:stuff xmlns:n3="ftp://example.org"></n3:stuff></n1:elem2>`,
+}, {
+	desc:     "exclusive subtree with inclusive prefixes",
+	in:       c14nExclusive,
+	method:   ExcC14N10,
+	context:  c14nContext,
+	prefixes: []string{"n0", "n3", "#default"},
+	want:     `<n1:elem2 xmlns:n0="foo:bar" xmlns:n1="http://example.net" xmlns:n3="ftp://example.org" xml:lang="en"><n3:stuff></n3:stuff></n1:elem2>`,
+}}
+
+func canonicalize(in string, method C14NMethod, comments bool, context []Attr, prefixes []string, raw bool) (string, error) {
+	var b strings.Builder
+	enc := NewCanonicalEncoder(&b, method)
+	enc.Comments = comments
+	enc.Context = context
+	enc.InclusivePrefixes = prefixes
+	d := NewDecoder(strings.NewReader(in))
+	for {
+		var tok Token
+		var err error
+		if raw {
+			tok, err = d.RawToken()
+		} else {
+			tok, err = d.Token()
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		if err := enc.EncodeToken(tok); err != nil {
+			return "", err
+		}
+	}
+	if err := enc.Flush(); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+func TestCanonicalEncoder(t *testing.T) {
+	for _, tt := range c14nTests {
+		for _, raw := range []bool{false, true} {
+			got, err := canonicalize(tt.in, tt.method, tt.comments, tt.context, tt.prefixes, raw)
+			if err != nil {
+				t.Errorf("%s (raw %v): %v", tt.desc, raw, err)
+				continue
+			}
+			if got != tt.want {
+				t.Errorf("%s (raw %v):\ngot  %s\nwant %s", tt.desc, raw, got, tt.want)
+			}
+		}
+	}
+}
+
+func TestCanonicalEncoderErrors(t *testing.T) {
+	tests := []struct {
+		toks []Token
+		err  string
+	}{{
+		toks: []Token{StartElement{Name{"p", "a"}, nil}},
+		err:  "xml: no prefix declared for name space p",
+	}, {
+		toks: []Token{
+			StartElement{Name{"", "a"}, nil},
+			EndElement{Name{"", "b"}},
+		},
+		err: "xml: end tag </b> does not match start tag <a>",
+	}, {
+		toks: []Token{EndElement{Name{"", "a"}}},
+		err:  "xml: end tag </a> without start tag",
+	}}
+	for _, tt := range tests {
+		enc := NewCanonicalEncoder(io.Discard, C14N10)
+		var err error
+		for _, tok := range tt.toks {
+			if err = enc.EncodeToken(tok); err != nil {
+				break
+			}
+		}
+		if err == nil || err.Error() != tt.err {
+			t.Errorf("EncodeToken(%v) = %v, want %s", tt.toks, err, tt.err)
+		}
+	}
+}
@@ -7,7 +7,9 @@ package xml_test
 import (
 	"encoding/xml"
 	"fmt"
+	"io"
 	"os"
+	"strings"
 )
 
 func ExampleMarshalIndent() {
@@ -149,3 +151,32 @@ func ExampleUnmarshal() {
 	// Groups: [Friends Squash]
 	// Address: {Hanga Roa Easter Island}
 }
+
+// This example computes the exclusive canonical form of an element,
+// as done when verifying an XML signature.
+func ExampleCanonicalEncoder() {
+	const data = `<ds:SignedInfo xmlns:ds="http://www.w3.org/2000/09/xmldsig#" xmlns:saml="urn:oasis:names:tc:SAML:2.0:assertion">
+  <ds:CanonicalizationMethod Algorithm="http://www.w3.org/2001/10/xml-exc-c14n#"/>
+</ds:SignedInfo>`
+	enc := xml.NewCanonicalEncoder(os.Stdout, xml.ExcC14N10)
+	d := xml.NewDecoder(strings.NewReader(data))
+	for {
+		tok, err := d.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		if err := enc.EncodeToken(tok); err != nil {
+			fmt.Println(err)
+			return
+		}
+	}
+	enc.Flush()
+	// Output:
+	// <ds:SignedInfo xmlns:ds="http://www.w3.org/2000/09/xmldsig#">
+	//   <ds:CanonicalizationMethod Algorithm="http://www.w3.org/2001/10/xml-exc-c14n#"></ds:CanonicalizationMethod>
+	// </ds:SignedInfo>
+}
@@ -152,6 +152,23 @@ func (enc *Encoder) Indent(prefix, indent string) {
 	enc.p.indent = indent
 }
 
+// PreservePrefixes causes the Encoder to write names with the name space
+// prefixes declared by the tokens themselves, instead of declaring name
+// spaces anew on each element that uses them.
+//
+// A namespace declaration is an attribute named xmlns, for the default
+// name space, or one with a Name of the form {Space: "xmlns", Local: prefix},
+// as returned by Decoder.Token and RawToken, or {Local: "xmlns:" + prefix},
+// as given by struct tags such as `xml:"xmlns:prefix,attr"`. While it is
+// in scope, an element or attribute whose Name.Space is the declared name
+// space URL, or the declared prefix itself, is written with that prefix.
+// Re-encoding the tokens read by a Decoder therefore yields the prefixes
+// of the original document. Name spaces without a declaration are written
+// as usual.
+func (enc *Encoder) PreservePrefixes() {
+	enc.p.preserve = true
+}
+
 // Encode writes the XML encoding of v to the stream.
 //
 // See the documentation for Marshal for details about the conversion
@@ -313,6 +330,9 @@ type printer struct {
 	attrPrefix map[string]string // map name space -> prefix
 	prefixes   []string
 	tags       []Name
+	preserve   bool     // use the prefixes declared in ns
+	ns         nsScope  // declarations of the open elements, if preserve
+	qnames     []string // written names of the open elements, if preserve
 }
 
 // createAttrPrefix finds the name space prefix attribute to use for the given name space,
@@ -352,10 +372,11 @@ func (p *printer) createAttrPrefix(url string) string {
 	if len(prefix) >= 3 && strings.EqualFold(prefix[:3], "xml") {
 		prefix = "_" + prefix
 	}
-	if p.attrNS[prefix] != "" {
+	if _, ok := p.ns.lookup(prefix); ok || p.attrNS[prefix] != "" {
 		// Name is taken. Find a better one.
 		for p.seq++; ; p.seq++ {
-			if id := prefix + "_" + strconv.Itoa(p.seq); p.attrNS[id] == "" {
+			id := prefix + "_" + strconv.Itoa(p.seq)
+			if _, ok := p.ns.lookup(id); !ok && p.attrNS[id] == "" {
 				prefix = id
 				break
 			}
@@ -397,6 +418,89 @@ func (p *printer) popPrefix() {
 	}
 }
 
+// An nsScope records the name space declarations of the open elements
+// of a token stream.
+type nsScope struct {
+	decls []nsDecl // innermost last
+}
+
+// An nsDecl binds a prefix to a name space URL.
+// The default name space has the prefix "".
+type nsDecl struct {
+	prefix, url string
+	depth       int // depth of the declaring element
+}
+
+// nsDeclPrefix reports whether name is the name of a name space
+// declaration attribute, and returns the prefix it declares.
+func nsDeclPrefix(name Name) (string, bool) {
+	switch {
+	case name.Space == xmlnsPrefix:
+		return name.Local, true
+	case name.Space == "" && name.Local == xmlnsPrefix:
+		return "", true
+	case name.Space == "" && strings.HasPrefix(name.Local, xmlnsPrefix+":"):
+		return name.Local[len(xmlnsPrefix)+1:], true
+	}
+	return "", false
+}
+
+// push records the declarations among the attributes of an element
+// at the given depth.
+func (s *nsScope) push(attrs []Attr, depth int) {
+	for _, a := range attrs {
+		if prefix, ok := nsDeclPrefix(a.Name); ok && prefix != xmlPrefix {
+			s.decls = append(s.decls, nsDecl{prefix, a.Value, depth})
+		}
+	}
+}
+
+// pop removes the declarations of the element at the given depth.
+func (s *nsScope) pop(depth int) {
+	i := len(s.decls)
+	for i > 0 && s.decls[i-1].depth >= depth {
+		i--
+	}
+	s.decls = s.decls[:i]
+}
+
+// lookup returns the name space URL bound to prefix
+// and reports whether there is one.
+func (s *nsScope) lookup(prefix string) (string, bool) {
+	for i := len(s.decls) - 1; i >= 0; i-- {
+		if d := s.decls[i]; d.prefix == prefix {
+			return d.url, true
+		}
+	}
+	return "", false
+}
+
+// qualify returns the prefix to write for a name in the name space
+// space. Space is either a declared prefix, as in the tokens returned by
+// RawToken, or a name space URL, as in those returned by Token. Only
+// element names may use the default name space. If no prefix is
+// declared, qualify returns false.
+func (s *nsScope) qualify(space string, isElementName bool) (string, bool) {
+	if space == xmlPrefix || space == xmlURL {
+		return xmlPrefix, true
+	}
+	if url, ok := s.lookup(space); ok && url != "" {
+		return space, true
+	}
+	for i := len(s.decls) - 1; i >= 0; i-- {
+		d := s.decls[i]
+		if d.url != space || d.prefix == "" && !isElementName {
+			continue
+		}
+		// The prefix may have been bound to another
+		// name space by an inner element.
+		if url, _ := s.lookup(d.prefix); url == space {
+			return d.prefix, true
+		}
+	}
+	return "", false
+}
+
 var (
 	marshalerType     = reflect.TypeOf((*Marshaler)(nil)).Elem()
 	marshalerAttrType = reflect.TypeOf((*MarshalerAttr)(nil)).Elem()
@@ -697,6 +801,10 @@ func (p *printer) writeStart(start *StartElement) error {
 	p.tags = append(p.tags, start.Name)
 	p.markPrefix()
 
+	if p.preserve {
+		return p.writePreservedStart(start)
+	}
+
 	p.writeIndent(1)
 	p.WriteByte('<')
 	p.WriteString(start.Name.Local)
@@ -740,17 +848,86 @@ func (p *printer) writeEnd(name Name) error {
 		}
 		return fmt.Errorf("xml: end tag </%s> in namespace %s does not match start tag <%s> in namespace %s", name.Local, name.Space, top.Local, top.Space)
 	}
+	qname := name.Local
+	if p.preserve {
+		p.ns.pop(len(p.tags))
+		qname = p.qnames[len(p.qnames)-1]
+		p.qnames = p.qnames[:len(p.qnames)-1]
+	}
 	p.tags = p.tags[:len(p.tags)-1]
 
 	p.writeIndent(-1)
 	p.WriteByte('<')
 	p.WriteByte('/')
-	p.WriteString(name.Local)
+	p.WriteString(qname)
 	p.WriteByte('>')
 	p.popPrefix()
 	return nil
 }
 
+// writePreservedStart writes the given start element using the
+// name space prefixes declared in scope. The element has already been
+// pushed onto p.tags.
+func (p *printer) writePreservedStart(start *StartElement) error {
+	depth := len(p.tags)
+	p.ns.push(start.Attr, depth)
+
+	qname := start.Name.Local
+	declare := false
+	if start.Name.Space != "" {
+		if prefix, ok := p.ns.qualify(start.Name.Space, true); !ok {
+			// Declare the name space as the default one,
+			// for the children of the element to use.
+			declare = true
+			p.ns.decls = append(p.ns.decls, nsDecl{url: start.Name.Space, depth: depth})
+		} else if prefix != "" {
+			qname = prefix + ":" + qname
+		}
+	}
+	p.qnames = append(p.qnames, qname)
+
+	p.writeIndent(1)
+	p.WriteByte('<')
+	p.WriteString(qname)
+
+	if declare {
+		p.WriteString(` xmlns="`)
+		p.EscapeString(start.Name.Space)
+		p.WriteByte('"')
+	}
+
+	// Attributes
+	for _, attr := range start.Attr {
+		name := attr.Name
+		if name.Local == "" {
+			continue
+		}
+		p.WriteByte(' ')
+		if prefix, ok := nsDeclPrefix(name); ok {
+			p.WriteString(xmlnsPrefix)
+			if prefix != "" {
+				p.WriteByte(':')
+				p.WriteString(prefix)
+			}
+		} else {
+			if name.Space != "" {
+				prefix, ok := p.ns.qualify(name.Space, false)
+				if !ok {
+					prefix = p.createAttrPrefix(name.Space)
+				}
+				p.WriteString(prefix)
+				p.WriteByte(':')
+			}
+			p.WriteString(name.Local)
+		}
+		p.WriteString(`="`)
+		p.EscapeString(attr.Value)
+		p.WriteByte('"')
+	}
+	p.WriteByte('>')
+	return nil
+}
+
 func (p *printer) marshalSimple(typ reflect.Type, val reflect.Value) (string, []byte, error) {
 	switch val.Kind() {
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
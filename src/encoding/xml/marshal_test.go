@@ -2347,6 +2347,116 @@ loop:
 	}
 }
 
+func TestPreservePrefixes(t *testing.T) {
+	const doc = `<a:root xmlns:a="urn:a" xmlns="urn:d"><child a:attr="1" xml:lang="en"><a:x xmlns:a="urn:other" a:y="2"></a:x><b:x xmlns:b="urn:a"></b:x></child></a:root>`
+	for _, raw := range []bool{false, true} {
+		var buf bytes.Buffer
+		enc := NewEncoder(&buf)
+		enc.PreservePrefixes()
+		d := NewDecoder(strings.NewReader(doc))
+		for {
+			var tok Token
+			var err error
+			if raw {
+				tok, err = d.RawToken()
+			} else {
+				tok, err = d.Token()
+			}
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := enc.EncodeToken(tok); err != nil {
+				t.Fatalf("raw %v: %v", raw, err)
+			}
+		}
+		if err := enc.Flush(); err != nil {
+			t.Fatal(err)
+		}
+		if got := buf.String(); got != doc {
+			t.Errorf("raw %v:\ngot  %s\nwant %s", raw, got, doc)
+		}
+	}
+}
+
+func TestPreservePrefixesTokens(t *testing.T) {
+	tests := []struct {
+		desc string
+		toks []Token
+		want string
+	}{{
+		desc: "user-chosen prefix",
+		toks: []Token{
+			StartElement{Name{"urn:x", "a"}, []Attr{
+				{Name{"xmlns", "x"}, "urn:x"},
+				{Name{"urn:x", "b"}, "c"},
+			}},
+			StartElement{Name{"urn:x", "d"}, nil},
+			EndElement{Name{"urn:x", "d"}},
+			EndElement{Name{"urn:x", "a"}},
+		},
+		want: `<x:a xmlns:x="urn:x" x:b="c"><x:d></x:d></x:a>`,
+	}, {
+		desc: "undeclared name space",
+		toks: []Token{
+			StartElement{Name{"urn:x", "a"}, []Attr{
+				{Name{"urn:y", "b"}, "c"},
+			}},
+			StartElement{Name{"urn:x", "d"}, nil},
+			EndElement{Name{"urn:x", "d"}},
+			EndElement{Name{"urn:x", "a"}},
+		},
+		want: `<a xmlns="urn:x" xmlns:_="urn:y" _:b="c"><d></d></a>`,
+	}, {
+		desc: "generated prefix does not clash",
+		toks: []Token{
+			StartElement{Name{"", "a"}, []Attr{
+				{Name{"xmlns", "y"}, "urn:z"},
+				{Name{"http://example.com/y", "b"}, "c"},
+			}},
+			EndElement{Name{"", "a"}},
+		},
+		want: `<a xmlns:y="urn:z" xmlns:y_1="http://example.com/y" y_1:b="c"></a>`,
+	}}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		enc := NewEncoder(&buf)
+		enc.PreservePrefixes()
+		for _, tok := range tt.toks {
+			if err := enc.EncodeToken(tok); err != nil {
+				t.Fatalf("%s: %v", tt.desc, err)
+			}
+		}
+		if err := enc.Flush(); err != nil {
+			t.Fatal(err)
+		}
+		if got := buf.String(); got != tt.want {
+			t.Errorf("%s:\ngot  %s\nwant %s", tt.desc, got, tt.want)
+		}
+	}
+}
+
+func TestPreservePrefixesMarshal(t *testing.T) {
+	type Item struct {
+		XMLName Name   `xml:"urn:x item"`
+		Xmlns   string `xml:"xmlns:x,attr"`
+		ID      string `xml:"urn:x id,attr"`
+		Value   string `xml:"urn:x value"`
+	}
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	enc.PreservePrefixes()
+	if err := enc.Encode(Item{Xmlns: "urn:x", ID: "1", Value: "v"}); err != nil {
+		t.Fatal(err)
+	}
+	const want = `<x:item xmlns:x="urn:x" x:id="1"><x:value>v</x:value></x:item>`
+	if got := buf.String(); got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+}
+
 func TestProcInstEncodeToken(t *testing.T) {
 	var buf bytes.Buffer
 	enc := NewEncoder(&buf)
@@ -14,6 +14,7 @@ import (
 	"reflect"
 	"runtime"
 	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"testing"
@@ -464,6 +465,170 @@ func TestHandler(t *testing.T) {
 	}
 }
 
+func TestMetricsHandler(t *testing.T) {
+	RemoveAll()
+	NewInt("requests").Add(3)
+	NewFloat("load-avg").Set(0.5)
+	m := NewMap("codes")
+	m.Add("200", 7)
+	m.Set("note", new(String))
+	m.Add("a\"b", 1)
+	NewString("version").Set("1.0")
+	defer RemoveAll()
+
+	for _, test := range []struct {
+		accept   string
+		wantType string
+		want     []string
+		notWant  []string
+	}{
+		{
+			accept:   "",
+			wantType: prometheusType,
+			want: []string{
+				"# TYPE codes untyped\n" +
+					`codes{key="200"} 7` + "\n" +
+					`codes{key="a\"b"} 1` + "\n",
+				"# TYPE load_avg untyped\nload_avg 0.5\n",
+				"# TYPE requests untyped\nrequests 3\n",
+				"# TYPE go_gc_cycles_total counter\n" +
+					"# HELP go_gc_cycles_total Count of all completed GC cycles.\n" +
+					"go_gc_cycles_total ",
+				"# TYPE go_sched_goroutines gauge\n",
+				"# TYPE go_gc_pauses_seconds histogram\n",
+				`go_gc_pauses_seconds_bucket{le="+Inf"} `,
+				"\ngo_gc_pauses_seconds_count ",
+				"# TYPE go_sched_latencies_seconds histogram\n",
+				"\ngo_sched_latencies_seconds_count ",
+			},
+			notWant: []string{"version", "note", "# UNIT", "# EOF", "_sum "},
+		},
+		{
+			accept:   "application/openmetrics-text; version=1.0.0, text/plain;q=0.5",
+			wantType: openMetricsType,
+			want: []string{
+				"# TYPE requests unknown\nrequests 3\n",
+				"# TYPE go_gc_cycles counter\n" +
+					"# HELP go_gc_cycles Count of all completed GC cycles.\n" +
+					"go_gc_cycles_total ",
+				"# TYPE go_gc_heap_allocs_bytes counter\n" +
+					"# UNIT go_gc_heap_allocs_bytes bytes\n",
+				"\ngo_gc_heap_allocs_bytes_total ",
+				"# TYPE go_gc_heap_allocs_by_size_bytes histogram\n" +
+					"# UNIT go_gc_heap_allocs_by_size_bytes bytes\n",
+				`go_gc_heap_allocs_by_size_bytes_bucket{le="+Inf"} `,
+				"\ngo_gc_heap_allocs_by_size_bytes_count ",
+				"# TYPE go_sched_latencies_seconds gaugehistogram\n",
+				"\ngo_sched_latencies_seconds_gcount ",
+			},
+			notWant: []string{"version", "untyped", "_sum ", "_gsum "},
+		},
+		{
+			accept:   "application/openmetrics-text;q=0",
+			wantType: prometheusType,
+		},
+	} {
+		req := httptest.NewRequest("GET", "/metrics", nil)
+		if test.accept != "" {
+			req.Header.Set("Accept", test.accept)
+		}
+		rr := httptest.NewRecorder()
+		MetricsHandler().ServeHTTP(rr, req)
+		if got := rr.Header().Get("Content-Type"); got != test.wantType {
+			t.Errorf("Accept %q: Content-Type = %q, want %q", test.accept, got, test.wantType)
+		}
+		body := rr.Body.String()
+		if test.wantType == openMetricsType && !strings.HasSuffix(body, "\n# EOF\n") {
+			t.Errorf("Accept %q: body does not end with # EOF", test.accept)
+		}
+		for _, s := range test.want {
+			if !strings.Contains(body, s) {
+				t.Errorf("Accept %q: body does not contain %q", test.accept, s)
+			}
+		}
+		for _, s := range test.notWant {
+			if strings.Contains(body, s) {
+				t.Errorf("Accept %q: body contains %q", test.accept, s)
+			}
+		}
+	}
+}
+
+func TestRuntimeMetricName(t *testing.T) {
+	for _, test := range []struct {
+		in         string
+		counter    bool
+		name, unit string
+	}{
+		{"/gc/heap/allocs:bytes", true, "go_gc_heap_allocs_bytes", "bytes"},
+		{"/gc/heap/allocs:objects", true, "go_gc_heap_allocs", ""},
+		{"/gc/heap/allocs-by-size:bytes", false, "go_gc_heap_allocs_by_size_bytes", "bytes"},
+		{"/gc/heap/objects:objects", false, "go_gc_heap_objects", "objects"},
+		{"/gc/cycles/total:gc-cycles", true, "go_gc_cycles", ""},
+		{"/memory/classes/total:bytes", false, "go_memory_classes_total_bytes", "bytes"},
+		{"/cpu/classes/gc/total:cpu-seconds", true, "go_cpu_classes_gc_cpu_seconds", "cpu_seconds"},
+		{"/gc/gogc:percent", false, "go_gc_gogc_percent", "percent"},
+		{"/godebug/non-default:*", false, "go_godebug_non_default", ""},
+	} {
+		name, unit := runtimeMetricName(test.in, test.counter)
+		if name != test.name || unit != test.unit {
+			t.Errorf("runtimeMetricName(%q, %v) = %q, %q, want %q, %q", test.in, test.counter, name, unit, test.name, test.unit)
+		}
+	}
+}
+
+func TestMetricsHandlerNames(t *testing.T) {
+	RemoveAll()
+	req := httptest.NewRequest("GET", "/metrics", nil)
+	rr := httptest.NewRecorder()
+	MetricsHandler().ServeHTTP(rr, req)
+
+	types := make(map[string]string)
+	samples := make(map[string]bool)
+	for _, line := range strings.Split(rr.Body.String(), "\n") {
+		if f := strings.Fields(line); len(f) == 4 && f[0] == "#" && f[1] == "TYPE" {
+			types[f[2]] = f[3]
+		} else if len(f) > 0 && f[0] != "#" {
+			name := f[0]
+			if i := strings.IndexByte(name, '{'); i >= 0 {
+				name = name[:i]
+			}
+			samples[name] = true
+		}
+	}
+	for _, test := range []struct {
+		family, typ string
+		samples     []string
+	}{
+		{"go_gc_cycles_automatic_total", "counter", []string{"go_gc_cycles_automatic_total"}},
+		{"go_gc_cycles_forced_total", "counter", []string{"go_gc_cycles_forced_total"}},
+		{"go_gc_cycles_total", "counter", []string{"go_gc_cycles_total"}},
+		{"go_gc_heap_allocs_bytes_total", "counter", []string{"go_gc_heap_allocs_bytes_total"}},
+		{"go_gc_heap_allocs_total", "counter", []string{"go_gc_heap_allocs_total"}},
+		{"go_gc_heap_tiny_allocs_total", "counter", []string{"go_gc_heap_tiny_allocs_total"}},
+		{"go_gc_heap_goal_bytes", "gauge", []string{"go_gc_heap_goal_bytes"}},
+		{"go_gc_heap_objects", "gauge", []string{"go_gc_heap_objects"}},
+		{"go_memory_classes_total_bytes", "gauge", []string{"go_memory_classes_total_bytes"}},
+		{"go_sched_goroutines", "gauge", []string{"go_sched_goroutines"}},
+		{"go_gc_pauses_seconds", "histogram", []string{"go_gc_pauses_seconds_bucket", "go_gc_pauses_seconds_count"}},
+		{"go_gc_heap_frees_by_size_bytes", "histogram", []string{"go_gc_heap_frees_by_size_bytes_bucket", "go_gc_heap_frees_by_size_bytes_count"}},
+	} {
+		if got := types[test.family]; got != test.typ {
+			t.Errorf("family %s has type %q, want %q", test.family, got, test.typ)
+		}
+		for _, name := range test.samples {
+			if !samples[name] {
+				t.Errorf("no sample named %s", name)
+			}
+		}
+	}
+	for name := range samples {
+		if strings.Count(name, "_total") > 1 {
+			t.Errorf("sample name %s repeats _total", name)
+		}
+	}
+}
+
 func BenchmarkRealworldExpvarUsage(b *testing.B) {
 	var (
 		bytesSent Int
@@ -0,0 +1,327 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package expvar
+
+import (
+	"bufio"
+	"math"
+	"net/http"
+	"runtime/metrics"
+	"strconv"
+	"strings"
+)
+
+const (
+	openMetricsType = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+	prometheusType  = "text/plain; version=0.0.4; charset=utf-8"
+)
+
+// MetricsHandler returns an HTTP handler that serves the exported
+// variables and the metrics of package runtime/metrics in the Prometheus
+// text exposition format, version 0.0.4. If the Accept header of the
+// request accepts "application/openmetrics-text", the OpenMetrics 1.0.0
+// text format is served instead.
+//
+// Int and Float variables are exposed as metrics of unknown type. A Map
+// variable is exposed as a single metric family with one sample, labeled
+// by "key", for each of its Int and Float entries. Names are turned into
+// valid metric names by replacing invalid characters with underscores.
+// Other variables are omitted.
+//
+// Runtime metrics are named after their path and unit with a "go" prefix,
+// so that /gc/heap/goal:bytes becomes go_gc_heap_goal_bytes. The unit is
+// not repeated if the path already ends with it. Cumulative metrics are
+// exposed as counters, whose samples carry a single "_total" suffix: a
+// trailing "total" path element is dropped, as is any unit other than
+// bytes or seconds, so that /gc/cycles/total:gc-cycles becomes
+// go_gc_cycles_total and /gc/heap/allocs:bytes becomes
+// go_gc_heap_allocs_bytes_total. Float64Histogram metrics are exposed as
+// classic histograms, with the runtime's own bucket boundaries and a _count
+// sample, and other metrics as gauges. The histograms have no _sum sample,
+// since the runtime does not record the sum of their observations. Neither
+// text format can carry Prometheus native histograms, so none are served.
+//
+// Unlike Handler, MetricsHandler is not installed at any path by default.
+func MetricsHandler() http.Handler {
+	return http.HandlerFunc(metricsHandler)
+}
+
+func metricsHandler(w http.ResponseWriter, r *http.Request) {
+	m := &metricsWriter{
+		openMetrics: acceptsOpenMetrics(r.Header.Values("Accept")),
+		seen:        make(map[string]bool),
+	}
+	w.Header().Add("Vary", "Accept")
+	if m.openMetrics {
+		w.Header().Set("Content-Type", openMetricsType)
+	} else {
+		w.Header().Set("Content-Type", prometheusType)
+	}
+	bw := bufio.NewWriter(w)
+	m.w = bw
+	Do(m.writeVar)
+	m.writeRuntimeMetrics()
+	if m.openMetrics {
+		bw.WriteString("# EOF\n")
+	}
+	bw.Flush()
+}
+
+// acceptsOpenMetrics reports whether the Accept header values accept
+// the OpenMetrics text format.
+func acceptsOpenMetrics(accept []string) bool {
+	for _, v := range accept {
+		for _, part := range strings.Split(v, ",") {
+			params := strings.Split(part, ";")
+			if strings.TrimSpace(params[0]) != "application/openmetrics-text" {
+				continue
+			}
+			q := 1.0
+			for _, p := range params[1:] {
+				p = strings.TrimSpace(p)
+				if strings.HasPrefix(p, "q=") {
+					q, _ = strconv.ParseFloat(p[len("q="):], 64)
+				}
+			}
+			if q > 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// A metricsWriter writes metric families in the Prometheus or
+// OpenMetrics text format.
+type metricsWriter struct {
+	w           *bufio.Writer
+	openMetrics bool
+	seen        map[string]bool // names of the families written so far
+}
+
+// family writes the metadata of a metric family. It reports false
+// if a family with the same name has already been written, in which
+// case its samples must be omitted.
+func (m *metricsWriter) family(name, typ, unit, help string) bool {
+	if m.seen[name] {
+		return false
+	}
+	m.seen[name] = true
+
+	typeName := name
+	if !m.openMetrics {
+		// The Prometheus format has no unknown or gauge histogram
+		// types, and names counters after their samples.
+		switch typ {
+		case "unknown":
+			typ = "untyped"
+		case "gaugehistogram":
+			typ = "histogram"
+		case "counter":
+			typeName += "_total"
+		}
+	}
+	m.w.WriteString("# TYPE " + typeName + " " + typ + "\n")
+	if m.openMetrics && unit != "" {
+		m.w.WriteString("# UNIT " + name + " " + unit + "\n")
+	}
+	if help != "" {
+		m.w.WriteString("# HELP " + typeName + " " + m.escape(help, false) + "\n")
+	}
+	return true
+}
+
+// sample writes a single sample line. labels is either empty
+// or a list of label pairs such as `key="value"`.
+func (m *metricsWriter) sample(name, labels, value string) {
+	m.w.WriteString(name)
+	if labels != "" {
+		m.w.WriteString("{" + labels + "}")
+	}
+	m.w.WriteString(" " + value + "\n")
+}
+
+// escape escapes s for use in a HELP line or, if label is true,
+// in a label value.
+func (m *metricsWriter) escape(s string, label bool) string {
+	quote := label || m.openMetrics
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '\\':
+			b.WriteString(`\\`)
+		case c == '\n':
+			b.WriteString(`\n`)
+		case c == '"' && quote:
+			b.WriteString(`\"`)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// writeVar writes the metric family of an exported variable.
+func (m *metricsWriter) writeVar(kv KeyValue) {
+	name := metricName(kv.Key)
+	if name == "" {
+		return
+	}
+	if value, ok := numericValue(kv.Value); ok {
+		if m.family(name, "unknown", "", "") {
+			m.sample(name, "", value)
+		}
+		return
+	}
+	v, ok := kv.Value.(*Map)
+	if !ok {
+		return
+	}
+	var samples []string
+	v.Do(func(kv KeyValue) {
+		if value, ok := numericValue(kv.Value); ok {
+			samples = append(samples, `key="`+m.escape(kv.Key, true)+`"`, value)
+		}
+	})
+	if len(samples) == 0 || !m.family(name, "unknown", "", "") {
+		return
+	}
+	for i := 0; i < len(samples); i += 2 {
+		m.sample(name, samples[i], samples[i+1])
+	}
+}
+
+// numericValue returns the value of an Int or Float variable.
+func numericValue(v Var) (string, bool) {
+	switch v := v.(type) {
+	case *Int:
+		return v.String(), true
+	case *Float:
+		return formatFloat(v.Value()), true
+	}
+	return "", false
+}
+
+// writeRuntimeMetrics writes a metric family for each supported
+// metric of package runtime/metrics.
+func (m *metricsWriter) writeRuntimeMetrics() {
+	descs := metrics.All()
+	samples := make([]metrics.Sample, len(descs))
+	for i := range samples {
+		samples[i].Name = descs[i].Name
+	}
+	metrics.Read(samples)
+
+	for i, s := range samples {
+		d := &descs[i]
+		counter := d.Cumulative && s.Value.Kind() != metrics.KindFloat64Histogram
+		name, unit := runtimeMetricName(d.Name, counter)
+		switch s.Value.Kind() {
+		case metrics.KindUint64:
+			m.writeRuntimeValue(name, unit, d, strconv.FormatUint(s.Value.Uint64(), 10))
+		case metrics.KindFloat64:
+			m.writeRuntimeValue(name, unit, d, formatFloat(s.Value.Float64()))
+		case metrics.KindFloat64Histogram:
+			typ := "histogram"
+			if !d.Cumulative {
+				typ = "gaugehistogram"
+			}
+			if m.family(name, typ, unit, d.Description) {
+				m.writeHistogram(name, s.Value.Float64Histogram(), d.Cumulative)
+			}
+		}
+	}
+}
+
+func (m *metricsWriter) writeRuntimeValue(name, unit string, d *metrics.Description, value string) {
+	typ := "gauge"
+	if d.Cumulative {
+		typ = "counter"
+	}
+	if m.family(name, typ, unit, d.Description) {
+		if d.Cumulative {
+			name += "_total"
+		}
+		m.sample(name, "", value)
+	}
+}
+
+// writeHistogram writes the samples of a histogram. The upper bound of
+// each bucket of h is used as its "le" label, even though runtime/metrics
+// buckets exclude their upper bound.
+func (m *metricsWriter) writeHistogram(name string, h *metrics.Float64Histogram, cumulative bool) {
+	var count uint64
+	for i, n := range h.Counts {
+		count += n
+		le := h.Buckets[i+1]
+		if math.IsInf(le, +1) {
+			// Written below.
+			continue
+		}
+		m.sample(name+"_bucket", `le="`+formatFloat(le)+`"`, strconv.FormatUint(count, 10))
+	}
+	// There must be a +Inf bucket holding all observations.
+	m.sample(name+"_bucket", `le="+Inf"`, strconv.FormatUint(count, 10))
+	if m.openMetrics && !cumulative {
+		m.sample(name+"_gcount", "", strconv.FormatUint(count, 10))
+	} else {
+		m.sample(name+"_count", "", strconv.FormatUint(count, 10))
+	}
+}
+
+// runtimeMetricName returns the metric family name and the unit of the
+// runtime/metrics metric named s, which is exposed as a counter if
+// counter is set.
+func runtimeMetricName(s string, counter bool) (name, unit string) {
+	path := s
+	if i := strings.LastIndexByte(s, ':'); i >= 0 {
+		path, unit = s[:i], s[i+1:]
+	}
+	name = metricName("go" + strings.ReplaceAll(path, "/", "_"))
+	if unit == "*" {
+		unit = ""
+	}
+	unit = metricName(unit)
+	if counter {
+		// Counter samples are suffixed with _total,
+		// which the name must not repeat.
+		name = strings.TrimSuffix(name, "_total")
+		if unit != "bytes" && unit != "seconds" && !strings.HasSuffix(unit, "_seconds") {
+			unit = ""
+		}
+	}
+	if unit != "" && !strings.HasSuffix(name, "_"+unit) {
+		name += "_" + unit
+	}
+	return name, unit
+}
+
+// metricName turns s into a valid metric name by replacing invalid
+// characters with underscores.
+func metricName(s string) string {
+	if s == "" {
+		return ""
+	}
+	b := []byte(s)
+	for i, c := range b {
+		if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || c == '_' || c == ':' || '0' <= c && c <= '9' && i > 0) {
+			b[i] = '_'
+		}
+	}
+	return string(b)
+}
+
+// formatFloat formats f as a sample value or label value.
+func formatFloat(f float64) string {
+	switch {
+	case math.IsInf(f, +1):
+		return "+Inf"
+	case math.IsInf(f, -1):
+		return "-Inf"
+	case math.IsNaN(f):
+		return "NaN"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
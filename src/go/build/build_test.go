@@ -735,3 +735,24 @@ func TestCgoImportsIgnored(t *testing.T) {
 		}
 	}
 }
+
+func TestDirectives(t *testing.T) {
+	p, err := ImportDir("testdata/directives", 0)
+	if err != nil {
+		t.Fatalf("could not import testdata: %v", err)
+	}
+
+	check := func(name string, list []Directive, want string) {
+		t.Helper()
+		var got []string
+		for _, d := range list {
+			got = append(got, d.Text+" "+filepath.ToSlash(d.Pos.String()))
+		}
+		if s := strings.Join(got, "; "); s != want {
+			t.Errorf("%s = %q, want %q", name, s, want)
+		}
+	}
+	check("Directives", p.Directives, "//go:debug x=y1 testdata/directives/a.go:5:1")
+	check("TestDirectives", p.TestDirectives, "//go:debug x=y2 testdata/directives/a_test.go:1:1")
+	check("XTestDirectives", p.XTestDirectives, "//go:debug x=y3 testdata/directives/b_test.go:1:1")
+}
@@ -72,7 +72,7 @@ var depsRules = `
 	NONE
 	< container/list, container/ring,
 	  internal/cfg, internal/cpu, internal/goexperiment,
-	  internal/goversion, internal/nettrace,
+	  internal/godebugs, internal/goversion, internal/nettrace,
 	  unicode/utf8, unicode/utf16, unicode,
 	  unsafe;
 
@@ -81,7 +81,8 @@ var depsRules = `
 	< internal/abi;
 
 	# RUNTIME is the core runtime group of packages, all of them very light-weight.
-	internal/abi, internal/cpu, internal/goexperiment, unsafe
+	internal/abi, internal/cpu, internal/goexperiment,
+	internal/godebugs, unsafe
 	< internal/bytealg
 	< internal/itoa
 	< internal/unsafeheader
@@ -120,6 +121,9 @@ var depsRules = `
 	< internal/syscall/execenv
 	< SYSCALL;
 
+	SYSCALL
+	< internal/godebug;
+
 	# TIME is SYSCALL plus the core packages about time, including context.
 	SYSCALL
 	< time/tzdata
@@ -177,7 +181,7 @@ var depsRules = `
 	< path/filepath
 	< io/ioutil, os/exec;
 
-	io/ioutil, os/exec, os/signal
+	internal/godebug, io/ioutil, os/exec, os/signal
 	< OS;
 
 	reflect !< OS;
@@ -357,6 +361,7 @@ var depsRules = `
 	golang.org/x/net/dns/dnsmessage,
 	golang.org/x/net/lif,
 	golang.org/x/net/route,
+	internal/godebug,
 	internal/nettrace,
 	internal/poll,
 	internal/singleflight,
@@ -468,11 +473,14 @@ var depsRules = `
 
 	# HTTP-aware packages
 
-	encoding/json, net/http
+	encoding/json, net/http, runtime/metrics
 	< expvar;
 
 	net/http, net/http/internal/ascii
-	< net/http/cookiejar, net/http/httputil;
+	< net/http/httputil;
+
+	encoding/json, net/http, net/http/internal/ascii
+	< net/http/cookiejar;
 
 	net/http, flag
 	< net/http/httptest;
@@ -494,7 +502,7 @@ var depsRules = `
 	# RPC
 	encoding/gob, encoding/json, go/token, html/template, net/http
 	< net/rpc
-	< net/rpc/jsonrpc;
+	< net/rpc/jsonrpc, net/rpc/jsonrpc2;
 
 	# System Information
 	internal/cpu, sync
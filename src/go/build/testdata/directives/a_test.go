@@ -0,0 +1,3 @@
+//go:debug x=y2
+
+package p
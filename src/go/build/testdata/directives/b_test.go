@@ -0,0 +1,3 @@
+//go:debug x=y3
+
+package p_test
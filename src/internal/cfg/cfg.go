@@ -37,6 +37,7 @@ const KnownEnv = `
 	GOARM
 	GOBIN
 	GOCACHE
+	GOCACHEPROG
 	GOENV
 	GOEXE
 	GOEXPERIMENT
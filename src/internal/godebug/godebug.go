@@ -0,0 +1,185 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package godebug makes the settings in the $GODEBUG environment variable
+// available to other packages. These settings are often used for compatibility
+// tweaks, when we need to change a default behavior but want to let users
+// opt back in to the original. For example GODEBUG=http2server=0 disables
+// HTTP/2 support in the net/http server.
+//
+// In typical usage, code should declare a Setting as a global
+// and then call Value each time the current setting value is needed:
+//
+//	var http2server = godebug.New("http2server")
+//
+//	func ServeConn(c net.Conn) {
+//		if http2server.Value() == "0" {
+//			disallow HTTP/2
+//			...
+//		}
+//		...
+//	}
+//
+// Each time a non-default setting causes a change in program behavior,
+// code should call IncNonDefault to increment a counter that can
+// be reported by runtime/metrics.
+//
+// Every setting must be listed in the table in internal/godebugs.
+//
+// The default value of a setting is the empty string, unless the main
+// module's go.mod file or the main package's //go:debug directives say
+// otherwise: in that case the go command records the default in the
+// binary, and $GODEBUG overrides it.
+package godebug
+
+import (
+	"internal/godebugs"
+	"sync"
+	"sync/atomic"
+)
+
+// A Setting is a single setting in the $GODEBUG environment variable.
+type Setting struct {
+	name string
+	once sync.Once
+	*setting
+}
+
+type setting struct {
+	info           *godebugs.Info
+	nonDefaultOnce sync.Once
+	nonDefault     uint64 // accessed atomically
+}
+
+// New returns a new Setting for the $GODEBUG setting with the given name.
+func New(name string) *Setting {
+	return &Setting{name: name}
+}
+
+// Name returns the name of the setting.
+func (s *Setting) Name() string {
+	return s.name
+}
+
+// String returns a printable form for the setting: name=value.
+func (s *Setting) String() string {
+	return s.Name() + "=" + s.Value()
+}
+
+// IncNonDefault increments the non-default behavior counter
+// associated with the given setting.
+// This counter is exposed in the runtime/metrics value
+// /godebug/non-default-behavior/<name>:events.
+func (s *Setting) IncNonDefault() {
+	s.init()
+	s.nonDefaultOnce.Do(s.register)
+	atomic.AddUint64(&s.nonDefault, 1)
+}
+
+func (s *Setting) register() {
+	if s.info.Opaque {
+		panic("godebug: unexpected IncNonDefault of " + s.name)
+	}
+	registerMetric("/godebug/non-default-behavior/"+s.name+":events", func() uint64 {
+		return atomic.LoadUint64(&s.nonDefault)
+	})
+}
+
+// init looks up the shared setting for s on first use.
+func (s *Setting) init() {
+	s.once.Do(func() {
+		s.setting = lookup(s.Name())
+		if s.info == nil {
+			panic("godebug: use of name not listed in godebugs.All: " + s.name)
+		}
+	})
+}
+
+// Value returns the current value for the GODEBUG setting s.
+//
+// Value maintains an internal cache that is synchronized
+// with changes to the $GODEBUG environment variable,
+// making Value efficient to call as frequently as needed.
+// Clients should therefore typically not attempt their own
+// caching of Value's result.
+func (s *Setting) Value() string {
+	s.init()
+	m, _ := current.Load().(map[string]string)
+	return m[s.name]
+}
+
+// cache maps setting names to their shared *setting,
+// so that all Settings with the same name share a counter.
+var cache sync.Map // name string -> *setting
+
+func lookup(name string) *setting {
+	if v, ok := cache.Load(name); ok {
+		return v.(*setting)
+	}
+	s := &setting{info: godebugs.Lookup(name)}
+	v, _ := cache.LoadOrStore(name, s)
+	return v.(*setting)
+}
+
+// registerMetric is provided by package runtime.
+// It forwards registrations to runtime/metrics.
+func registerMetric(name string, read func() uint64)
+
+// setUpdate is provided by package runtime.
+// It calls update(def, env), where def is the default GODEBUG setting
+// and env is the current value of the $GODEBUG environment variable.
+// After that first call, the runtime calls update(def, env)
+// again each time the environment variable changes
+// (due to use of os.Setenv, for example).
+func setUpdate(update func(string, string))
+
+func init() {
+	setUpdate(update)
+}
+
+// current holds the settings in effect, as a map[string]string
+// from setting name to value.
+var current atomic.Value
+
+// updateMu serializes calls to update.
+var updateMu sync.Mutex
+
+// update records the settings from def, the default GODEBUG setting,
+// overridden by env, the current $GODEBUG.
+func update(def, env string) {
+	updateMu.Lock()
+	defer updateMu.Unlock()
+
+	m := make(map[string]string)
+	parse(m, def)
+	parse(m, env)
+	current.Store(m)
+}
+
+// parse parses the GODEBUG setting string s,
+// which has the form k=v,k2=v2,k3=v3, and adds the settings to m.
+// Later settings override earlier ones.
+// Fields that are not of the form k=v are ignored.
+func parse(m map[string]string, s string) {
+	for s != "" {
+		field := s
+		if i := index(s, ','); i >= 0 {
+			field, s = s[:i], s[i+1:]
+		} else {
+			s = ""
+		}
+		if i := index(field, '='); i >= 0 {
+			m[field[:i]] = field[i+1:]
+		}
+	}
+}
+
+func index(s string, c byte) int {
+	for i := 0; i < len(s); i++ {
+		if s[i] == c {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,91 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package godebug_test
+
+import (
+	. "internal/godebug"
+	"os"
+	"runtime/metrics"
+	"testing"
+)
+
+func TestGet(t *testing.T) {
+	defer os.Setenv("GODEBUG", os.Getenv("GODEBUG"))
+	netdns := New("netdns")
+	tests := []struct {
+		godebug string
+		want    string
+	}{
+		{"", ""},
+		{"netdns=", ""},
+		{"netdns=go", "go"},
+		{"netdns=go,", "go"},
+		{"netdns,netdns=go,", "go"},
+		{"netdns1=go,netdns=go,", "go"},
+		{"netdns=cgo,netdns=go,", "go"},
+		{"netdns", ""},
+		{",netdns", ""},
+		{"netdns=go,baz", "go"},
+		{"foo=bar,baz", ""},
+	}
+	for _, tt := range tests {
+		os.Setenv("GODEBUG", tt.godebug)
+		if got := netdns.Value(); got != tt.want {
+			t.Errorf("for %q, Value() = %q; want %q", tt.godebug, got, tt.want)
+		}
+	}
+}
+
+func TestValueAllocs(t *testing.T) {
+	defer os.Setenv("GODEBUG", os.Getenv("GODEBUG"))
+	os.Setenv("GODEBUG", "netdns=go")
+	netdns := New("netdns")
+	if n := testing.AllocsPerRun(100, func() { netdns.Value() }); n != 0 {
+		t.Errorf("Value allocated %v times, want 0", n)
+	}
+}
+
+func TestUnknown(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Value of unknown setting did not panic")
+		}
+	}()
+	New("nosuchsetting").Value()
+}
+
+func TestMetrics(t *testing.T) {
+	defer os.Setenv("GODEBUG", os.Getenv("GODEBUG"))
+	const name = "http2server"
+
+	s := New(name)
+	os.Setenv("GODEBUG", name+"=0")
+	if v := s.Value(); v != "0" {
+		t.Fatalf("Value() = %q, want %q", v, "0")
+	}
+
+	sample := []metrics.Sample{{Name: "/godebug/non-default-behavior/" + name + ":events"}}
+	read := func() uint64 {
+		t.Helper()
+		metrics.Read(sample)
+		if sample[0].Value.Kind() != metrics.KindUint64 {
+			t.Fatalf("%s has kind %v, want uint64", sample[0].Name, sample[0].Value.Kind())
+		}
+		return sample[0].Value.Uint64()
+	}
+	if n := read(); n != 0 {
+		t.Errorf("%s = %d before IncNonDefault, want 0", sample[0].Name, n)
+	}
+	for i := 0; i < 3; i++ {
+		s.IncNonDefault()
+	}
+	// A second Setting with the same name shares the counter,
+	// and does not need Value to be called before IncNonDefault.
+	s2 := New(name)
+	s2.IncNonDefault()
+	if n := read(); n != 4 {
+		t.Errorf("%s = %d, want 4", sample[0].Name, n)
+	}
+}
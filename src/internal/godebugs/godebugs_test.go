@@ -0,0 +1,30 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package godebugs_test
+
+import (
+	"internal/godebugs"
+	"testing"
+)
+
+func TestAll(t *testing.T) {
+	for i, info := range godebugs.All {
+		if i > 0 && godebugs.All[i-1].Name >= info.Name {
+			t.Errorf("godebugs.All is not sorted: %s before %s", godebugs.All[i-1].Name, info.Name)
+		}
+		if info.Package == "" {
+			t.Errorf("Name=%s missing Package", info.Name)
+		}
+		if info.Changed != 0 && info.Old == "" {
+			t.Errorf("Name=%s has Changed=%d but no Old", info.Name, info.Changed)
+		}
+		if got := godebugs.Lookup(info.Name); got == nil || got.Name != info.Name {
+			t.Errorf("Lookup(%q) = %v", info.Name, got)
+		}
+	}
+	if godebugs.Lookup("nosuchsetting") != nil {
+		t.Errorf("Lookup(%q) != nil", "nosuchsetting")
+	}
+}
@@ -0,0 +1,51 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package godebugs provides a table of known GODEBUG settings,
+// for use by a variety of other packages, including internal/godebug,
+// runtime, runtime/metrics, and cmd/go/internal/load.
+package godebugs
+
+// An Info describes a single known GODEBUG setting.
+type Info struct {
+	Name    string // name of the setting ("http2client")
+	Package string // package that uses the setting ("net/http")
+	Changed int    // minor version when default changed, if any; 18 means Go 1.18
+	Old     string // value that restores behavior prior to Changed
+	Opaque  bool   // setting does not export information to runtime/metrics using [internal/godebug.Setting.IncNonDefault]
+}
+
+// All is the table of known settings, sorted by Name.
+//
+// Note: After adding a non-Opaque entry to this table, add the matching
+// /godebug/non-default-behavior metric to runtime/metrics/description.go
+// and runtime/metrics/doc.go.
+// (Otherwise the runtime/metrics test will fail.)
+var All = []Info{
+	{Name: "http2client", Package: "net/http"},
+	{Name: "http2debug", Package: "net/http", Opaque: true},
+	{Name: "http2server", Package: "net/http"},
+	{Name: "netdns", Package: "net", Opaque: true},
+	{Name: "x509roots", Package: "crypto/x509", Opaque: true},
+}
+
+// Lookup returns the Info with the given name.
+func Lookup(name string) *Info {
+	// binary search, avoiding import of sort.
+	lo := 0
+	hi := len(All)
+	for lo < hi {
+		m := int(uint(lo+hi) >> 1)
+		mid := All[m].Name
+		if name == mid {
+			return &All[m]
+		}
+		if name < mid {
+			hi = m
+		} else {
+			lo = m + 1
+		}
+	}
+	return nil
+}
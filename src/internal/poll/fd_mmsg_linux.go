@@ -0,0 +1,71 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package poll
+
+import (
+	"internal/syscall/unix"
+	"syscall"
+)
+
+// ReadMsgs wraps the recvmmsg network call.
+// It receives at least one message, blocking if necessary,
+// and returns the number of message headers filled in.
+func (fd *FD) ReadMsgs(msgs []unix.Mmsghdr, flags int) (int, error) {
+	if len(msgs) == 0 {
+		return 0, nil
+	}
+	if err := fd.readLock(); err != nil {
+		return 0, err
+	}
+	defer fd.readUnlock()
+	if err := fd.pd.prepareRead(fd.isFile); err != nil {
+		return 0, err
+	}
+	for {
+		n, err := unix.Recvmmsg(fd.Sysfd, msgs, flags)
+		if err != nil {
+			if err == syscall.EINTR {
+				continue
+			}
+			if err == syscall.EAGAIN && fd.pd.pollable() {
+				if err = fd.pd.waitRead(fd.isFile); err == nil {
+					continue
+				}
+			}
+		}
+		return n, err
+	}
+}
+
+// WriteMsgs wraps the sendmmsg network call.
+// Unlike a single sendmmsg call, it keeps writing until
+// all the messages have been sent or an error occurs.
+// It returns the number of messages sent.
+func (fd *FD) WriteMsgs(msgs []unix.Mmsghdr, flags int) (int, error) {
+	if err := fd.writeLock(); err != nil {
+		return 0, err
+	}
+	defer fd.writeUnlock()
+	if err := fd.pd.prepareWrite(fd.isFile); err != nil {
+		return 0, err
+	}
+	var nn int
+	for nn < len(msgs) {
+		n, err := unix.Sendmmsg(fd.Sysfd, msgs[nn:], flags)
+		if err == syscall.EINTR {
+			continue
+		}
+		if err == syscall.EAGAIN && fd.pd.pollable() {
+			if err = fd.pd.waitWrite(fd.isFile); err == nil {
+				continue
+			}
+		}
+		if err != nil {
+			return nn, err
+		}
+		nn += n
+	}
+	return nn, nil
+}
@@ -0,0 +1,48 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package unix
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// UDP socket options and control message types for generic
+// segmentation offload (GSO) and generic receive offload (GRO).
+const (
+	SOL_UDP     = 0x11
+	UDP_SEGMENT = 0x67
+	UDP_GRO     = 0x68
+)
+
+// Mmsghdr is a message header for the recvmmsg and sendmmsg
+// system calls. Len is set by the kernel to the number of bytes
+// transferred for the message.
+type Mmsghdr struct {
+	Hdr syscall.Msghdr
+	Len uint32
+}
+
+func Recvmmsg(fd int, msgs []Mmsghdr, flags int) (int, error) {
+	if len(msgs) == 0 {
+		return 0, nil
+	}
+	n, _, errno := syscall.Syscall6(recvmmsgTrap, uintptr(fd), uintptr(unsafe.Pointer(&msgs[0])), uintptr(len(msgs)), uintptr(flags), 0, 0)
+	if errno != 0 {
+		return 0, errno
+	}
+	return int(n), nil
+}
+
+func Sendmmsg(fd int, msgs []Mmsghdr, flags int) (int, error) {
+	if len(msgs) == 0 {
+		return 0, nil
+	}
+	n, _, errno := syscall.Syscall6(sendmmsgTrap, uintptr(fd), uintptr(unsafe.Pointer(&msgs[0])), uintptr(len(msgs)), uintptr(flags), 0, 0)
+	if errno != 0 {
+		return 0, errno
+	}
+	return int(n), nil
+}
@@ -0,0 +1,13 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build (linux && 386) || (linux && arm) || (linux && mips) || (linux && mipsle)
+// +build linux,386 linux,arm linux,mips linux,mipsle
+
+package unix
+
+// SetIovlen sets the number of I/O vectors in the message header.
+func (h *Mmsghdr) SetIovlen(length int) {
+	h.Hdr.Iovlen = uint32(length)
+}
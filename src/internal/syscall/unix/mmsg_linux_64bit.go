@@ -0,0 +1,14 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build linux && (amd64 || arm64 || mips64 || mips64le || ppc64 || ppc64le || riscv64 || s390x)
+// +build linux
+// +build amd64 arm64 mips64 mips64le ppc64 ppc64le riscv64 s390x
+
+package unix
+
+// SetIovlen sets the number of I/O vectors in the message header.
+func (h *Mmsghdr) SetIovlen(length int) {
+	h.Hdr.Iovlen = uint64(length)
+}
@@ -5,6 +5,10 @@
 package unix
 
 const (
-	getrandomTrap     uintptr = 355
-	copyFileRangeTrap uintptr = 377
+	getrandomTrap       uintptr = 355
+	copyFileRangeTrap   uintptr = 377
+	pidfdSendSignalTrap uintptr = 424
+	pidfdOpenTrap       uintptr = 434
+	recvmmsgTrap        uintptr = 337
+	sendmmsgTrap        uintptr = 345
 )
@@ -5,6 +5,10 @@
 package unix
 
 const (
-	getrandomTrap     uintptr = 318
-	copyFileRangeTrap uintptr = 326
+	getrandomTrap       uintptr = 318
+	copyFileRangeTrap   uintptr = 326
+	pidfdSendSignalTrap uintptr = 424
+	pidfdOpenTrap       uintptr = 434
+	recvmmsgTrap        uintptr = 299
+	sendmmsgTrap        uintptr = 307
 )
@@ -8,6 +8,10 @@
 package unix
 
 const (
-	getrandomTrap     uintptr = 5313
-	copyFileRangeTrap uintptr = 5320
+	getrandomTrap       uintptr = 5313
+	copyFileRangeTrap   uintptr = 5320
+	pidfdSendSignalTrap uintptr = 5424
+	pidfdOpenTrap       uintptr = 5434
+	recvmmsgTrap        uintptr = 5294
+	sendmmsgTrap        uintptr = 5302
 )
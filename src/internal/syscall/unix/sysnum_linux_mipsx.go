@@ -8,6 +8,10 @@
 package unix
 
 const (
-	getrandomTrap     uintptr = 4353
-	copyFileRangeTrap uintptr = 4360
+	getrandomTrap       uintptr = 4353
+	copyFileRangeTrap   uintptr = 4360
+	pidfdSendSignalTrap uintptr = 4424
+	pidfdOpenTrap       uintptr = 4434
+	recvmmsgTrap        uintptr = 4335
+	sendmmsgTrap        uintptr = 4343
 )
@@ -0,0 +1,22 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package net
+
+import (
+	"internal/syscall/unix"
+	"runtime"
+)
+
+func (fd *netFD) readMsgs(msgs []unix.Mmsghdr, flags int) (n int, err error) {
+	n, err = fd.pfd.ReadMsgs(msgs, flags)
+	runtime.KeepAlive(fd)
+	return n, wrapSyscallError("recvmmsg", err)
+}
+
+func (fd *netFD) writeMsgs(msgs []unix.Mmsghdr, flags int) (n int, err error) {
+	n, err = fd.pfd.WriteMsgs(msgs, flags)
+	runtime.KeepAlive(fd)
+	return n, wrapSyscallError("sendmmsg", err)
+}
@@ -0,0 +1,111 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package http
+
+import (
+	"compress/gzip"
+	"sync"
+)
+
+// GzipHandler returns a handler that compresses the responses of h
+// with gzip for clients whose Accept-Encoding header accepts it.
+//
+// A response is sent uncompressed if h sets its Content-Encoding, if it
+// is a partial response with a Content-Range header, or if its status
+// code does not allow a body. Compressed responses have no
+// Content-Length or Accept-Ranges header, and a strong ETag set by h is
+// made weak. "Accept-Encoding" is added to the Vary header of all
+// responses.
+//
+// If h does not set a Content-Type, it is detected from the data of the
+// first Write, as by the Server.
+//
+// The ResponseWriter passed to h implements Flusher, which flushes the
+// data compressed so far. It does not implement Hijacker.
+//
+// For static files, FileServer can serve precompressed files instead.
+func GzipHandler(h Handler) Handler {
+	return HandlerFunc(func(w ResponseWriter, r *Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+		if preferredEncoding(r.Header["Accept-Encoding"], []string{"gzip"}) == "" {
+			h.ServeHTTP(w, r)
+			return
+		}
+		gw := &gzipResponseWriter{rw: w}
+		defer gw.close()
+		h.ServeHTTP(gw, r)
+	})
+}
+
+var gzipWriterPool = sync.Pool{
+	New: func() interface{} { return gzip.NewWriter(nil) },
+}
+
+// gzipResponseWriter is the ResponseWriter used by GzipHandler.
+type gzipResponseWriter struct {
+	rw          ResponseWriter
+	gz          *gzip.Writer // non-nil if the response is compressed
+	wroteHeader bool
+}
+
+func (w *gzipResponseWriter) Header() Header { return w.rw.Header() }
+
+func (w *gzipResponseWriter) WriteHeader(code int) {
+	if w.wroteHeader || code >= 100 && code <= 199 && code != StatusSwitchingProtocols {
+		// Superfluous calls are passed through so that the
+		// Server logs them.
+		w.rw.WriteHeader(code)
+		return
+	}
+	w.wroteHeader = true
+	h := w.rw.Header()
+	if bodyAllowedForStatus(code) && h.get("Content-Encoding") == "" && h.get("Content-Range") == "" {
+		h.Set("Content-Encoding", "gzip")
+		h.Del("Content-Length")
+		h.Del("Accept-Ranges")
+		if etag := h.get("Etag"); len(etag) > 0 && etag[0] == '"' {
+			h.Set("Etag", "W/"+etag)
+		}
+		w.gz = gzipWriterPool.Get().(*gzip.Writer)
+		w.gz.Reset(w.rw)
+	}
+	w.rw.WriteHeader(code)
+}
+
+func (w *gzipResponseWriter) Write(p []byte) (int, error) {
+	if !w.wroteHeader {
+		if _, haveType := w.rw.Header()["Content-Type"]; !haveType && len(p) > 0 {
+			w.rw.Header().Set("Content-Type", DetectContentType(p))
+		}
+		w.WriteHeader(StatusOK)
+	}
+	if w.gz == nil {
+		return w.rw.Write(p)
+	}
+	return w.gz.Write(p)
+}
+
+func (w *gzipResponseWriter) Flush() {
+	if !w.wroteHeader {
+		w.WriteHeader(StatusOK)
+	}
+	if w.gz != nil {
+		w.gz.Flush()
+	}
+	if f, ok := w.rw.(Flusher); ok {
+		f.Flush()
+	}
+}
+
+// close finishes the compressed response, if any.
+func (w *gzipResponseWriter) close() {
+	if w.gz == nil {
+		return
+	}
+	w.gz.Close()
+	w.gz.Reset(nil)
+	gzipWriterPool.Put(w.gz)
+	w.gz = nil
+}
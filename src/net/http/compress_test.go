@@ -0,0 +1,207 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package http_test
+
+import (
+	"compress/gzip"
+	"io"
+	. "net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func gunzip(t *testing.T, r io.Reader) string {
+	t.Helper()
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestGzipHandler(t *testing.T) {
+	body := strings.Repeat("hello, world\n", 100)
+	for _, test := range []struct {
+		name         string
+		accept       string
+		handler      func(w ResponseWriter, r *Request)
+		wantCode     int
+		wantEncoding string
+		wantBody     string
+		wantHeader   map[string]string
+	}{
+		{
+			name:   "Compressed",
+			accept: "gzip, deflate",
+			handler: func(w ResponseWriter, r *Request) {
+				w.Header().Set("Content-Length", "1300")
+				w.Header().Set("ETag", `"v1"`)
+				io.WriteString(w, body)
+			},
+			wantEncoding: "gzip",
+			wantBody:     body,
+			wantHeader: map[string]string{
+				"Content-Type":   "text/plain; charset=utf-8",
+				"Content-Length": "",
+				"ETag":           `W/"v1"`,
+			},
+		},
+		{
+			name:   "NotAccepted",
+			accept: "gzip;q=0, br",
+			handler: func(w ResponseWriter, r *Request) {
+				io.WriteString(w, body)
+			},
+			wantBody: body,
+		},
+		{
+			name:   "AlreadyEncoded",
+			accept: "gzip",
+			handler: func(w ResponseWriter, r *Request) {
+				w.Header().Set("Content-Encoding", "br")
+				io.WriteString(w, "brotli data")
+			},
+			wantEncoding: "br",
+			wantBody:     "brotli data",
+		},
+		{
+			name:   "NoContent",
+			accept: "gzip",
+			handler: func(w ResponseWriter, r *Request) {
+				w.WriteHeader(StatusNoContent)
+			},
+			wantCode: StatusNoContent,
+		},
+		{
+			name:   "Range",
+			accept: "gzip",
+			handler: func(w ResponseWriter, r *Request) {
+				r.Header.Set("Range", "bytes=0-4")
+				ServeContent(w, r, "hello.txt", time.Time{}, strings.NewReader(body))
+			},
+			wantCode: StatusPartialContent,
+			wantBody: "hello",
+			wantHeader: map[string]string{
+				"Content-Range": "bytes 0-4/1300",
+			},
+		},
+		{
+			name:   "WholeContent",
+			accept: "gzip",
+			handler: func(w ResponseWriter, r *Request) {
+				ServeContent(w, r, "hello.txt", time.Time{}, strings.NewReader(body))
+			},
+			wantEncoding: "gzip",
+			wantBody:     body,
+			wantHeader: map[string]string{
+				"Content-Length": "",
+				"Accept-Ranges":  "",
+			},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.Header.Set("Accept-Encoding", test.accept)
+			rec := httptest.NewRecorder()
+			GzipHandler(HandlerFunc(test.handler)).ServeHTTP(rec, req)
+
+			wantCode := test.wantCode
+			if wantCode == 0 {
+				wantCode = StatusOK
+			}
+			if rec.Code != wantCode {
+				t.Errorf("status = %d; want %d", rec.Code, wantCode)
+			}
+			h := rec.Header()
+			if got := h.Get("Content-Encoding"); got != test.wantEncoding {
+				t.Errorf("Content-Encoding = %q; want %q", got, test.wantEncoding)
+			}
+			if got := h.Get("Vary"); got != "Accept-Encoding" {
+				t.Errorf("Vary = %q; want Accept-Encoding", got)
+			}
+			for k, want := range test.wantHeader {
+				if got := h.Get(k); got != want {
+					t.Errorf("%s = %q; want %q", k, got, want)
+				}
+			}
+			got := rec.Body.String()
+			if test.wantEncoding == "gzip" {
+				got = gunzip(t, rec.Body)
+			}
+			if got != test.wantBody {
+				t.Errorf("body = %q; want %q", got, test.wantBody)
+			}
+		})
+	}
+}
+
+func TestGzipHandlerFlush(t *testing.T) {
+	flushed := make(chan bool)
+	proceed := make(chan bool)
+	h := GzipHandler(HandlerFunc(func(w ResponseWriter, r *Request) {
+		io.WriteString(w, "first\n")
+		w.(Flusher).Flush()
+		flushed <- true
+		<-proceed
+		io.WriteString(w, "second\n")
+	}))
+	ts := httptest.NewServer(h)
+	defer ts.Close()
+
+	req, _ := NewRequest("GET", ts.URL, nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	res, err := ts.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	<-flushed
+	zr, err := gzip.NewReader(res.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, len("first\n"))
+	if _, err := io.ReadFull(zr, buf); err != nil || string(buf) != "first\n" {
+		t.Fatalf("read before second write = %q, %v; want %q", buf, err, "first\n")
+	}
+	close(proceed)
+	rest, err := io.ReadAll(zr)
+	if err != nil || string(rest) != "second\n" {
+		t.Errorf("rest = %q, %v; want %q", rest, err, "second\n")
+	}
+}
+
+func TestGzipHandlerTransport_h1(t *testing.T) { testGzipHandlerTransport(t, h1Mode) }
+func TestGzipHandlerTransport_h2(t *testing.T) { testGzipHandlerTransport(t, h2Mode) }
+func testGzipHandlerTransport(t *testing.T, h2 bool) {
+	defer afterTest(t)
+	body := strings.Repeat("a", 10000)
+	cst := newClientServerTest(t, h2, GzipHandler(HandlerFunc(func(w ResponseWriter, r *Request) {
+		io.WriteString(w, body)
+	})))
+	defer cst.close()
+
+	res, err := cst.c.Get(cst.ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Uncompressed {
+		t.Errorf("response was not compressed")
+	}
+	if string(got) != body {
+		t.Errorf("got %d bytes of body; want %d", len(got), len(body))
+	}
+}
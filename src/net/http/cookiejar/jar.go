@@ -3,6 +3,9 @@
 // license that can be found in the LICENSE file.
 
 // Package cookiejar implements an in-memory RFC 6265-compliant http.CookieJar.
+//
+// The contents of a Jar can be saved and restored as a list of entries,
+// either directly with Jar.Entries and Jar.AddEntries or through a Storage.
 package cookiejar
 
 import (
@@ -55,11 +58,17 @@ type Options struct {
 	// secure: it means that the HTTP server for foo.co.uk can set a cookie
 	// for bar.co.uk.
 	PublicSuffixList PublicSuffixList
+
+	// Storage, if non-nil, holds entries saved by an earlier run.
+	// New loads the jar from Storage, and Jar.Save writes the jar's
+	// entries back to it.
+	Storage Storage
 }
 
 // Jar implements the http.CookieJar interface from the net/http package.
 type Jar struct {
-	psList PublicSuffixList
+	psList  PublicSuffixList
+	storage Storage
 
 	// mu locks the remaining fields.
 	mu sync.Mutex
@@ -75,12 +84,25 @@ type Jar struct {
 
 // New returns a new cookie jar. A nil *Options is equivalent to a zero
 // Options.
+//
+// If o.Storage is set, New loads the jar from it and returns an error if
+// the saved entries cannot be loaded or are invalid.
 func New(o *Options) (*Jar, error) {
 	jar := &Jar{
 		entries: make(map[string]map[string]entry),
 	}
 	if o != nil {
 		jar.psList = o.PublicSuffixList
+		jar.storage = o.Storage
+	}
+	if jar.storage != nil {
+		entries, err := jar.storage.Load()
+		if err != nil {
+			return nil, err
+		}
+		if err := jar.AddEntries(entries); err != nil {
+			return nil, err
+		}
 	}
 	return jar, nil
 }
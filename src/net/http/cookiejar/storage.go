@@ -0,0 +1,277 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cookiejar
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// An Entry is a cookie as stored in a Jar, together with the state that
+// RFC 6265 section 5.3 keeps for it. Entries are the unit in which the
+// contents of a Jar are saved and restored; see Jar.Entries, Jar.AddEntries
+// and Storage.
+type Entry struct {
+	Name  string
+	Value string
+
+	// Domain is the canonical host name of the cookie's domain,
+	// without a leading dot. If HostOnly is true, the cookie is only
+	// sent to Domain itself, not to its subdomains.
+	Domain   string
+	Path     string
+	HostOnly bool
+
+	// SameSite is "", "SameSite", "SameSite=Strict" or "SameSite=Lax".
+	SameSite string
+	Secure   bool
+	HttpOnly bool
+
+	// Persistent reports whether the cookie expires at Expires.
+	// A cookie that is not persistent is a session cookie; its
+	// Expires is ignored.
+	Persistent bool
+	Expires    time.Time
+	Creation   time.Time
+	LastAccess time.Time
+}
+
+// Storage is a place to save the entries of a Jar between program runs.
+//
+// Implementations of Storage must be safe for concurrent use by multiple
+// goroutines.
+type Storage interface {
+	// Load returns the saved entries. If nothing has been saved yet,
+	// Load returns no entries and a nil error.
+	Load() ([]Entry, error)
+
+	// Save replaces the saved entries with entries.
+	Save(entries []Entry) error
+}
+
+// Entries returns the entries of all cookies in j that have not expired,
+// sorted by Domain, Path and Name. It includes session cookies, which
+// callers that mimic a browser session may want to drop before saving.
+func (j *Jar) Entries() []Entry {
+	return j.allEntries(time.Now())
+}
+
+// allEntries is like Entries but takes the current time as a parameter.
+func (j *Jar) allEntries(now time.Time) []Entry {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	var entries []Entry
+	for _, submap := range j.entries {
+		for _, e := range submap {
+			if e.Persistent && !e.Expires.After(now) {
+				continue
+			}
+			entries = append(entries, e.export())
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		a, b := &entries[i], &entries[j]
+		if a.Domain != b.Domain {
+			return a.Domain < b.Domain
+		}
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		return a.Name < b.Name
+	})
+	return entries
+}
+
+// AddEntries adds entries, such as those previously returned by Entries,
+// to j. An entry replaces any cookie in j with the same Domain, Path and
+// Name. Entries of persistent cookies that have expired are ignored.
+// Cookies with equal Creation times are sent in the order of entries.
+//
+// Entries are checked against j's PublicSuffixList in the same way as
+// cookies received from a server: an entry for a domain cookie whose
+// Domain is a public suffix is rejected. If any entry is invalid,
+// AddEntries returns an error and adds none of them.
+func (j *Jar) AddEntries(entries []Entry) error {
+	return j.addEntries(entries, time.Now())
+}
+
+// addEntries is like AddEntries but takes the current time as a parameter.
+func (j *Jar) addEntries(entries []Entry, now time.Time) error {
+	add := make([]entry, 0, len(entries))
+	for i := range entries {
+		e, err := j.importEntry(&entries[i])
+		if err != nil {
+			return err
+		}
+		if e.Persistent && !e.Expires.After(now) {
+			continue
+		}
+		add = append(add, e)
+	}
+
+	// Assign sequence numbers in creation order, so that Cookies
+	// keeps returning cookies with equal Creation in a stable order.
+	sort.SliceStable(add, func(i, j int) bool {
+		return add[i].Creation.Before(add[j].Creation)
+	})
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	for _, e := range add {
+		key := jarKey(e.Domain, j.psList)
+		submap := j.entries[key]
+		if submap == nil {
+			submap = make(map[string]entry)
+			j.entries[key] = submap
+		}
+		e.seqNum = j.nextSeqNum
+		j.nextSeqNum++
+		submap[e.id()] = e
+	}
+	return nil
+}
+
+// export returns e as an Entry.
+func (e *entry) export() Entry {
+	x := Entry{
+		Name:       e.Name,
+		Value:      e.Value,
+		Domain:     e.Domain,
+		Path:       e.Path,
+		HostOnly:   e.HostOnly,
+		SameSite:   e.SameSite,
+		Secure:     e.Secure,
+		HttpOnly:   e.HttpOnly,
+		Persistent: e.Persistent,
+		Creation:   e.Creation,
+		LastAccess: e.LastAccess,
+	}
+	if e.Persistent {
+		x.Expires = e.Expires
+	}
+	return x
+}
+
+// importEntry checks x and returns it as an entry.
+func (j *Jar) importEntry(x *Entry) (entry, error) {
+	e := entry{
+		Name:       x.Name,
+		Value:      x.Value,
+		Domain:     x.Domain,
+		Path:       x.Path,
+		HostOnly:   x.HostOnly,
+		SameSite:   x.SameSite,
+		Secure:     x.Secure,
+		HttpOnly:   x.HttpOnly,
+		Persistent: x.Persistent,
+		Expires:    x.Expires,
+		Creation:   x.Creation,
+		LastAccess: x.LastAccess,
+	}
+	if !e.Persistent {
+		e.Expires = endOfTime
+	}
+
+	if host, err := canonicalHost(e.Domain); err != nil || host != e.Domain || host == "" {
+		return e, fmt.Errorf("cookiejar: entry %s: %w", e.id(), errMalformedDomain)
+	}
+	if !e.HostOnly {
+		if isIP(e.Domain) {
+			return e, fmt.Errorf("cookiejar: entry %s: %w", e.id(), errNoHostname)
+		}
+		if j.psList != nil {
+			if ps := j.psList.PublicSuffix(e.Domain); ps != "" && !hasDotSuffix(e.Domain, ps) {
+				return e, fmt.Errorf("cookiejar: entry %s: %w", e.id(), errIllegalDomain)
+			}
+		}
+	}
+	if e.Path == "" || e.Path[0] != '/' {
+		return e, fmt.Errorf("cookiejar: entry %s: %w", e.id(), errMalformedPath)
+	}
+	switch e.SameSite {
+	case "", "SameSite", "SameSite=Strict", "SameSite=Lax":
+	default:
+		return e, fmt.Errorf("cookiejar: entry %s: invalid SameSite value %q", e.id(), e.SameSite)
+	}
+	return e, nil
+}
+
+var errMalformedPath = errors.New("cookiejar: malformed cookie path")
+
+// Save saves the entries of j to the Storage given in the Options
+// used to create j. It returns an error if j has no Storage.
+func (j *Jar) Save() error {
+	if j.storage == nil {
+		return errNoStorage
+	}
+	return j.storage.Save(j.Entries())
+}
+
+var errNoStorage = errors.New("cookiejar: jar has no storage")
+
+// FileStorage is a Storage that keeps entries as JSON in a file.
+type FileStorage struct {
+	name string
+}
+
+// NewFileStorage returns a FileStorage that keeps entries in the named
+// file. The file is created by the first call to Save.
+func NewFileStorage(name string) *FileStorage {
+	return &FileStorage{name: name}
+}
+
+// Load reads the entries saved in the file. If the file does not exist,
+// Load returns no entries and a nil error.
+func (s *FileStorage) Load() ([]Entry, error) {
+	data, err := os.ReadFile(s.name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var entries []Entry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("cookiejar: reading %s: %v", s.name, err)
+	}
+	return entries, nil
+}
+
+// Save writes entries to the file, replacing its contents. The new
+// contents are written to a temporary file in the same directory which is
+// then renamed over the file, so a concurrent Load sees either the old or
+// the new entries. The file is only readable by its owner.
+func (s *FileStorage) Save(entries []Entry) error {
+	if entries == nil {
+		entries = []Entry{}
+	}
+	data, err := json.MarshalIndent(entries, "", "\t")
+	if err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(s.name), filepath.Base(s.name)+".*")
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(append(data, '\n'))
+	if err1 := f.Close(); err == nil {
+		err = err1
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), s.name)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
@@ -0,0 +1,184 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cookiejar
+
+import (
+	"errors"
+	"net/http"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEntriesRoundTrip(t *testing.T) {
+	jar := newTestJar()
+	u := mustParseURL("https://www.host.test/some/path")
+	jar.setCookies(u, []*http.Cookie{
+		{Name: "a", Value: "1"},
+		{Name: "b", Value: "2", Domain: "host.test", Secure: true},
+		{Name: "c", Value: "3", Path: "/", HttpOnly: true, SameSite: http.SameSiteLaxMode},
+		{Name: "d", Value: "4", MaxAge: 3600},
+		{Name: "e", Value: "5", Expires: tNow.Add(-time.Hour)}, // already expired
+	}, tNow)
+	jar.setCookies(mustParseURL("http://www.other.test"), []*http.Cookie{
+		{Name: "f", Value: "6", MaxAge: 60},
+	}, tNow.Add(time.Second))
+
+	entries := jar.allEntries(tNow.Add(2 * time.Second))
+	var got []string
+	for _, e := range entries {
+		got = append(got, e.Domain+e.Path+" "+e.Name)
+	}
+	want := []string{
+		"host.test/some b",
+		"www.host.test/ c",
+		"www.host.test/some a",
+		"www.host.test/some d",
+		"www.other.test/ f",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("entries = %q; want %q", got, want)
+	}
+	b := entries[0]
+	if b.HostOnly || !b.Secure || b.Persistent || !b.Expires.IsZero() {
+		t.Errorf("entry b = %+v; want domain, secure, session cookie", b)
+	}
+	if d := entries[3]; !d.HostOnly || !d.Persistent || !d.Expires.Equal(tNow.Add(time.Hour)) {
+		t.Errorf("entry d = %+v; want host-only cookie expiring in an hour", d)
+	}
+	if c := entries[1]; !c.HttpOnly || c.SameSite != "SameSite=Lax" {
+		t.Errorf("entry c = %+v; want HttpOnly, SameSite=Lax", c)
+	}
+
+	// Restore into a new jar and check the cookies sent.
+	jar2 := newTestJar()
+	if err := jar2.addEntries(entries, tNow); err != nil {
+		t.Fatal(err)
+	}
+	if got := jar2.allEntries(tNow.Add(2 * time.Second)); !reflect.DeepEqual(got, entries) {
+		t.Errorf("restored entries = %+v; want %+v", got, entries)
+	}
+	for _, q := range []query{
+		// Cookies with equal path length and creation time are
+		// returned in the order of the entries.
+		{"https://www.host.test/some/path", "b=2 a=1 d=4 c=3"},
+		{"http://www.host.test/some/path", "a=1 d=4 c=3"},
+		{"https://sub.www.host.test/some", "b=2"},
+		{"http://www.other.test/", "f=6"},
+	} {
+		if got := cookiesString(jar2, q.toURL, tNow.Add(3*time.Second)); got != q.want {
+			t.Errorf("cookies for %s = %q; want %q", q.toURL, got, q.want)
+		}
+	}
+
+	// Persistent cookies expire after restoring.
+	if got := cookiesString(jar2, "http://www.other.test/", tNow.Add(2*time.Minute)); got != "" {
+		t.Errorf("cookies for expired entry = %q; want none", got)
+	}
+}
+
+func cookiesString(jar *Jar, rawURL string, now time.Time) string {
+	var s []string
+	for _, c := range jar.cookies(mustParseURL(rawURL), now) {
+		s = append(s, c.Name+"="+c.Value)
+	}
+	return strings.Join(s, " ")
+}
+
+func TestAddEntriesInvalid(t *testing.T) {
+	valid := Entry{Name: "a", Value: "1", Domain: "www.host.test", Path: "/", HostOnly: true}
+	for _, test := range []struct {
+		name string
+		edit func(*Entry)
+		want error
+	}{
+		{"PublicSuffix", func(e *Entry) { e.Domain, e.HostOnly = "co.uk", false }, errIllegalDomain},
+		{"UpperCase", func(e *Entry) { e.Domain = "WWW.host.test" }, errMalformedDomain},
+		{"EmptyDomain", func(e *Entry) { e.Domain = "" }, errMalformedDomain},
+		{"IPDomainCookie", func(e *Entry) { e.Domain, e.HostOnly = "127.0.0.1", false }, errNoHostname},
+		{"RelativePath", func(e *Entry) { e.Path = "foo" }, errMalformedPath},
+		{"SameSite", func(e *Entry) { e.SameSite = "SameSite=Bogus" }, nil},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			bad := valid
+			test.edit(&bad)
+			jar := newTestJar()
+			err := jar.addEntries([]Entry{valid, bad}, tNow)
+			if err == nil {
+				t.Fatal("AddEntries succeeded; want error")
+			}
+			if test.want != nil && !errors.Is(err, test.want) {
+				t.Errorf("AddEntries error = %v; want %v", err, test.want)
+			}
+			if n := len(jar.allEntries(tNow)); n != 0 {
+				t.Errorf("jar has %d entries after failed AddEntries; want 0", n)
+			}
+		})
+	}
+
+	// A host-only cookie for a public suffix or an IP address is fine,
+	// as for cookies received from a server.
+	jar := newTestJar()
+	entries := []Entry{
+		{Name: "a", Domain: "co.uk", Path: "/", HostOnly: true},
+		{Name: "b", Domain: "127.0.0.1", Path: "/", HostOnly: true},
+	}
+	if err := jar.addEntries(entries, tNow); err != nil {
+		t.Errorf("AddEntries of host-only entries: %v", err)
+	}
+}
+
+func TestFileStorage(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "cookies.json")
+	s := NewFileStorage(name)
+	if entries, err := s.Load(); err != nil || entries != nil {
+		t.Fatalf("Load of missing file = %v, %v; want nil, nil", entries, err)
+	}
+
+	jar, err := New(&Options{PublicSuffixList: testPSL{}, Storage: s})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := jar.Save(); err != nil {
+		t.Fatal(err)
+	}
+	u := mustParseURL("http://www.host.test/")
+	jar.SetCookies(u, []*http.Cookie{
+		{Name: "session", Value: "s"},
+		{Name: "persistent", Value: "p", MaxAge: 3600},
+	})
+	if err := jar.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	jar2, err := New(&Options{PublicSuffixList: testPSL{}, Storage: s})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, c := range jar2.Cookies(u) {
+		got = append(got, c.Name+"="+c.Value)
+	}
+	if want := []string{"persistent=p", "session=s"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("cookies after reload = %q; want %q", got, want)
+	}
+	saved, reloaded := jar.Entries(), jar2.Entries()
+	if len(saved) != len(reloaded) {
+		t.Fatalf("got %d entries after reload; want %d", len(reloaded), len(saved))
+	}
+	for i := range saved {
+		a, b := saved[i], reloaded[i]
+		if a.Name != b.Name || a.Persistent != b.Persistent || !a.Expires.Equal(b.Expires) || !a.Creation.Equal(b.Creation) {
+			t.Errorf("entry after reload = %+v; want %+v", b, a)
+		}
+	}
+
+	jar3, _ := New(nil)
+	if err := jar3.Save(); err != errNoStorage {
+		t.Errorf("Save without Storage = %v; want %v", err, errNoStorage)
+	}
+}
@@ -90,6 +90,10 @@ currently supported:
 	GODEBUG=http2debug=1   # enable verbose HTTP/2 debug logs
 	GODEBUG=http2debug=2   # ... even more verbose, with frame dumps
 
+A program can also change the defaults for these settings with godebug
+lines in its go.mod file or //go:debug directives in its main package;
+see 'go help go.mod'.
+
 The GODEBUG variables are not covered by Go's API compatibility
 promise. Please report any issues before disabling HTTP/2
 support: https://golang.org/s/http2bug
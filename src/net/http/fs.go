@@ -13,6 +13,7 @@ import (
 	"io/fs"
 	"mime"
 	"mime/multipart"
+	"net/http/internal/ascii"
 	"net/textproto"
 	"net/url"
 	"os"
@@ -201,7 +202,7 @@ func ServeContent(w ResponseWriter, req *Request, name string, modtime time.Time
 		}
 		return size, nil
 	}
-	serveContent(w, req, name, modtime, sizeFunc, content)
+	serveContent(w, req, name, modtime, sizeFunc, content, "")
 }
 
 // errSeeker is returned by ServeContent's sizeFunc when the content
@@ -218,7 +219,8 @@ var errNoOverlap = errors.New("invalid range: failed to overlap")
 // if modtime.IsZero(), modtime is unknown.
 // content must be seeked to the beginning of the file.
 // The sizeFunc is called at most once. Its error, if any, is sent in the HTTP response.
-func serveContent(w ResponseWriter, r *Request, name string, modtime time.Time, sizeFunc func() (int64, error), content io.ReadSeeker) {
+// If coding is not empty, content is encoded with that content coding.
+func serveContent(w ResponseWriter, r *Request, name string, modtime time.Time, sizeFunc func() (int64, error), content io.ReadSeeker, coding string) {
 	setLastModified(w, modtime)
 	done, rangeReq := checkPreconditions(w, r, modtime)
 	if done {
@@ -274,6 +276,12 @@ func serveContent(w ResponseWriter, r *Request, name string, modtime time.Time,
 			// dumb client. Ignore the range request.
 			ranges = nil
 		}
+		if coding != "" && len(ranges) > 1 {
+			// The parts of a multipart/byteranges response would
+			// be labeled with the Content-Encoding of the whole
+			// response. Send the whole encoded content instead.
+			ranges = nil
+		}
 		switch {
 		case len(ranges) == 1:
 			// RFC 7233, Section 4.1:
@@ -326,10 +334,13 @@ func serveContent(w ResponseWriter, r *Request, name string, modtime time.Time,
 		}
 
 		w.Header().Set("Accept-Ranges", "bytes")
-		if w.Header().Get("Content-Encoding") == "" {
+		if coding != "" || w.Header().Get("Content-Encoding") == "" {
 			w.Header().Set("Content-Length", strconv.FormatInt(sendSize, 10))
 		}
 	}
+	if coding != "" {
+		w.Header().Set("Content-Encoding", coding)
+	}
 
 	w.WriteHeader(code)
 
@@ -659,9 +670,165 @@ func serveFile(w ResponseWriter, r *Request, fs FileSystem, name string, redirec
 		return
 	}
 
+	// Whether a precompressed version is served depends on
+	// Accept-Encoding, so every response for a file says so, including
+	// those that serve the file itself.
+	w.Header().Add("Vary", "Accept-Encoding")
+	if servePrecompressed(w, r, fs, name, f, d) {
+		return
+	}
+
 	// serveContent will check modification time
 	sizeFunc := func() (int64, error) { return d.Size(), nil }
-	serveContent(w, r, d.Name(), d.ModTime(), sizeFunc, f)
+	serveContent(w, r, d.Name(), d.ModTime(), sizeFunc, f, "")
+}
+
+// precompressedEncodings lists the content codings of the precompressed
+// files served by serveFile and their file name extensions, in order of
+// preference.
+var precompressedEncodings = [...]struct {
+	coding, ext string
+}{
+	{"br", ".br"},
+	{"zstd", ".zst"},
+	{"gzip", ".gz"},
+}
+
+// servePrecompressed serves a precompressed version of the file name,
+// opened as f with info d, if the client accepts one. A precompressed
+// version is a regular file next to name with one of the extensions in
+// precompressedEncodings that is not older than name. Only the versions
+// in codings the client accepts are looked for, most preferred first,
+// so requests that accept none of them cost no extra Open calls. The
+// caller is responsible for the Vary header. It reports whether it served
+// the response.
+func servePrecompressed(w ResponseWriter, r *Request, fsys FileSystem, name string, f File, d fs.FileInfo) bool {
+	accept := r.Header["Accept-Encoding"]
+	if len(accept) == 0 {
+		return false
+	}
+	var codings []string
+	for _, pe := range precompressedEncodings {
+		codings = append(codings, pe.coding)
+	}
+	var (
+		coding string
+		cf     File
+		cd     fs.FileInfo
+	)
+	for cf == nil {
+		coding = preferredEncoding(accept, codings)
+		if coding == "" {
+			return false
+		}
+		for i, c := range codings {
+			if c == coding {
+				codings = append(codings[:i], codings[i+1:]...)
+				break
+			}
+		}
+		var ext string
+		for _, pe := range precompressedEncodings {
+			if pe.coding == coding {
+				ext = pe.ext
+			}
+		}
+		ff, err := fsys.Open(name + ext)
+		if err != nil {
+			continue
+		}
+		fd, err := ff.Stat()
+		if err != nil || !fd.Mode().IsRegular() || fd.ModTime().Before(d.ModTime()) {
+			ff.Close()
+			continue
+		}
+		cf, cd = ff, fd
+	}
+	defer cf.Close()
+
+	// The content type is that of the uncompressed file.
+	if _, haveType := w.Header()["Content-Type"]; !haveType {
+		ctype := mime.TypeByExtension(filepath.Ext(name))
+		if ctype == "" {
+			var buf [sniffLen]byte
+			n, _ := io.ReadFull(f, buf[:])
+			ctype = DetectContentType(buf[:n])
+		}
+		w.Header().Set("Content-Type", ctype)
+	}
+
+	// Each content coding is a different representation, with its own
+	// strong validator.
+	if etag := w.Header().get("Etag"); len(etag) >= 2 && etag[0] == '"' && etag[len(etag)-1] == '"' {
+		w.Header().Set("Etag", etag[:len(etag)-1]+"-"+coding+`"`)
+	}
+
+	sizeFunc := func() (int64, error) { return cd.Size(), nil }
+	serveContent(w, r, d.Name(), d.ModTime(), sizeFunc, cf, coding)
+	return true
+}
+
+// preferredEncoding returns the content coding from codings, which are in
+// order of the server's preference, that is most preferred by a client that
+// sent the Accept-Encoding header values accept. It returns "" if the client
+// accepts none of codings, or prefers the identity coding.
+func preferredEncoding(accept []string, codings []string) string {
+	var (
+		qs        = make([]float64, len(codings))
+		listed    = make([]bool, len(codings))
+		identityQ = -1.0 // unlisted
+		anyQ      = -1.0 // unlisted
+	)
+	for _, v := range accept {
+		for _, part := range strings.Split(v, ",") {
+			coding, params := part, ""
+			if i := strings.IndexByte(part, ';'); i >= 0 {
+				coding, params = part[:i], part[i+1:]
+			}
+			coding, _ = ascii.ToLower(textproto.TrimString(coding))
+			if coding == "" {
+				continue
+			}
+			if coding == "x-gzip" {
+				coding = "gzip"
+			}
+			q := 1.0
+			for _, param := range strings.Split(params, ";") {
+				param = textproto.TrimString(param)
+				if len(param) > 2 && (param[0] == 'q' || param[0] == 'Q') && param[1] == '=' {
+					var err error
+					if q, err = strconv.ParseFloat(param[2:], 64); err != nil || q < 0 || q > 1 {
+						q = 0
+					}
+				}
+			}
+			switch coding {
+			case "identity":
+				identityQ = q
+			case "*":
+				anyQ = q
+			}
+			for i, c := range codings {
+				if c == coding {
+					qs[i], listed[i] = q, true
+				}
+			}
+		}
+	}
+	best, bestQ := "", 0.0
+	for i, c := range codings {
+		q := qs[i]
+		if !listed[i] {
+			q = anyQ
+		}
+		if q > bestQ {
+			best, bestQ = c, q
+		}
+	}
+	if identityQ > bestQ {
+		return ""
+	}
+	return best
 }
 
 // toHTTPError returns a non-specific HTTP error message and status code
@@ -711,6 +878,9 @@ func localRedirect(w ResponseWriter, r *Request, newPath string) {
 // Outside of those two special cases, ServeFile does not use
 // r.URL.Path for selecting the file or directory to serve; only the
 // file or directory provided in the name argument is used.
+//
+// Like FileServer, ServeFile serves a precompressed version of the
+// file if one exists and the client accepts it.
 func ServeFile(w ResponseWriter, r *Request, name string) {
 	if containsDotDot(r.URL.Path) {
 		// Too many programs use r.URL.Path to construct the argument to
@@ -826,6 +996,19 @@ func FS(fsys fs.FS) FileSystem {
 // ending in "/index.html" to the same path, without the final
 // "index.html".
 //
+// If a file has precompressed versions next to it, named like the file
+// with an added ".br", ".zst" or ".gz" extension, the file server sends
+// the one preferred by the client's Accept-Encoding header instead, with
+// a matching Content-Encoding (br, zstd or gzip). A precompressed version
+// that is older than the file is ignored. The Content-Type is that of the
+// uncompressed file, range requests refer to the compressed bytes, and a
+// strong ETag set by the caller gets a suffix naming the content coding.
+// Only the versions in codings the client accepts are looked for, so
+// requests without a matching Accept-Encoding header cost no extra Open
+// calls. Since the response depends on that header, all responses for
+// files include "Vary: Accept-Encoding", whether or not the file has
+// precompressed versions.
+//
 // To use the operating system's file system implementation,
 // use http.Dir:
 //
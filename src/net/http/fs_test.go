@@ -26,6 +26,7 @@ import (
 	"runtime"
 	"strings"
 	"testing"
+	"testing/fstest"
 	"time"
 )
 
@@ -1409,3 +1410,166 @@ func testServeFileRejectsInvalidSuffixLengths(t *testing.T, h2 bool) {
 		})
 	}
 }
+
+func TestFileServerPrecompressed(t *testing.T) {
+	modTime := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	fsys := fstest.MapFS{
+		"app.js":        {Data: []byte("console.log('hello')"), ModTime: modTime},
+		"app.js.gz":     {Data: []byte("gzip data"), ModTime: modTime},
+		"app.js.br":     {Data: []byte("brotli data"), ModTime: modTime.Add(time.Hour)},
+		"stale.css":     {Data: []byte("body {}"), ModTime: modTime.Add(time.Hour)},
+		"stale.css.gz":  {Data: []byte("stale gzip data"), ModTime: modTime},
+		"noext":         {Data: []byte("<html></html>"), ModTime: modTime},
+		"noext.zst":     {Data: []byte("zstd data"), ModTime: modTime},
+		"plain.txt":     {Data: []byte("plain"), ModTime: modTime},
+		"dir.txt.gz/ok": {Data: []byte("not a regular file"), ModTime: modTime},
+		"dir.txt":       {Data: []byte("dir"), ModTime: modTime},
+	}
+	jsType := mime.TypeByExtension(".js")
+	for _, test := range []struct {
+		path, accept string
+		wantEncoding string
+		wantBody     string
+		wantType     string
+	}{
+		// Even without Accept-Encoding, the response depends on it.
+		{"/app.js", "", "", "console.log('hello')", jsType},
+		{"/app.js", "gzip", "gzip", "gzip data", jsType},
+		{"/app.js", "x-gzip", "gzip", "gzip data", jsType},
+		{"/app.js", "gzip, deflate, br", "br", "brotli data", jsType},
+		{"/app.js", "br;q=0.5, GZIP", "gzip", "gzip data", jsType},
+		{"/app.js", "*", "br", "brotli data", jsType},
+		{"/app.js", "br;q=0, *;q=0.1", "gzip", "gzip data", jsType},
+		{"/app.js", "gzip;q=0.5, identity", "", "console.log('hello')", jsType},
+		{"/app.js", "zstd", "", "console.log('hello')", jsType},
+		{"/stale.css", "gzip", "", "body {}", "text/css; charset=utf-8"},
+		{"/noext", "zstd", "zstd", "zstd data", "text/html; charset=utf-8"},
+		{"/plain.txt", "gzip", "", "plain", "text/plain; charset=utf-8"},
+		{"/dir.txt", "gzip", "", "dir", "text/plain; charset=utf-8"},
+	} {
+		req := httptest.NewRequest("GET", test.path, nil)
+		if test.accept != "" {
+			req.Header.Set("Accept-Encoding", test.accept)
+		}
+		rec := httptest.NewRecorder()
+		FileServer(FS(fsys)).ServeHTTP(rec, req)
+		name := test.path + " with Accept-Encoding " + test.accept
+		if rec.Code != StatusOK {
+			t.Errorf("%s: status = %d; want 200", name, rec.Code)
+			continue
+		}
+		h := rec.Header()
+		if got := h.Get("Content-Encoding"); got != test.wantEncoding {
+			t.Errorf("%s: Content-Encoding = %q; want %q", name, got, test.wantEncoding)
+		}
+		if got := rec.Body.String(); got != test.wantBody {
+			t.Errorf("%s: body = %q; want %q", name, got, test.wantBody)
+		}
+		if got, want := h.Get("Content-Length"), fmt.Sprint(len(test.wantBody)); got != want {
+			t.Errorf("%s: Content-Length = %q; want %q", name, got, want)
+		}
+		if got := h.Get("Content-Type"); got != test.wantType {
+			t.Errorf("%s: Content-Type = %q; want %q", name, got, test.wantType)
+		}
+		if got := h["Vary"]; !reflect.DeepEqual(got, []string{"Accept-Encoding"}) {
+			t.Errorf("%s: Vary = %q; want [Accept-Encoding]", name, got)
+		}
+	}
+}
+
+// openCountFS is a FileSystem that counts the calls to Open.
+type openCountFS struct {
+	FileSystem
+	opens []string
+}
+
+func (fsys *openCountFS) Open(name string) (File, error) {
+	fsys.opens = append(fsys.opens, name)
+	return fsys.FileSystem.Open(name)
+}
+
+func TestFileServerPrecompressedOpens(t *testing.T) {
+	modTime := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	fsys := fstest.MapFS{
+		"app.js":    {Data: []byte("console.log('hello')"), ModTime: modTime},
+		"app.js.gz": {Data: []byte("gzip data"), ModTime: modTime},
+	}
+	for _, test := range []struct {
+		accept string
+		want   []string
+	}{
+		{"", []string{"/app.js"}},
+		{"deflate", []string{"/app.js"}},
+		{"gzip", []string{"/app.js", "/app.js.gz"}},
+		{"gzip;q=0.5, br", []string{"/app.js", "/app.js.br", "/app.js.gz"}},
+		{"br, zstd", []string{"/app.js", "/app.js.br", "/app.js.zst"}},
+	} {
+		cfs := &openCountFS{FileSystem: FS(fsys)}
+		req := httptest.NewRequest("GET", "/app.js", nil)
+		if test.accept != "" {
+			req.Header.Set("Accept-Encoding", test.accept)
+		}
+		FileServer(cfs).ServeHTTP(httptest.NewRecorder(), req)
+		if !reflect.DeepEqual(cfs.opens, test.want) {
+			t.Errorf("Accept-Encoding %q: opened %q; want %q", test.accept, cfs.opens, test.want)
+		}
+	}
+}
+
+func TestFileServerPrecompressedRangeAndETag(t *testing.T) {
+	modTime := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	fsys := fstest.MapFS{
+		"app.js":    {Data: []byte("console.log('hello')"), ModTime: modTime},
+		"app.js.gz": {Data: []byte("0123456789"), ModTime: modTime},
+	}
+	fileServer := FileServer(FS(fsys))
+	h := HandlerFunc(func(w ResponseWriter, r *Request) {
+		w.Header().Set("ETag", `"v1"`)
+		fileServer.ServeHTTP(w, r)
+	})
+	serve := func(hdr ...string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/app.js", nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		for i := 0; i < len(hdr); i += 2 {
+			req.Header.Set(hdr[i], hdr[i+1])
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := serve("Range", "bytes=2-4")
+	if rec.Code != StatusPartialContent {
+		t.Fatalf("range request: status = %d; want 206", rec.Code)
+	}
+	if got, want := rec.Header().Get("Content-Range"), "bytes 2-4/10"; got != want {
+		t.Errorf("range request: Content-Range = %q; want %q", got, want)
+	}
+	if got, want := rec.Header().Get("Content-Encoding"), "gzip"; got != want {
+		t.Errorf("range request: Content-Encoding = %q; want %q", got, want)
+	}
+	if got, want := rec.Body.String(), "234"; got != want {
+		t.Errorf("range request: body = %q; want %q", got, want)
+	}
+
+	// Multiple ranges would need a multipart response, each part of
+	// which would be encoded; the whole encoded content is sent instead.
+	rec = serve("Range", "bytes=0-1,4-5")
+	if rec.Code != StatusOK || rec.Body.String() != "0123456789" {
+		t.Errorf("multiple range request: got status %d, body %q; want 200 and the whole gzip file", rec.Code, rec.Body.String())
+	}
+
+	rec = serve()
+	if got, want := rec.Header().Get("ETag"), `"v1-gzip"`; got != want {
+		t.Errorf("ETag = %q; want %q", got, want)
+	}
+	if rec = serve("If-None-Match", `"v1-gzip"`); rec.Code != StatusNotModified {
+		t.Errorf("If-None-Match of gzip ETag: status = %d; want 304", rec.Code)
+	}
+	if rec = serve("If-None-Match", `"v1"`); rec.Code != StatusOK {
+		t.Errorf("If-None-Match of identity ETag: status = %d; want 200", rec.Code)
+	}
+	if rec = serve("Range", "bytes=2-4", "If-Range", `"v1"`); rec.Code != StatusOK {
+		t.Errorf("If-Range with identity ETag: status = %d; want 200", rec.Code)
+	}
+}
@@ -0,0 +1,26 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !nethttpomithttp2
+// +build !nethttpomithttp2
+
+package http
+
+import "internal/godebug"
+
+var http2debug = godebug.New("http2debug")
+
+// The bundled HTTP/2 code reads $GODEBUG itself when it is initialized.
+// This init runs after it, to also apply an http2debug default set
+// by the main module's go.mod or //go:debug directives.
+func init() {
+	switch http2debug.Value() {
+	case "1":
+		http2VerboseLogs = true
+	case "2":
+		http2VerboseLogs = true
+		http2logFrameWrites = true
+		http2logFrameReads = true
+	}
+}
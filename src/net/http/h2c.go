@@ -0,0 +1,201 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Unencrypted HTTP/2 ("h2c"), as described in RFC 7540, sections 3.2
+// and 3.4.
+
+package http
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http/internal/ascii"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/http/httpguts"
+)
+
+// h2cPrefaceTail is the part of the HTTP/2 client connection preface
+// that follows the "PRI * HTTP/2.0" request line and the empty header
+// block, both of which are read as an HTTP/1 request.
+const h2cPrefaceTail = "SM\r\n\r\n"
+
+// h2cUpgradeSettings is the HTTP2-Settings header value sent by the
+// Transport in an upgrade request. It is a SETTINGS payload with a
+// single SETTINGS_ENABLE_PUSH=0 parameter, matching the settings the
+// HTTP/2 client sends in its connection preface.
+const h2cUpgradeSettings = "AAIAAAAA"
+
+// isH2CUpgrade reports whether r asks to upgrade the connection to
+// unencrypted HTTP/2.
+func isH2CUpgrade(r *Request) bool {
+	return r.ProtoMajor == 1 && r.ProtoMinor == 1 &&
+		httpguts.HeaderValuesContainsToken(r.Header["Upgrade"], "h2c") &&
+		httpguts.HeaderValuesContainsToken(r.Header["Connection"], "Upgrade") &&
+		httpguts.HeaderValuesContainsToken(r.Header["Connection"], "HTTP2-Settings") &&
+		len(r.Header["Http2-Settings"]) == 1
+}
+
+// unencryptedHTTP2Conn is a net.Conn whose reads come from r, which
+// holds data already read from the connection as HTTP/1.
+type unencryptedHTTP2Conn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (c *unencryptedHTTP2Conn) Read(p []byte) (int, error) { return c.r.Read(p) }
+
+// serveUnencryptedHTTP2 serves c as unencrypted HTTP/2 if w's request
+// starts an HTTP/2 connection preface or asks for an h2c upgrade that
+// the server can grant. It reports whether it took over the connection;
+// if not, the request is to be served as HTTP/1.
+func (c *conn) serveUnencryptedHTTP2(ctx context.Context, w *response) bool {
+	req := w.req
+	opts := &http2ServeConnOpts{
+		Context:    ctx,
+		Handler:    serverHandler{c.server},
+		BaseConfig: c.server,
+	}
+	switch {
+	case req.isH2Upgrade():
+		b, err := c.bufr.Peek(len(h2cPrefaceTail))
+		if err != nil || string(b) != h2cPrefaceTail {
+			return true // not HTTP/2 after all; hang up
+		}
+		c.bufr.Discard(len(b))
+		opts.SawClientPreface = true
+
+	case isH2CUpgrade(req):
+		if req.ContentLength < 0 || req.ContentLength > maxPostHandlerReadBytes || req.Header.get("Expect") != "" {
+			// The request body would have to be buffered before
+			// switching protocols. Serve it as HTTP/1 instead.
+			return false
+		}
+		settings, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(req.Header.get("Http2-Settings"), "="))
+		if err != nil || len(settings)%6 != 0 {
+			const publicErr = "400 Bad Request"
+			fmt.Fprintf(c.rwc, "HTTP/1.1 "+publicErr+"\r\nContent-Type: text/plain; charset=utf-8\r\nConnection: close\r\n\r\n"+publicErr+": invalid HTTP2-Settings")
+			return true
+		}
+		body, err := io.ReadAll(req.Body)
+		if err != nil {
+			return true
+		}
+		if len(body) == 0 {
+			req.Body = NoBody
+		} else {
+			req.Body = io.NopCloser(bytes.NewReader(body))
+		}
+		for _, k := range []string{"Connection", "Upgrade", "Http2-Settings"} {
+			delete(req.Header, k)
+		}
+		req.Proto, req.ProtoMajor, req.ProtoMinor = "HTTP/2.0", 2, 0
+		if _, err := io.WriteString(c.rwc, "HTTP/1.1 101 Switching Protocols\r\nConnection: Upgrade\r\nUpgrade: h2c\r\n\r\n"); err != nil {
+			return true
+		}
+		opts.UpgradeRequest = req
+		opts.Settings = settings
+
+	default:
+		return false
+	}
+	defer w.cancelCtx()
+	c.setState(c.rwc, StateActive, skipHooks)
+	c.server.h2c.ServeConn(&unencryptedHTTP2Conn{c.rwc, c.bufr}, opts)
+	return true
+}
+
+// dialUnencryptedHTTP2 sets up unencrypted HTTP/2 on the newly dialed
+// pconn, if t supports it. It returns a nil RoundTripper if pconn is
+// to be used for HTTP/1.
+func (t *Transport) dialUnencryptedHTTP2(ctx context.Context, pconn *persistConn, cm connectMethod) (RoundTripper, error) {
+	t2, ok := t.h2transport.(*http2Transport)
+	if !ok || t2.upgradeUnencrypted == nil {
+		return nil, nil
+	}
+	p := t.protocols()
+	if !p.UnencryptedHTTP2() {
+		return nil, nil
+	}
+	conn := pconn.conn
+	if p.HTTP1() {
+		switched, err := t.upgradeToUnencryptedHTTP2(ctx, pconn, cm)
+		if err != nil || !switched {
+			return nil, err
+		}
+		conn = &unencryptedHTTP2Conn{pconn.conn, pconn.br}
+	}
+	alt := t2.upgradeUnencrypted(cm.targetAddr, conn)
+	if e, ok := alt.(erringRoundTripper); ok {
+		// conn was closed by upgradeUnencrypted.
+		return nil, e.RoundTripErr()
+	}
+	return alt, nil
+}
+
+// upgradeToUnencryptedHTTP2 sends an "OPTIONS *" request asking to
+// upgrade pconn to unencrypted HTTP/2, and reports whether the server
+// switched protocols. If it did not, pconn remains usable for HTTP/1.
+// The response to the OPTIONS request itself is discarded: after an
+// upgrade it arrives on HTTP/2 stream 1, which the client never uses.
+func (t *Transport) upgradeToUnencryptedHTTP2(ctx context.Context, pconn *persistConn, cm connectMethod) (switched bool, err error) {
+	req := &Request{
+		Method: "OPTIONS",
+		URL:    &url.URL{Opaque: "*"},
+		Host:   cm.targetAddr,
+		Header: Header{
+			"Connection":     {"Upgrade, HTTP2-Settings"},
+			"Upgrade":        {"h2c"},
+			"Http2-Settings": {h2cUpgradeSettings},
+		},
+	}
+	pconn.readLimit = pconn.maxHeaderResponseSize()
+	var resp *Response
+	didReadResponse := make(chan struct{}) // closed after the request write+read is done or fails
+	go func() {
+		defer close(didReadResponse)
+		if err = req.Write(pconn.bw); err == nil {
+			err = pconn.bw.Flush()
+		}
+		if err != nil {
+			return
+		}
+		resp, err = ReadResponse(pconn.br, req)
+		if err == nil && resp.StatusCode != StatusSwitchingProtocols {
+			_, err = io.Copy(io.Discard, resp.Body)
+		}
+	}()
+	select {
+	case <-ctx.Done():
+		pconn.conn.Close()
+		<-didReadResponse
+		return false, ctx.Err()
+	case <-didReadResponse:
+		// resp or err now set
+	}
+	if err != nil {
+		pconn.conn.Close()
+		return false, err
+	}
+	if resp.StatusCode == StatusSwitchingProtocols {
+		if !ascii.EqualFold(resp.Header.get("Upgrade"), "h2c") {
+			pconn.conn.Close()
+			return false, errors.New("net/http: server switched to unexpected protocol " + resp.Header.get("Upgrade"))
+		}
+		pconn.readLimit = maxInt64
+		return true, nil
+	}
+	if resp.Close {
+		pconn.conn.Close()
+		return false, errors.New("net/http: server closed connection after declining h2c upgrade")
+	}
+	return false, nil
+}
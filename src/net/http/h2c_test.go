@@ -0,0 +1,176 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package http_test
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	. "net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func protocols(http1, h2c bool) *Protocols {
+	p := new(Protocols)
+	p.SetHTTP1(http1)
+	p.SetUnencryptedHTTP2(h2c)
+	return p
+}
+
+func newUnencryptedHTTP2Server(t *testing.T, p *Protocols) *httptest.Server {
+	ts := httptest.NewUnstartedServer(HandlerFunc(func(w ResponseWriter, r *Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("reading body: %v", err)
+		}
+		fmt.Fprintf(w, "%s %s %q", r.Proto, r.Method, body)
+	}))
+	ts.Config.Protocols = p
+	ts.Start()
+	return ts
+}
+
+func TestProtocolsString(t *testing.T) {
+	var p Protocols
+	if got, want := p.String(), "{}"; got != want {
+		t.Errorf("zero Protocols = %v; want %v", got, want)
+	}
+	p.SetHTTP1(true)
+	p.SetUnencryptedHTTP2(true)
+	if got, want := p.String(), "{HTTP1,UnencryptedHTTP2}"; got != want {
+		t.Errorf("Protocols = %v; want %v", got, want)
+	}
+	p.SetHTTP1(false)
+	if p.HTTP1() || p.HTTP2() || !p.UnencryptedHTTP2() {
+		t.Errorf("after SetHTTP1(false): %v", p)
+	}
+}
+
+func TestUnencryptedHTTP2(t *testing.T) {
+	for _, test := range []struct {
+		name      string
+		server    *Protocols
+		client    *Protocols
+		wantProto string
+	}{
+		{"PriorKnowledge", protocols(true, true), protocols(false, true), "HTTP/2.0"},
+		{"PriorKnowledgeOnly", protocols(false, true), protocols(false, true), "HTTP/2.0"},
+		{"Upgrade", protocols(true, true), protocols(true, true), "HTTP/2.0"},
+		{"UpgradeDeclined", nil, protocols(true, true), "HTTP/1.1"},
+		{"NotRequested", protocols(true, true), nil, "HTTP/1.1"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			defer afterTest(t)
+			ts := newUnencryptedHTTP2Server(t, test.server)
+			defer ts.Close()
+			tr := &Transport{Protocols: test.client}
+			defer tr.CloseIdleConnections()
+			c := &Client{Transport: tr}
+
+			for i := 0; i < 2; i++ {
+				res, err := c.Post(ts.URL, "text/plain", strings.NewReader("body"))
+				if err != nil {
+					t.Fatal(err)
+				}
+				got, err := io.ReadAll(res.Body)
+				res.Body.Close()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if res.Proto != test.wantProto {
+					t.Errorf("request %d: response Proto = %q; want %q", i, res.Proto, test.wantProto)
+				}
+				if want := test.wantProto + ` POST "body"`; string(got) != want {
+					t.Errorf("request %d: body = %q; want %q", i, got, want)
+				}
+			}
+		})
+	}
+}
+
+// Tests that the server passes the request that asked for an upgrade,
+// and its body, to the handler over HTTP/2.
+func TestServerUnencryptedHTTP2UpgradeRequest(t *testing.T) {
+	defer afterTest(t)
+	ts := newUnencryptedHTTP2Server(t, protocols(true, true))
+	defer ts.Close()
+
+	c, err := net.Dial("tcp", ts.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	io.WriteString(c, "POST / HTTP/1.1\r\nHost: foo\r\nContent-Length: 4\r\n"+
+		"Connection: Upgrade, HTTP2-Settings\r\nUpgrade: h2c\r\nHTTP2-Settings: AAIAAAAA\r\n\r\nbody")
+	br := bufio.NewReader(c)
+	res, err := ReadResponse(br, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.StatusCode != StatusSwitchingProtocols || res.Header.Get("Upgrade") != "h2c" {
+		t.Fatalf("got status %v, Upgrade %q; want 101, h2c", res.Status, res.Header.Get("Upgrade"))
+	}
+
+	// The client preface and an empty SETTINGS frame.
+	io.WriteString(c, "PRI * HTTP/2.0\r\n\r\nSM\r\n\r\n\x00\x00\x00\x04\x00\x00\x00\x00\x00")
+	var sawBody bool
+	for !sawBody {
+		var hdr [9]byte
+		if _, err := io.ReadFull(br, hdr[:]); err != nil {
+			t.Fatal(err)
+		}
+		payload := make([]byte, int(hdr[0])<<16|int(hdr[1])<<8|int(hdr[2]))
+		if _, err := io.ReadFull(br, payload); err != nil {
+			t.Fatal(err)
+		}
+		const frameData = 0x0
+		streamID := uint32(hdr[5]&0x7f)<<24 | uint32(hdr[6])<<16 | uint32(hdr[7])<<8 | uint32(hdr[8])
+		if hdr[3] == frameData && streamID == 1 && len(payload) > 0 {
+			if got, want := string(payload), `HTTP/2.0 POST "body"`; got != want {
+				t.Errorf("response body on stream 1 = %q; want %q", got, want)
+			}
+			sawBody = true
+		}
+	}
+}
+
+func TestServerUnencryptedHTTP2BadSettings(t *testing.T) {
+	defer afterTest(t)
+	ts := newUnencryptedHTTP2Server(t, protocols(true, true))
+	defer ts.Close()
+
+	c, err := net.Dial("tcp", ts.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	io.WriteString(c, "GET / HTTP/1.1\r\nHost: foo\r\n"+
+		"Connection: Upgrade, HTTP2-Settings\r\nUpgrade: h2c\r\nHTTP2-Settings: AAIA\r\n\r\n")
+	res, err := ReadResponse(bufio.NewReader(c), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.StatusCode != StatusBadRequest {
+		t.Errorf("got status %v; want 400", res.Status)
+	}
+}
+
+func TestServerHTTP1Disabled(t *testing.T) {
+	defer afterTest(t)
+	ts := newUnencryptedHTTP2Server(t, protocols(false, true))
+	defer ts.Close()
+
+	res, err := ts.Client().Get(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != StatusHTTPVersionNotSupported {
+		t.Errorf("got status %v; want 505", res.Status)
+	}
+}
@@ -112,6 +112,11 @@ type ClientTrace struct {
 	// returned before the final non-1xx response. Got1xxResponse is called
 	// for "100 Continue" responses, even if Got100Continue is also defined.
 	// If it returns an error, the client request is aborted with that error value.
+	//
+	// The Transport does not limit the number of 1xx responses delivered
+	// to Got1xxResponse; callers that need a limit can return an error.
+	// Without Got1xxResponse, the size of all 1xx response headers is
+	// limited by the Transport's MaxResponseHeaderBytes.
 	Got1xxResponse func(code int, header textproto.MIMEHeader) error
 
 	// DNSStart is called when a DNS lookup begins.
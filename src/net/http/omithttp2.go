@@ -8,7 +8,9 @@
 package http
 
 import (
+	"context"
 	"errors"
+	"net"
 	"sync"
 	"time"
 )
@@ -26,8 +28,9 @@ var http2goAwayTimeout = 1 * time.Second
 const http2NextProtoTLS = "h2"
 
 type http2Transport struct {
-	MaxHeaderListSize uint32
-	ConnPool          interface{}
+	MaxHeaderListSize  uint32
+	ConnPool           interface{}
+	upgradeUnencrypted func(string, net.Conn) RoundTripper
 }
 
 func (*http2Transport) RoundTrip(*Request) (*Response, error) { panic(noHTTP2) }
@@ -63,6 +66,17 @@ func http2NewPriorityWriteScheduler(interface{}) http2WriteScheduler { panic(noH
 
 func http2ConfigureServer(s *Server, conf *http2Server) error { panic(noHTTP2) }
 
+type http2ServeConnOpts struct {
+	Context          context.Context
+	BaseConfig       *Server
+	Handler          Handler
+	UpgradeRequest   *Request
+	Settings         []byte
+	SawClientPreface bool
+}
+
+func (*http2Server) ServeConn(net.Conn, *http2ServeConnOpts) { panic(noHTTP2) }
+
 var http2ErrNoCachedConn = http2noCachedConnError{}
 
 type http2noCachedConnError struct{}
@@ -3816,7 +3816,7 @@ func testServerReaderFromOrder(t *testing.T, h2 bool) {
 
 // Issue 6157, Issue 6685
 func TestCodesPreventingContentTypeAndBody(t *testing.T) {
-	for _, code := range []int{StatusNotModified, StatusNoContent, StatusContinue} {
+	for _, code := range []int{StatusNotModified, StatusNoContent} {
 		ht := newHandlerTest(HandlerFunc(func(w ResponseWriter, r *Request) {
 			if r.URL.Path == "/header" {
 				w.Header().Set("Content-Length", "123")
@@ -6610,3 +6610,38 @@ func testQuerySemicolon(t *testing.T, query string, wantX string, allowSemicolon
 		}
 	}
 }
+
+// Tests that the server sends 1xx responses written by the handler
+// to HTTP/1.1 clients, but not to HTTP/1.0 clients.
+func TestServerWriteHeader1xx(t *testing.T) {
+	setParallel(t)
+	defer afterTest(t)
+	ts := httptest.NewServer(HandlerFunc(func(w ResponseWriter, r *Request) {
+		w.Header().Set("Link", "</style.css>; rel=preload; as=style")
+		w.WriteHeader(StatusEarlyHints)
+		io.WriteString(w, "ok")
+	}))
+	defer ts.Close()
+
+	for _, test := range []struct {
+		proto string
+		want  string
+	}{
+		{"HTTP/1.1", "HTTP/1.1 103 Early Hints\r\nLink: </style.css>; rel=preload; as=style\r\n\r\nHTTP/1.1 200 OK\r\n"},
+		{"HTTP/1.0", "HTTP/1.0 200 OK\r\n"},
+	} {
+		conn, err := net.Dial("tcp", ts.Listener.Addr().String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		fmt.Fprintf(conn, "GET / %s\r\nHost: foo\r\nConnection: close\r\n\r\n", test.proto)
+		got, err := io.ReadAll(conn)
+		conn.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(string(got), test.want) {
+			t.Errorf("%s response = %q; want prefix %q", test.proto, got, test.want)
+		}
+	}
+}
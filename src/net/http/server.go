@@ -13,6 +13,7 @@ import (
 	"crypto/tls"
 	"errors"
 	"fmt"
+	"internal/godebug"
 	"io"
 	"log"
 	"math/rand"
@@ -20,7 +21,6 @@ import (
 	"net/textproto"
 	"net/url"
 	urlpkg "net/url"
-	"os"
 	"path"
 	"runtime"
 	"sort"
@@ -147,10 +147,15 @@ type ResponseWriter interface {
 	// send error codes.
 	//
 	// The provided code must be a valid HTTP 1xx-5xx status code.
-	// Only one header may be written. Go does not currently
-	// support sending user-defined 1xx informational headers,
-	// with the exception of 100-continue response header that the
-	// Server sends automatically when the Request.Body is read.
+	// Any number of 1xx headers may be written, followed by at most
+	// one 2xx-5xx header. 1xx headers are sent immediately, but 2xx-5xx
+	// headers may be buffered. Use the Flusher interface to send
+	// buffered data. The header map is cleared when 2xx-5xx headers are
+	// sent, but not with 1xx headers.
+	//
+	// The server will automatically send a 100 (Continue) header
+	// on the first read from the request body if the request has
+	// an "Expect: 100-continue" header.
 	WriteHeader(statusCode int)
 }
 
@@ -1121,6 +1126,33 @@ func (w *response) WriteHeader(code int) {
 		return
 	}
 	checkWriteHeaderCode(code)
+
+	// Handle informational headers.
+	// 101 Switching Protocols is a final response; see issue 26161.
+	if code >= 100 && code <= 199 && code != StatusSwitchingProtocols {
+		// A server must not send 1xx responses to an HTTP/1.0 client
+		// (RFC 7231, section 6.2).
+		if !w.req.ProtoAtLeast(1, 1) {
+			return
+		}
+		// Prevent a potential race with an automatically-sent 100 Continue
+		// triggered by Request.Body.Read().
+		if code == StatusContinue && w.canWriteContinue.isSet() {
+			w.writeContinueMu.Lock()
+			w.canWriteContinue.setFalse()
+			w.writeContinueMu.Unlock()
+		}
+
+		writeStatusLine(w.conn.bufw, true, code, w.statusBuf[:])
+
+		// Per RFC 8297 we must not clear the current header map.
+		w.handlerHeader.WriteSubset(w.conn.bufw, excludedHeadersNoBody)
+		w.conn.bufw.Write(crlf)
+		w.conn.bufw.Flush()
+
+		return
+	}
+
 	w.wroteHeader = true
 	w.status = code
 
@@ -1139,6 +1171,10 @@ func (w *response) WriteHeader(code int) {
 	}
 }
 
+// excludedHeadersNoBody is the set of headers not sent with responses
+// that have no body, such as 1xx informational responses.
+var excludedHeadersNoBody = map[string]bool{"Content-Length": true, "Transfer-Encoding": true}
+
 // extraHeader is the set of headers sometimes added by chunkWriter.writeHeader.
 // This type is used to avoid extra allocations from cloning and/or populating
 // the response Header map and all its 1-element slices.
@@ -1858,9 +1894,8 @@ func (c *conn) serve(ctx context.Context) {
 			// If we read any bytes off the wire, we're active.
 			c.setState(c.rwc, StateActive, runHooks)
 		}
+		const errorHeaders = "\r\nContent-Type: text/plain; charset=utf-8\r\nConnection: close\r\n\r\n"
 		if err != nil {
-			const errorHeaders = "\r\nContent-Type: text/plain; charset=utf-8\r\nConnection: close\r\n\r\n"
-
 			switch {
 			case err == errTooLarge:
 				// Their HTTP client may or may not be
@@ -1899,6 +1934,15 @@ func (c *conn) serve(ctx context.Context) {
 			}
 		}
 
+		if c.server.h2c != nil && c.tlsState == nil && c.serveUnencryptedHTTP2(ctx, w) {
+			return
+		}
+		if !c.server.protocols().HTTP1() {
+			const publicErr = "505 HTTP Version Not Supported"
+			fmt.Fprintf(c.rwc, "HTTP/1.1 "+publicErr+errorHeaders+publicErr)
+			return
+		}
+
 		// Expect 100 Continue support
 		req := w.req
 		if req.expectsContinue() {
@@ -2617,11 +2661,24 @@ type Server struct {
 	// value.
 	ConnContext func(ctx context.Context, c net.Conn) context.Context
 
+	// Protocols is the set of protocols accepted by the server.
+	//
+	// If Protocols includes UnencryptedHTTP2, the server will accept
+	// unencrypted HTTP/2 connections, both from clients with prior
+	// knowledge of HTTP/2 support and from clients requesting an
+	// upgrade with an "Upgrade: h2c" header.
+	//
+	// If Protocols is nil, the default is usually HTTP/1 and HTTP/2.
+	// If TLSNextProto is non-nil and does not contain an "h2" entry,
+	// the default is HTTP/1 only.
+	Protocols *Protocols
+
 	inShutdown atomicBool // true when server is in shutdown
 
-	disableKeepAlives int32     // accessed atomically.
-	nextProtoOnce     sync.Once // guards setupHTTP2_* init
-	nextProtoErr      error     // result of http2.ConfigureServer if used
+	disableKeepAlives int32        // accessed atomically.
+	nextProtoOnce     sync.Once    // guards setupHTTP2_* init
+	nextProtoErr      error        // result of http2.ConfigureServer if used
+	h2c               *http2Server // serves unencrypted HTTP/2, if enabled
 
 	mu         sync.Mutex
 	listeners  map[*net.Listener]struct{}
@@ -3251,26 +3308,72 @@ func (srv *Server) setupHTTP2_Serve() error {
 }
 
 func (srv *Server) onceSetNextProtoDefaults_Serve() {
-	if srv.shouldConfigureHTTP2ForServe() {
+	if srv.shouldConfigureHTTP2ForServe() || srv.protocols().UnencryptedHTTP2() {
 		srv.onceSetNextProtoDefaults()
 	}
 }
 
+var http2server = godebug.New("http2server")
+
 // onceSetNextProtoDefaults configures HTTP/2, if the user hasn't
 // configured otherwise. (by setting srv.TLSNextProto non-nil)
 // It must only be called via srv.nextProtoOnce (use srv.setupHTTP2_*).
 func (srv *Server) onceSetNextProtoDefaults() {
-	if omitBundledHTTP2 || strings.Contains(os.Getenv("GODEBUG"), "http2server=0") {
+	if omitBundledHTTP2 {
+		return
+	}
+	if http2server.Value() == "0" {
+		http2server.IncNonDefault()
+		return
+	}
+	p := srv.protocols()
+	if !p.HTTP2() && !p.UnencryptedHTTP2() {
 		return
 	}
 	// Enable HTTP/2 by default if the user hasn't otherwise
 	// configured their TLSNextProto map.
-	if srv.TLSNextProto == nil {
+	if srv.TLSNextProto == nil || srv.Protocols != nil && srv.TLSNextProto[http2NextProtoTLS] == nil {
 		conf := &http2Server{
 			NewWriteScheduler: func() http2WriteScheduler { return http2NewPriorityWriteScheduler(nil) },
 		}
 		srv.nextProtoErr = http2ConfigureServer(srv, conf)
+		if srv.nextProtoErr != nil {
+			return
+		}
+		if !p.HTTP2() {
+			// Only unencrypted HTTP/2 was asked for; don't offer
+			// HTTP/2 to TLS clients.
+			delete(srv.TLSNextProto, http2NextProtoTLS)
+			srv.TLSConfig.NextProtos = removeString(srv.TLSConfig.NextProtos, http2NextProtoTLS)
+		}
+		if p.UnencryptedHTTP2() {
+			srv.h2c = conf
+		}
+	}
+}
+
+// protocols returns the set of protocols accepted by srv.
+func (srv *Server) protocols() Protocols {
+	if srv.Protocols != nil {
+		return *srv.Protocols
+	}
+	var p Protocols
+	p.SetHTTP1(true) // the default always includes HTTP/1
+	if srv.TLSNextProto == nil || srv.TLSNextProto[http2NextProtoTLS] != nil {
+		p.SetHTTP2(true)
+	}
+	return p
+}
+
+// removeString returns a copy of s without any element equal to v.
+func removeString(s []string, v string) []string {
+	var r []string
+	for _, e := range s {
+		if e != v {
+			r = append(r, e)
+		}
 	}
+	return r
 }
 
 // TimeoutHandler returns a Handler that runs h with the given time limit.
@@ -17,6 +17,7 @@ import (
 	"crypto/tls"
 	"errors"
 	"fmt"
+	"internal/godebug"
 	"io"
 	"log"
 	"net"
@@ -24,7 +25,6 @@ import (
 	"net/http/internal/ascii"
 	"net/textproto"
 	"net/url"
-	"os"
 	"reflect"
 	"strings"
 	"sync"
@@ -281,6 +281,28 @@ type Transport struct {
 	// To use a custom dialer or TLS config and still attempt HTTP/2
 	// upgrades, set this to true.
 	ForceAttemptHTTP2 bool
+
+	// Protocols is the set of protocols supported by the transport.
+	//
+	// If Protocols includes UnencryptedHTTP2 and does not include HTTP1,
+	// the transport uses unencrypted HTTP/2 for requests to http:// URLs
+	// with prior knowledge that the server supports it.
+	//
+	// If Protocols includes both UnencryptedHTTP2 and HTTP1, the
+	// transport asks the server to upgrade each new connection for
+	// http:// URLs to unencrypted HTTP/2 with an "OPTIONS *" request
+	// carrying an "Upgrade: h2c" header, and keeps using HTTP/1 on the
+	// connection if the server declines.
+	//
+	// Unencrypted HTTP/2 is not used for requests sent through an HTTP
+	// proxy.
+	//
+	// If Protocols is nil, the default is usually HTTP/1 and HTTP/2,
+	// with HTTP/2 subject to the same conditions described for
+	// TLSNextProto and ForceAttemptHTTP2.
+	// If Protocols is non-nil, HTTP/2 is configured even if a custom
+	// dialer or TLS config is provided.
+	Protocols *Protocols
 }
 
 // A cancelKey is the key of the reqCanceler map.
@@ -332,6 +354,10 @@ func (t *Transport) Clone() *Transport {
 	if t.TLSClientConfig != nil {
 		t2.TLSClientConfig = t.TLSClientConfig.Clone()
 	}
+	if t.Protocols != nil {
+		t2.Protocols = new(Protocols)
+		*t2.Protocols = *t.Protocols
+	}
 	if !t.tlsNextProtoWasNil {
 		npm := map[string]func(authority string, c *tls.Conn) RoundTripper{}
 		for k, v := range t.TLSNextProto {
@@ -356,11 +382,25 @@ func (t *Transport) hasCustomTLSDialer() bool {
 	return t.DialTLS != nil || t.DialTLSContext != nil
 }
 
+// protocols returns the set of protocols supported by t.
+func (t *Transport) protocols() Protocols {
+	if t.Protocols != nil {
+		return *t.Protocols
+	}
+	var p Protocols
+	p.SetHTTP1(true) // the default always includes HTTP/1
+	p.SetHTTP2(true)
+	return p
+}
+
+var http2client = godebug.New("http2client")
+
 // onceSetNextProtoDefaults initializes TLSNextProto.
 // It must be called via t.nextProtoOnce.Do.
 func (t *Transport) onceSetNextProtoDefaults() {
 	t.tlsNextProtoWasNil = (t.TLSNextProto == nil)
-	if strings.Contains(os.Getenv("GODEBUG"), "http2client=0") {
+	if http2client.Value() == "0" {
+		http2client.IncNonDefault()
 		return
 	}
 
@@ -379,12 +419,17 @@ func (t *Transport) onceSetNextProtoDefaults() {
 		}
 	}
 
-	if t.TLSNextProto != nil {
+	p := t.protocols()
+	if t.Protocols != nil {
+		// An explicit set of protocols overrides the checks below.
+		if !p.HTTP2() && !p.UnencryptedHTTP2() {
+			return
+		}
+	} else if t.TLSNextProto != nil {
 		// This is the documented way to disable http2 on a
 		// Transport.
 		return
-	}
-	if !t.ForceAttemptHTTP2 && (t.TLSClientConfig != nil || t.Dial != nil || t.DialContext != nil || t.hasCustomTLSDialer()) {
+	} else if !t.ForceAttemptHTTP2 && (t.TLSClientConfig != nil || t.Dial != nil || t.DialContext != nil || t.hasCustomTLSDialer()) {
 		// Be conservative and don't automatically enable
 		// http2 if they've specified a custom TLS config or
 		// custom dialers. Let them opt-in themselves via
@@ -402,6 +447,12 @@ func (t *Transport) onceSetNextProtoDefaults() {
 		return
 	}
 	t.h2transport = t2
+	if !p.HTTP2() {
+		// Only unencrypted HTTP/2 was asked for; don't negotiate
+		// HTTP/2 over TLS.
+		delete(t.TLSNextProto, http2NextProtoTLS)
+		t.TLSClientConfig.NextProtos = removeString(t.TLSClientConfig.NextProtos, http2NextProtoTLS)
+	}
 
 	// Auto-configure the http2.Transport's MaxHeaderListSize from
 	// the http.Transport's MaxResponseHeaderBytes. They don't
@@ -1744,6 +1795,16 @@ func (t *Transport) dialConn(ctx context.Context, cm connectMethod) (pconn *pers
 	pconn.br = bufio.NewReaderSize(pconn, t.readBufferSize())
 	pconn.bw = bufio.NewWriterSize(persistConnWriter{pconn}, t.writeBufferSize())
 
+	if pconn.tlsState == nil && cm.targetScheme == "http" && (cm.proxyURL == nil || cm.proxyURL.Scheme == "socks5") {
+		alt, err := t.dialUnencryptedHTTP2(ctx, pconn, cm)
+		if err != nil {
+			return nil, err
+		}
+		if alt != nil {
+			return &persistConn{t: t, cacheKey: pconn.cacheKey, alt: alt}, nil
+		}
+	}
+
 	go pconn.readLoop()
 	go pconn.writeLoop()
 	return pconn, nil
@@ -2272,9 +2333,6 @@ func (pc *persistConn) readResponse(rc requestAndChan, trace *httptrace.ClientTr
 			trace.GotFirstResponseByte()
 		}
 	}
-	num1xx := 0               // number of informational 1xx headers received
-	const max1xxResponses = 5 // arbitrary bound on number of informational responses
-
 	continueCh := rc.continueCh
 	for {
 		resp, err = ReadResponse(pc.br, rc.req)
@@ -2298,15 +2356,18 @@ func (pc *persistConn) readResponse(rc requestAndChan, trace *httptrace.ClientTr
 		// treat 101 as a terminal status, see issue 26161
 		is1xxNonTerminal := is1xx && resCode != StatusSwitchingProtocols
 		if is1xxNonTerminal {
-			num1xx++
-			if num1xx > max1xxResponses {
-				return nil, errors.New("net/http: too many 1xx informational responses")
-			}
-			pc.readLimit = pc.maxHeaderResponseSize() // reset the limit
 			if trace != nil && trace.Got1xxResponse != nil {
 				if err := trace.Got1xxResponse(resCode, textproto.MIMEHeader(resp.Header)); err != nil {
 					return nil, err
 				}
+				// The 1xx response was delivered to the user, who is
+				// responsible for limiting the number of responses.
+				// Reset the header limit.
+				//
+				// Otherwise, the size of all headers (the 1xx ones
+				// and the final response's) is limited together by
+				// maxHeaderResponseSize.
+				pc.readLimit = pc.maxHeaderResponseSize()
 			}
 			continue
 		}
@@ -2929,29 +2929,76 @@ func TestTransportIgnore1xxResponses(t *testing.T) {
 	}
 }
 
-func TestTransportLimits1xxResponses(t *testing.T) {
+func TestTransportLimits1xxResponses_h1(t *testing.T) { testTransportLimits1xxResponses(t, h1Mode) }
+func TestTransportLimits1xxResponses_h2(t *testing.T) { testTransportLimits1xxResponses(t, h2Mode) }
+
+func testTransportLimits1xxResponses(t *testing.T, h2 bool) {
 	setParallel(t)
 	defer afterTest(t)
-	cst := newClientServerTest(t, h1Mode, HandlerFunc(func(w ResponseWriter, r *Request) {
-		conn, buf, _ := w.(Hijacker).Hijack()
+	cst := newClientServerTest(t, h2, HandlerFunc(func(w ResponseWriter, r *Request) {
+		w.Header().Add("X-Header", strings.Repeat("a", 100))
 		for i := 0; i < 10; i++ {
-			buf.Write([]byte("HTTP/1.1 123 OneTwoThree\r\n\r\n"))
+			w.WriteHeader(123)
 		}
-		buf.Write([]byte("HTTP/1.1 204 No Content\r\n\r\n"))
-		buf.Flush()
-		conn.Close()
+		w.WriteHeader(204)
 	}))
 	defer cst.close()
 	cst.tr.DisableKeepAlives = true // prevent log spam; our test server is hanging up anyway
+	cst.tr.MaxResponseHeaderBytes = 1000
 
 	res, err := cst.c.Get(cst.ts.URL)
-	if res != nil {
-		defer res.Body.Close()
+	if err == nil {
+		res.Body.Close()
+		t.Fatalf("Get succeeded; want error")
+	}
+	for _, want := range []string{
+		"response headers exceeded",
+		"too large",
+	} {
+		if strings.Contains(err.Error(), want) {
+			return
+		}
 	}
-	got := fmt.Sprint(err)
-	wantSub := "too many 1xx informational responses"
-	if !strings.Contains(got, wantSub) {
-		t.Errorf("Get error = %v; want substring %q", err, wantSub)
+	t.Errorf(`Get error = %v; want "response headers exceeded" or "too large"`, err)
+}
+
+func TestTransportDoesNotLimitDelivered1xxResponses_h1(t *testing.T) {
+	testTransportDoesNotLimitDelivered1xxResponses(t, h1Mode)
+}
+func TestTransportDoesNotLimitDelivered1xxResponses_h2(t *testing.T) {
+	testTransportDoesNotLimitDelivered1xxResponses(t, h2Mode)
+}
+
+func testTransportDoesNotLimitDelivered1xxResponses(t *testing.T, h2 bool) {
+	setParallel(t)
+	defer afterTest(t)
+	const num1xx = 10
+	cst := newClientServerTest(t, h2, HandlerFunc(func(w ResponseWriter, r *Request) {
+		w.Header().Add("X-Header", strings.Repeat("a", 100))
+		for i := 0; i < num1xx; i++ {
+			w.WriteHeader(123)
+		}
+		w.WriteHeader(204)
+	}))
+	defer cst.close()
+	cst.tr.DisableKeepAlives = true // prevent log spam; our test server is hanging up anyway
+	cst.tr.MaxResponseHeaderBytes = 1000
+
+	got1xx := 0
+	ctx := httptrace.WithClientTrace(context.Background(), &httptrace.ClientTrace{
+		Got1xxResponse: func(code int, header textproto.MIMEHeader) error {
+			got1xx++
+			return nil
+		},
+	})
+	req, _ := NewRequestWithContext(ctx, "GET", cst.ts.URL, nil)
+	res, err := cst.c.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if got1xx != num1xx {
+		t.Errorf("got %v 1xx responses; want %v", got1xx, num1xx)
 	}
 }
 
@@ -5902,6 +5949,7 @@ func TestTransportClone(t *testing.T) {
 		},
 		ReadBufferSize:  1,
 		WriteBufferSize: 1,
+		Protocols:       new(Protocols),
 	}
 	tr2 := tr.Clone()
 	rv := reflect.ValueOf(tr2).Elem()
@@ -4,7 +4,7 @@
 
 // Package jsonrpc implements a JSON-RPC 1.0 ClientCodec and ServerCodec
 // for the rpc package.
-// For JSON-RPC 2.0 support, see https://godoc.org/?q=json-rpc+2.0
+// For JSON-RPC 2.0 support, see package net/rpc/jsonrpc2.
 package jsonrpc
 
 import (
@@ -0,0 +1,382 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package jsonrpc2
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/rpc"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+type Args struct {
+	A, B int
+}
+
+type Reply struct {
+	C int
+}
+
+type Arith int
+
+func (t *Arith) Add(args *Args, reply *Reply) error {
+	reply.C = args.A + args.B
+	return nil
+}
+
+func (t *Arith) Div(args *Args, reply *Reply) error {
+	if args.B == 0 {
+		return errors.New("divide by zero")
+	}
+	reply.C = args.A / args.B
+	return nil
+}
+
+func (t *Arith) Sum(args []int, reply *int) error {
+	for _, a := range args {
+		*reply += a
+	}
+	return nil
+}
+
+func (t *Arith) Fail(args *Args, reply *Reply) error {
+	return &Error{Code: 42, Message: "failed", Data: args.A}
+}
+
+var (
+	notified = make(chan int, 10)
+	blocked  = make(chan bool)
+	release  = make(chan bool)
+)
+
+type Service struct{}
+
+func (Service) Notify(i int, reply *bool) error {
+	notified <- i
+	return nil
+}
+
+func (Service) Block(i int, reply *int) error {
+	blocked <- true
+	<-release
+	*reply = i
+	return nil
+}
+
+func init() {
+	rpc.Register(new(Arith))
+	rpc.Register(Service{})
+}
+
+// rawConn serves a connection and returns the client end of it,
+// and a decoder for the responses written to it.
+func rawConn(t *testing.T) (net.Conn, *json.Decoder) {
+	cli, srv := net.Pipe()
+	go ServeConn(srv)
+	t.Cleanup(func() { cli.Close() })
+	return cli, json.NewDecoder(cli)
+}
+
+type testResponse struct {
+	Version string          `json:"jsonrpc"`
+	Result  json.RawMessage `json:"result"`
+	Error   *Error          `json:"error"`
+	Id      json.RawMessage `json:"id"`
+}
+
+func (r testResponse) String() string {
+	b, _ := json.Marshal(r)
+	return string(b)
+}
+
+func TestServer(t *testing.T) {
+	cli, dec := rawConn(t)
+	tests := []struct {
+		req  string
+		want string
+	}{
+		{
+			`{"jsonrpc": "2.0", "method": "Arith.Add", "params": {"A": 1, "B": 2}, "id": "a"}`,
+			`{"jsonrpc":"2.0","result":{"C":3},"error":null,"id":"a"}`,
+		},
+		{
+			`{"jsonrpc": "2.0", "method": "Arith.Add", "params": [{"A": 3, "B": 4}], "id": 1}`,
+			`{"jsonrpc":"2.0","result":{"C":7},"error":null,"id":1}`,
+		},
+		{
+			`{"jsonrpc": "2.0", "method": "Arith.Add", "id": 2}`,
+			`{"jsonrpc":"2.0","result":{"C":0},"error":null,"id":2}`,
+		},
+		{
+			`{"jsonrpc": "2.0", "method": "Arith.Sum", "params": [1, 2, 3], "id": 3}`,
+			`{"jsonrpc":"2.0","result":6,"error":null,"id":3}`,
+		},
+		{
+			`{"jsonrpc": "2.0", "method": "Arith.Div", "params": {"A": 1}, "id": 4}`,
+			`{"jsonrpc":"2.0","result":null,"error":{"code":-32000,"message":"divide by zero"},"id":4}`,
+		},
+		{
+			`{"jsonrpc": "2.0", "method": "Arith.Fail", "params": {"A": 5}, "id": 5}`,
+			`{"jsonrpc":"2.0","result":null,"error":{"code":42,"message":"failed","data":5},"id":5}`,
+		},
+		{
+			`{"jsonrpc": "2.0", "method": "Arith.Nope", "id": 6}`,
+			`{"jsonrpc":"2.0","result":null,"error":{"code":-32601,"message":"rpc: can't find method Arith.Nope"},"id":6}`,
+		},
+		{
+			`{"jsonrpc": "2.0", "method": "Arith.Add", "params": [1, 2], "id": 7}`,
+			`{"jsonrpc":"2.0","result":null,"error":{"code":-32602,"message":"jsonrpc2: too many params given by position"},"id":7}`,
+		},
+		{
+			`{"jsonrpc": "2.0", "method": "Arith.Add", "params": 1, "id": 8}`,
+			`{"jsonrpc":"2.0","result":null,"error":{"code":-32602,"message":"jsonrpc2: params must be an object or an array"},"id":8}`,
+		},
+		{
+			`{"method": "Arith.Add", "id": 9}`,
+			`{"jsonrpc":"2.0","result":null,"error":{"code":-32600,"message":"invalid request"},"id":9}`,
+		},
+		{
+			`{"jsonrpc": "2.0", "method": 1, "id": {}}`,
+			`{"jsonrpc":"2.0","result":null,"error":{"code":-32600,"message":"invalid request"},"id":null}`,
+		},
+		{
+			`[]`,
+			`{"jsonrpc":"2.0","result":null,"error":{"code":-32600,"message":"empty batch"},"id":null}`,
+		},
+	}
+	for _, tt := range tests {
+		if _, err := io.WriteString(cli, tt.req); err != nil {
+			t.Fatal(err)
+		}
+		var resp testResponse
+		if err := dec.Decode(&resp); err != nil {
+			t.Fatalf("reading response to %s: %v", tt.req, err)
+		}
+		if got := resp.String(); got != tt.want {
+			t.Errorf("response to %s:\ngot  %s\nwant %s", tt.req, got, tt.want)
+		}
+	}
+}
+
+func TestServerNotification(t *testing.T) {
+	cli, dec := rawConn(t)
+	io.WriteString(cli, `{"jsonrpc": "2.0", "method": "Service.Notify", "params": [1]}`)
+	io.WriteString(cli, `{"jsonrpc": "2.0", "method": "Arith.Nope"}`)
+	io.WriteString(cli, `{"jsonrpc": "2.0", "method": "Arith.Add", "params": {"A": 1}, "id": 1}`)
+	var resp testResponse
+	if err := dec.Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if string(resp.Id) != "1" {
+		t.Errorf("got response %s; want response to request 1 only", resp)
+	}
+	select {
+	case i := <-notified:
+		if i != 1 {
+			t.Errorf("notified with %d; want 1", i)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("notification was not served")
+	}
+}
+
+func TestServerBatch(t *testing.T) {
+	cli, dec := rawConn(t)
+	io.WriteString(cli, `[
+		{"jsonrpc": "2.0", "method": "Arith.Add", "params": {"A": 1, "B": 2}, "id": 1},
+		{"jsonrpc": "2.0", "method": "Service.Notify", "params": [2]},
+		{"jsonrpc": "2.0", "method": "Arith.Div", "params": {"A": 1}, "id": "two"},
+		1,
+		{"jsonrpc": "2.0", "method": "Arith.Nope", "id": 3}
+	]`)
+	var resps []testResponse
+	if err := dec.Decode(&resps); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, r := range resps {
+		got = append(got, r.String())
+	}
+	sort.Strings(got)
+	want := []string{
+		`{"jsonrpc":"2.0","result":null,"error":{"code":-32000,"message":"divide by zero"},"id":"two"}`,
+		`{"jsonrpc":"2.0","result":null,"error":{"code":-32600,"message":"invalid request"},"id":null}`,
+		`{"jsonrpc":"2.0","result":null,"error":{"code":-32601,"message":"rpc: can't find method Arith.Nope"},"id":3}`,
+		`{"jsonrpc":"2.0","result":{"C":3},"error":null,"id":1}`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("batch responses:\ngot  %q\nwant %q", got, want)
+	}
+	<-notified
+
+	// A batch of notifications gets no response.
+	io.WriteString(cli, `[{"jsonrpc": "2.0", "method": "Service.Notify", "params": [3]}]`)
+	io.WriteString(cli, `{"jsonrpc": "2.0", "method": "Arith.Add", "params": {"A": 1}, "id": 4}`)
+	var resp testResponse
+	if err := dec.Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if string(resp.Id) != "4" {
+		t.Errorf("got response %s; want response to request 4 only", resp)
+	}
+	<-notified
+}
+
+func TestServerParseError(t *testing.T) {
+	cli, dec := rawConn(t)
+	io.WriteString(cli, `{"jsonrpc": "2.0", "method": }`)
+	var resp testResponse
+	if err := dec.Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Error == nil || resp.Error.Code != CodeParseError || string(resp.Id) != "null" {
+		t.Errorf("got response %s; want parse error", resp)
+	}
+	if err := dec.Decode(&resp); err != io.EOF {
+		t.Errorf("after parse error: got %v; want io.EOF", err)
+	}
+}
+
+func TestClientCodec(t *testing.T) {
+	cli, srv := net.Pipe()
+	go ServeConn(srv)
+	client := rpc.NewClientWithCodec(NewClientCodec(cli))
+	defer client.Close()
+
+	var reply Reply
+	if err := client.Call("Arith.Add", &Args{7, 8}, &reply); err != nil {
+		t.Fatal(err)
+	}
+	if reply.C != 15 {
+		t.Errorf("Add: got %d; want 15", reply.C)
+	}
+	var sum int
+	if err := client.Call("Arith.Sum", []int{1, 2, 3}, &sum); err != nil || sum != 6 {
+		t.Errorf("Sum = %d, %v; want 6, nil", sum, err)
+	}
+	err := client.Call("Arith.Div", &Args{7, 0}, &reply)
+	if err != rpc.ServerError("divide by zero") {
+		t.Errorf("Div: got %v; want divide by zero", err)
+	}
+	err = client.Call("Arith.Nope", &Args{}, &reply)
+	if err != rpc.ServerError("rpc: can't find method Arith.Nope") {
+		t.Errorf("Nope: got %v; want method not found", err)
+	}
+}
+
+func newTestClient() *Client {
+	cli, srv := net.Pipe()
+	go ServeConn(srv)
+	return NewClient(cli)
+}
+
+func TestClient(t *testing.T) {
+	client := newTestClient()
+	defer client.Close()
+	ctx := context.Background()
+
+	var reply Reply
+	if err := client.Call(ctx, "Arith.Add", &Args{1, 2}, &reply); err != nil || reply.C != 3 {
+		t.Errorf("Add = %d, %v; want 3, nil", reply.C, err)
+	}
+
+	err := client.Call(ctx, "Arith.Fail", &Args{A: 5}, &reply)
+	var e *Error
+	if !errors.As(err, &e) || e.Code != 42 || e.Message != "failed" || e.Data != 5.0 {
+		t.Errorf("Fail: got %#v; want *Error with code 42 and data 5", err)
+	}
+	err = client.Call(ctx, "Arith.Div", &Args{1, 0}, &reply)
+	if !errors.As(err, &e) || e.Code != CodeServerError || e.Message != "divide by zero" {
+		t.Errorf("Div: got %#v; want server error", err)
+	}
+
+	if err := client.Notify("Service.Notify", 4); err != nil {
+		t.Fatal(err)
+	}
+	if i := <-notified; i != 4 {
+		t.Errorf("notified with %d; want 4", i)
+	}
+
+	batch := []BatchElem{
+		{Method: "Arith.Add", Params: &Args{1, 2}, Result: new(Reply)},
+		{Method: "Service.Notify", Params: 5, Notify: true},
+		{Method: "Arith.Div", Params: &Args{1, 0}, Result: new(Reply)},
+		{Method: "Arith.Sum", Params: []int{1, 2, 3}, Result: new(int)},
+	}
+	if err := client.Batch(ctx, batch); err != nil {
+		t.Fatal(err)
+	}
+	if r := batch[0].Result.(*Reply); batch[0].Error != nil || r.C != 3 {
+		t.Errorf("batch Add = %d, %v; want 3, nil", r.C, batch[0].Error)
+	}
+	if batch[1].Error != nil {
+		t.Errorf("batch notification: %v", batch[1].Error)
+	}
+	if !errors.As(batch[2].Error, &e) || e.Code != CodeServerError {
+		t.Errorf("batch Div: got %v; want server error", batch[2].Error)
+	}
+	if r := batch[3].Result.(*int); batch[3].Error != nil || *r != 6 {
+		t.Errorf("batch Sum = %d, %v; want 6, nil", *r, batch[3].Error)
+	}
+	if i := <-notified; i != 5 {
+		t.Errorf("notified with %d; want 5", i)
+	}
+}
+
+func TestClientCancel(t *testing.T) {
+	client := newTestClient()
+	defer client.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error, 1)
+	result := -1
+	go func() {
+		errc <- client.Call(ctx, "Service.Block", 1, &result)
+	}()
+	<-blocked
+	cancel()
+	if err := <-errc; err != context.Canceled {
+		t.Fatalf("Call: got %v; want %v", err, context.Canceled)
+	}
+
+	// The response to the abandoned call is discarded.
+	release <- true
+	var reply Reply
+	if err := client.Call(context.Background(), "Arith.Add", &Args{1, 2}, &reply); err != nil || reply.C != 3 {
+		t.Errorf("Add = %d, %v; want 3, nil", reply.C, err)
+	}
+	if result != -1 {
+		t.Errorf("abandoned call set its result to %d", result)
+	}
+}
+
+func TestClientClose(t *testing.T) {
+	client := newTestClient()
+	errc := make(chan error, 1)
+	go func() {
+		var i int
+		errc <- client.Call(context.Background(), "Service.Block", 1, &i)
+	}()
+	<-blocked
+	if err := client.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-errc; err != rpc.ErrShutdown {
+		t.Errorf("pending call: got %v; want %v", err, rpc.ErrShutdown)
+	}
+	if err := client.Call(context.Background(), "Arith.Add", &Args{}, nil); err != rpc.ErrShutdown {
+		t.Errorf("call after Close: got %v; want %v", err, rpc.ErrShutdown)
+	}
+	if err := client.Close(); err != rpc.ErrShutdown {
+		t.Errorf("second Close: got %v; want %v", err, rpc.ErrShutdown)
+	}
+	release <- true
+}
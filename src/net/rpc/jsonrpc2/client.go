@@ -0,0 +1,455 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package jsonrpc2 implements a JSON-RPC 2.0 ClientCodec and ServerCodec
+// for the rpc package, as well as a Client for calling arbitrary JSON-RPC
+// 2.0 servers.
+//
+// The codecs let an rpc.Server serve JSON-RPC 2.0 clients, including
+// notifications and batch requests, and let an rpc.Client call services
+// over JSON-RPC 2.0. A Client additionally sends notifications and batch
+// requests and abandons calls when their context is done.
+//
+// For JSON-RPC 1.0, see package net/rpc/jsonrpc.
+package jsonrpc2
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/rpc"
+	"sync"
+)
+
+// version is the value of the "jsonrpc" member of requests and responses.
+const version = "2.0"
+
+// firstByte returns the first non-space byte of data, or 0 if there is none.
+func firstByte(data []byte) byte {
+	for _, c := range data {
+		switch c {
+		case ' ', '\t', '\r', '\n':
+			continue
+		}
+		return c
+	}
+	return 0
+}
+
+// marshalParams returns the params member of a request for params.
+// Values that encode as JSON objects or arrays are sent as named or
+// positional params; any other value is sent as the only positional
+// param. A nil params omits the member.
+func marshalParams(params interface{}) (json.RawMessage, error) {
+	if params == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+	switch firstByte(b) {
+	case '{', '[':
+		return b, nil
+	}
+	return json.RawMessage("[" + string(b) + "]"), nil
+}
+
+type clientRequest struct {
+	Version string          `json:"jsonrpc"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Id      *uint64         `json:"id,omitempty"` // nil for notifications
+}
+
+type clientResponse struct {
+	Version string          `json:"jsonrpc"`
+	Result  json.RawMessage `json:"result"`
+	Error   *Error          `json:"error"`
+	Id      json.RawMessage `json:"id"`
+}
+
+// seq returns the id of r as set by a client, which only uses
+// non-negative integers.
+func (r *clientResponse) seq() (uint64, bool) {
+	var seq uint64
+	if firstByte(r.Id) == 'n' || json.Unmarshal(r.Id, &seq) != nil {
+		return 0, false
+	}
+	return seq, true
+}
+
+type clientCodec struct {
+	dec *json.Decoder // for reading JSON values
+	enc *json.Encoder // for writing JSON values
+	c   io.Closer
+
+	// temporary work space
+	req  clientRequest
+	resp clientResponse
+
+	// JSON-RPC responses include the request id but not the request method.
+	// Package rpc expects both.
+	// We save the request method in pending when sending a request
+	// and then look it up by request ID when filling out the rpc Response.
+	mutex   sync.Mutex        // protects pending
+	pending map[uint64]string // map request id to method name
+}
+
+// NewClientCodec returns a new rpc.ClientCodec using JSON-RPC 2.0 on conn.
+//
+// The argument of each call is sent as params as described for Client.Call.
+// An error object in a response is reported by the rpc.Client as an
+// rpc.ServerError holding its message.
+func NewClientCodec(conn io.ReadWriteCloser) rpc.ClientCodec {
+	return &clientCodec{
+		dec:     json.NewDecoder(conn),
+		enc:     json.NewEncoder(conn),
+		c:       conn,
+		pending: make(map[uint64]string),
+	}
+}
+
+func (c *clientCodec) WriteRequest(r *rpc.Request, param interface{}) error {
+	params, err := marshalParams(param)
+	if err != nil {
+		return err
+	}
+	c.mutex.Lock()
+	c.pending[r.Seq] = r.ServiceMethod
+	c.mutex.Unlock()
+	seq := r.Seq
+	c.req = clientRequest{Version: version, Method: r.ServiceMethod, Params: params, Id: &seq}
+	return c.enc.Encode(&c.req)
+}
+
+func (c *clientCodec) ReadResponseHeader(r *rpc.Response) error {
+	c.resp = clientResponse{}
+	if err := c.dec.Decode(&c.resp); err != nil {
+		return err
+	}
+	seq, ok := c.resp.seq()
+	if !ok {
+		// The server could not tell which request failed,
+		// so neither can we.
+		if c.resp.Error != nil {
+			return c.resp.Error
+		}
+		return fmt.Errorf("jsonrpc2: invalid response id %s", c.resp.Id)
+	}
+
+	c.mutex.Lock()
+	r.ServiceMethod = c.pending[seq]
+	delete(c.pending, seq)
+	c.mutex.Unlock()
+
+	r.Error = ""
+	r.Seq = seq
+	if c.resp.Error != nil || c.resp.Result == nil {
+		x := "unspecified error"
+		if c.resp.Error != nil && c.resp.Error.Message != "" {
+			x = c.resp.Error.Message
+		}
+		r.Error = x
+	}
+	return nil
+}
+
+func (c *clientCodec) ReadResponseBody(x interface{}) error {
+	if x == nil {
+		return nil
+	}
+	return json.Unmarshal(c.resp.Result, x)
+}
+
+func (c *clientCodec) Close() error {
+	return c.c.Close()
+}
+
+// A Client is a JSON-RPC 2.0 client. Unlike an rpc.Client, it can call
+// methods with any name, send notifications and batch requests, and
+// abandon calls when their context is done. A Client is safe for
+// concurrent use by multiple goroutines.
+type Client struct {
+	c   io.Closer
+	dec *json.Decoder
+
+	sending sync.Mutex // protects enc
+	enc     *json.Encoder
+
+	mutex    sync.Mutex // protects following
+	seq      uint64
+	pending  map[uint64]*clientCall
+	closing  bool // user has called Close
+	shutdown bool // the connection has failed
+}
+
+// A clientCall is a call waiting for its response.
+type clientCall struct {
+	result interface{}
+	err    error
+	done   chan struct{} // closed when the call is complete
+}
+
+// NewClient returns a new Client to handle requests to the server at
+// the other end of the connection.
+func NewClient(conn io.ReadWriteCloser) *Client {
+	c := &Client{
+		c:       conn,
+		dec:     json.NewDecoder(conn),
+		enc:     json.NewEncoder(conn),
+		pending: make(map[uint64]*clientCall),
+	}
+	go c.input()
+	return c
+}
+
+// Dial connects to a JSON-RPC 2.0 server at the specified network address.
+func Dial(network, address string) (*Client, error) {
+	conn, err := net.Dial(network, address)
+	if err != nil {
+		return nil, err
+	}
+	return NewClient(conn), nil
+}
+
+// Close closes the connection. Calls in progress fail with rpc.ErrShutdown.
+func (c *Client) Close() error {
+	c.mutex.Lock()
+	if c.closing {
+		c.mutex.Unlock()
+		return rpc.ErrShutdown
+	}
+	c.closing = true
+	c.mutex.Unlock()
+	return c.c.Close()
+}
+
+// Call calls method with params and waits for the response, unmarshaling
+// its result into result. If the server replies with an error object,
+// Call returns it as an *Error.
+//
+// Params that encode as a JSON object or array are sent as named or
+// positional params; any other value is sent as the only positional
+// param. If params is nil, the request has no params.
+//
+// If ctx is done before the response arrives, Call returns ctx.Err()
+// and the response is discarded when it arrives. JSON-RPC 2.0 has no way
+// to tell the server that a call was abandoned.
+func (c *Client) Call(ctx context.Context, method string, params, result interface{}) error {
+	req, err := newClientRequest(method, params)
+	if err != nil {
+		return err
+	}
+	call := &clientCall{result: result, done: make(chan struct{})}
+	seq, err := c.register(call)
+	if err != nil {
+		return err
+	}
+	req.Id = &seq
+	if err := c.write(req); err != nil {
+		c.unregister(seq)
+		return err
+	}
+	return c.wait(ctx, seq, call)
+}
+
+// Notify sends a notification, a request to which the server does not
+// respond, of method with params. Params are sent as for Call.
+func (c *Client) Notify(method string, params interface{}) error {
+	req, err := newClientRequest(method, params)
+	if err != nil {
+		return err
+	}
+	c.mutex.Lock()
+	stopped := c.closing || c.shutdown
+	c.mutex.Unlock()
+	if stopped {
+		return rpc.ErrShutdown
+	}
+	return c.write(req)
+}
+
+// A BatchElem is a request sent as part of a batch by Client.Batch.
+type BatchElem struct {
+	Method string
+	Params interface{}
+
+	// Result is where the result of the call is unmarshaled, as for
+	// Client.Call.
+	Result interface{}
+
+	// Notify marks the request as a notification, which gets no response.
+	Notify bool
+
+	// Error is set by Batch to the error of the call, if any.
+	Error error
+}
+
+// Batch sends the requests of batch in a single batch request and waits
+// for their responses. It sets the Result and Error of each element as
+// Call would. Batch only returns an error if the batch could not be sent.
+func (c *Client) Batch(ctx context.Context, batch []BatchElem) error {
+	if len(batch) == 0 {
+		return nil
+	}
+	reqs := make([]*clientRequest, len(batch))
+	for i := range batch {
+		req, err := newClientRequest(batch[i].Method, batch[i].Params)
+		if err != nil {
+			return err
+		}
+		reqs[i] = req
+	}
+	calls := make([]*clientCall, len(batch))
+	seqs := make([]uint64, len(batch))
+	var registered []uint64
+	for i := range batch {
+		if batch[i].Notify {
+			continue
+		}
+		call := &clientCall{result: batch[i].Result, done: make(chan struct{})}
+		seq, err := c.register(call)
+		if err != nil {
+			c.unregister(registered...)
+			return err
+		}
+		calls[i], seqs[i] = call, seq
+		reqs[i].Id = &seqs[i]
+		registered = append(registered, seq)
+	}
+	if err := c.write(reqs); err != nil {
+		c.unregister(registered...)
+		return err
+	}
+	for i, call := range calls {
+		if call != nil {
+			batch[i].Error = c.wait(ctx, seqs[i], call)
+		}
+	}
+	return nil
+}
+
+func newClientRequest(method string, params interface{}) (*clientRequest, error) {
+	p, err := marshalParams(params)
+	if err != nil {
+		return nil, err
+	}
+	return &clientRequest{Version: version, Method: method, Params: p}, nil
+}
+
+// register adds call to the pending calls and returns its id.
+func (c *Client) register(call *clientCall) (uint64, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.closing || c.shutdown {
+		return 0, rpc.ErrShutdown
+	}
+	seq := c.seq
+	c.seq++
+	c.pending[seq] = call
+	return seq, nil
+}
+
+// unregister removes the calls with the given ids from the pending
+// calls, reporting whether they were all still pending.
+func (c *Client) unregister(seqs ...uint64) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	ok := true
+	for _, seq := range seqs {
+		if _, pending := c.pending[seq]; !pending {
+			ok = false
+		}
+		delete(c.pending, seq)
+	}
+	return ok
+}
+
+func (c *Client) write(v interface{}) error {
+	c.sending.Lock()
+	defer c.sending.Unlock()
+	return c.enc.Encode(v)
+}
+
+// wait waits for call, with id seq, to complete or ctx to be done.
+func (c *Client) wait(ctx context.Context, seq uint64, call *clientCall) error {
+	select {
+	case <-call.done:
+		return call.err
+	case <-ctx.Done():
+		if c.unregister(seq) {
+			return ctx.Err()
+		}
+		// The response is being delivered.
+		<-call.done
+		return call.err
+	}
+}
+
+func (c *Client) input() {
+	var err error
+	for err == nil {
+		var data json.RawMessage
+		if err = c.dec.Decode(&data); err != nil {
+			break
+		}
+		if firstByte(data) == '[' {
+			var resps []clientResponse
+			if err = json.Unmarshal(data, &resps); err != nil {
+				break
+			}
+			for i := range resps {
+				c.deliver(&resps[i])
+			}
+			continue
+		}
+		var resp clientResponse
+		if err = json.Unmarshal(data, &resp); err != nil {
+			break
+		}
+		c.deliver(&resp)
+	}
+	// Terminate pending calls.
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.shutdown = true
+	if c.closing {
+		err = rpc.ErrShutdown
+	} else if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	for seq, call := range c.pending {
+		delete(c.pending, seq)
+		call.err = err
+		close(call.done)
+	}
+}
+
+// deliver completes the call that resp responds to, if it is pending.
+func (c *Client) deliver(resp *clientResponse) {
+	seq, ok := resp.seq()
+	if !ok {
+		// A response to a request the server could not parse;
+		// the call it belongs to is unknown.
+		return
+	}
+	c.mutex.Lock()
+	call := c.pending[seq]
+	delete(c.pending, seq)
+	c.mutex.Unlock()
+	if call == nil {
+		// The call was abandoned.
+		return
+	}
+	switch {
+	case resp.Error != nil:
+		call.err = resp.Error
+	case call.result != nil && resp.Result != nil:
+		call.err = json.Unmarshal(resp.Result, call.result)
+	}
+	close(call.done)
+}
@@ -0,0 +1,37 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package jsonrpc2
+
+import "strconv"
+
+// Error codes defined by the JSON-RPC 2.0 specification.
+const (
+	CodeParseError     = -32700 // invalid JSON was received
+	CodeInvalidRequest = -32600 // the JSON sent is not a valid request object
+	CodeMethodNotFound = -32601 // the method does not exist
+	CodeInvalidParams  = -32602 // invalid method parameters
+	CodeInternalError  = -32603 // internal JSON-RPC error
+
+	// CodeServerError is the code used by the server for errors
+	// returned by service methods that are not of type *Error.
+	// The specification reserves codes -32000 to -32099 for such
+	// implementation-defined server errors.
+	CodeServerError = -32000
+)
+
+// An Error is a JSON-RPC 2.0 error object.
+//
+// A service method may return an *Error to reply with a specific code
+// and data. Calls made with a Client return an *Error when the server
+// replies with an error object.
+type Error struct {
+	Code    int64       `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"` // additional information, if any
+}
+
+func (e *Error) Error() string {
+	return "jsonrpc2: code " + strconv.FormatInt(e.Code, 10) + ": " + e.Message
+}
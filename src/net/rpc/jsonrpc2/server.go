@@ -0,0 +1,308 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package jsonrpc2
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/rpc"
+	"reflect"
+	"sync"
+)
+
+var (
+	errInvalidParams = errors.New("jsonrpc2: params must be an object or an array")
+	errTooManyParams = errors.New("jsonrpc2: too many params given by position")
+)
+
+type serverCodec struct {
+	dec *json.Decoder // for reading JSON values
+	enc *json.Encoder // for writing JSON values
+	c   io.Closer
+
+	// temporary work space
+	req   serverRequest   // request being read
+	call  *serverCall     // call of req
+	queue []serverRequest // requests of the current batch not yet read
+	batch *serverBatch    // current batch, or nil
+
+	// JSON-RPC clients can use arbitrary json values as request IDs.
+	// Package rpc expects uint64 request IDs.
+	// We assign uint64 sequence numbers to incoming requests
+	// but save the original request ID in the pending map.
+	// When rpc responds, we use the sequence number in
+	// the response to find the original request ID.
+	mutex   sync.Mutex // protects seq, pending, batches and writes to enc
+	seq     uint64
+	pending map[uint64]*serverCall
+}
+
+// A serverCall is a request the server has not responded to yet.
+type serverCall struct {
+	id     json.RawMessage // nil for notifications
+	batch  *serverBatch    // batch the request is part of, or nil
+	errObj *Error          // error to reply with, if the params were invalid
+}
+
+// A serverBatch collects the responses to a batch of requests.
+// They are written together once all of them are known.
+type serverBatch struct {
+	remaining int // number of responses still to come
+	responses []*serverResponse
+}
+
+// NewServerCodec returns a new rpc.ServerCodec using JSON-RPC 2.0 on conn.
+//
+// The codec reads request objects and batches of them, and maps the
+// method of each request to the ServiceMethod of an rpc.Request. Params
+// given by name, as a JSON object, are unmarshaled into the argument of
+// the method. Params given by position, as a JSON array, must hold at
+// most one element, the argument, unless the argument is itself an
+// array or slice, into which the whole array is unmarshaled. Params may
+// be omitted, leaving the argument zero.
+//
+// Notifications, requests without an id, are served but not replied to.
+// The responses to a batch are written together once all of its calls
+// have completed. Errors returned by service methods are written as error
+// objects: an *Error as is, and other errors with CodeServerError.
+func NewServerCodec(conn io.ReadWriteCloser) rpc.ServerCodec {
+	return &serverCodec{
+		dec:     json.NewDecoder(conn),
+		enc:     json.NewEncoder(conn),
+		c:       conn,
+		pending: make(map[uint64]*serverCall),
+	}
+}
+
+type serverRequest struct {
+	Version string          `json:"jsonrpc"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+	Id      json.RawMessage `json:"id"`
+
+	invalid bool // the request object is malformed
+}
+
+type serverResponse struct {
+	Version string          `json:"jsonrpc"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+	Id      json.RawMessage `json:"id"`
+}
+
+// parseRequest parses a single request object.
+func parseRequest(data json.RawMessage) serverRequest {
+	var req serverRequest
+	err := json.Unmarshal(data, &req)
+	// Keep the id of a malformed request, if possible,
+	// so that the error response can refer to it.
+	switch firstByte(req.Id) {
+	case 0, '"', 'n', '-', '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+	default:
+		req.Id = nil
+		req.invalid = true
+	}
+	if err != nil || req.Version != version || req.Method == "" {
+		req.invalid = true
+	}
+	return req
+}
+
+// readBatch reads the next request or batch of requests into c.queue.
+// It replies to requests that are invalid as a whole.
+func (c *serverCodec) readBatch() error {
+	var data json.RawMessage
+	if err := c.dec.Decode(&data); err != nil {
+		if _, ok := err.(*json.SyntaxError); ok {
+			// The stream cannot be resynchronized, so reply
+			// and give up on the connection.
+			c.respond(&serverCall{}, nil, &Error{Code: CodeParseError, Message: err.Error()})
+		}
+		return err
+	}
+	c.batch = nil
+	if firstByte(data) != '[' {
+		c.queue = append(c.queue[:0], parseRequest(data))
+		return nil
+	}
+	var elems []json.RawMessage
+	if err := json.Unmarshal(data, &elems); err != nil {
+		return err
+	}
+	if len(elems) == 0 {
+		return c.respond(&serverCall{}, nil, &Error{Code: CodeInvalidRequest, Message: "empty batch"})
+	}
+	c.batch = new(serverBatch)
+	c.queue = c.queue[:0]
+	for _, elem := range elems {
+		req := parseRequest(elem)
+		if req.Id != nil || req.invalid {
+			c.batch.remaining++
+		}
+		c.queue = append(c.queue, req)
+	}
+	return nil
+}
+
+func (c *serverCodec) ReadRequestHeader(r *rpc.Request) error {
+	for {
+		for len(c.queue) == 0 {
+			if err := c.readBatch(); err != nil {
+				return err
+			}
+		}
+		c.req = c.queue[0]
+		c.queue = c.queue[1:]
+		c.call = &serverCall{id: c.req.Id, batch: c.batch}
+		if !c.req.invalid {
+			break
+		}
+		// Package rpc has no way to reply to an invalid request
+		// without reading its body, so reply here and move on.
+		if c.call.id == nil {
+			c.call.id = null
+		}
+		if err := c.respond(c.call, nil, &Error{Code: CodeInvalidRequest, Message: "invalid request"}); err != nil {
+			return err
+		}
+	}
+	r.ServiceMethod = c.req.Method
+
+	c.mutex.Lock()
+	c.seq++
+	c.pending[c.seq] = c.call
+	r.Seq = c.seq
+	c.mutex.Unlock()
+
+	return nil
+}
+
+func (c *serverCodec) ReadRequestBody(x interface{}) error {
+	if x == nil {
+		return nil
+	}
+	if err := unmarshalParams(c.req.Params, x); err != nil {
+		c.call.errObj = &Error{Code: CodeInvalidParams, Message: err.Error()}
+		return err
+	}
+	return nil
+}
+
+// unmarshalParams unmarshals the params of a request into x.
+func unmarshalParams(params json.RawMessage, x interface{}) error {
+	switch firstByte(params) {
+	case 0, 'n':
+		return nil
+	case '{':
+		return json.Unmarshal(params, x)
+	case '[':
+		var elems []json.RawMessage
+		if err := json.Unmarshal(params, &elems); err != nil {
+			return err
+		}
+		if t := reflect.TypeOf(x); t.Kind() == reflect.Ptr {
+			if k := t.Elem().Kind(); k == reflect.Slice || k == reflect.Array {
+				return json.Unmarshal(params, x)
+			}
+		}
+		switch len(elems) {
+		case 0:
+			return nil
+		case 1:
+			return json.Unmarshal(elems[0], x)
+		}
+		return errTooManyParams
+	}
+	return errInvalidParams
+}
+
+var null = json.RawMessage([]byte("null"))
+
+// takeCall removes and returns the call with sequence number seq.
+func (c *serverCodec) takeCall(seq uint64) (*serverCall, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	call, ok := c.pending[seq]
+	if !ok {
+		return nil, errors.New("invalid sequence number in response")
+	}
+	delete(c.pending, seq)
+	return call, nil
+}
+
+func (c *serverCodec) WriteResponse(r *rpc.Response, x interface{}) error {
+	call, err := c.takeCall(r.Seq)
+	if err != nil {
+		return err
+	}
+	if call.id == nil {
+		// Notifications get no response.
+		return nil
+	}
+	if r.Error != "" {
+		errObj := call.errObj
+		if errObj == nil {
+			// Errors not returned by a method nor caused by the
+			// params come from looking up the method.
+			errObj = &Error{Code: CodeMethodNotFound, Message: r.Error}
+		}
+		return c.respond(call, nil, errObj)
+	}
+	result, err := json.Marshal(x)
+	if err != nil {
+		return c.respond(call, nil, &Error{Code: CodeInternalError, Message: err.Error()})
+	}
+	return c.respond(call, result, nil)
+}
+
+// WriteError implements rpc.ServerErrorWriter.
+func (c *serverCodec) WriteError(r *rpc.Response, err error) error {
+	call, err1 := c.takeCall(r.Seq)
+	if err1 != nil {
+		return err1
+	}
+	if call.id == nil {
+		return nil
+	}
+	var errObj *Error
+	if !errors.As(err, &errObj) {
+		errObj = &Error{Code: CodeServerError, Message: err.Error()}
+	}
+	return c.respond(call, nil, errObj)
+}
+
+// respond writes the response to call, or adds it to the responses
+// of its batch.
+func (c *serverCodec) respond(call *serverCall, result json.RawMessage, errObj *Error) error {
+	resp := &serverResponse{Version: version, Result: result, Error: errObj, Id: call.id}
+	if resp.Id == nil {
+		// Invalid request so no id. Use JSON null.
+		resp.Id = null
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	b := call.batch
+	if b == nil {
+		return c.enc.Encode(resp)
+	}
+	b.responses = append(b.responses, resp)
+	b.remaining--
+	if b.remaining > 0 {
+		return nil
+	}
+	return c.enc.Encode(b.responses)
+}
+
+func (c *serverCodec) Close() error {
+	return c.c.Close()
+}
+
+// ServeConn runs the JSON-RPC 2.0 server on a single connection.
+// ServeConn blocks, serving the connection until the client hangs up.
+// The caller typically invokes ServeConn in a go statement.
+func ServeConn(conn io.ReadWriteCloser) {
+	rpc.ServeCodec(NewServerCodec(conn))
+}
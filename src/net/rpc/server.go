@@ -358,6 +358,22 @@ func (server *Server) sendResponse(sending *sync.Mutex, req *Request, reply inte
 	server.freeResponse(resp)
 }
 
+// sendError is like sendResponse but passes the error returned by a
+// method to a codec that can encode it.
+func (server *Server) sendError(sending *sync.Mutex, req *Request, codec ServerErrorWriter, err error) {
+	resp := server.getResponse()
+	resp.ServiceMethod = req.ServiceMethod
+	resp.Error = err.Error()
+	resp.Seq = req.Seq
+	sending.Lock()
+	err = codec.WriteError(resp, err)
+	if debugLog && err != nil {
+		log.Println("rpc: writing response:", err)
+	}
+	sending.Unlock()
+	server.freeResponse(resp)
+}
+
 func (m *methodType) NumCalls() (n uint) {
 	m.Lock()
 	n = m.numCalls
@@ -377,6 +393,11 @@ func (s *service) call(server *Server, sending *sync.Mutex, wg *sync.WaitGroup,
 	returnValues := function.Call([]reflect.Value{s.rcvr, argv, replyv})
 	// The return value for the method is an error.
 	errInter := returnValues[0].Interface()
+	if ew, ok := codec.(ServerErrorWriter); ok && errInter != nil {
+		server.sendError(sending, req, ew, errInter.(error))
+		server.freeRequest(req)
+		return
+	}
 	errmsg := ""
 	if errInter != nil {
 		errmsg = errInter.(error).Error()
@@ -657,6 +678,20 @@ type ServerCodec interface {
 	Close() error
 }
 
+// A ServerErrorWriter is a ServerCodec that can encode the errors
+// returned by service methods in more detail than the Error string of
+// a Response. When a method returns a non-nil error, the server calls
+// WriteError instead of WriteResponse if the codec implements
+// ServerErrorWriter. Errors detected by the server itself, such as an
+// unknown method, are still written with WriteResponse.
+type ServerErrorWriter interface {
+	ServerCodec
+
+	// WriteError writes the response to a call whose method returned
+	// err. The Error field of the Response is set to err.Error().
+	WriteError(r *Response, err error) error
+}
+
 // ServeConn runs the DefaultServer on a single connection.
 // ServeConn blocks, serving the connection until the client hangs up.
 // The caller typically invokes ServeConn in a go statement.
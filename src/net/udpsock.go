@@ -195,6 +195,81 @@ func (c *UDPConn) WriteMsgUDP(b, oob []byte, addr *UDPAddr) (n, oobn int, err er
 	return
 }
 
+// A UDPMessage is a single datagram read by ReadBatch or written by
+// WriteBatch.
+type UDPMessage struct {
+	// Buffers holds the payload. ReadBatch scatters a datagram
+	// across the buffers in order and WriteBatch gathers the
+	// payload from them.
+	Buffers [][]byte
+
+	// OOB holds the out-of-band data, as for ReadMsgUDP and
+	// WriteMsgUDP.
+	OOB []byte
+
+	// Addr is the source address of a message read by ReadBatch.
+	// For WriteBatch it is the destination address, which must be
+	// nil if the connection is connected.
+	Addr *UDPAddr
+
+	N     int // number of payload bytes read or written
+	NN    int // number of out-of-band bytes read or written
+	Flags int // flags set on a message read by ReadBatch
+
+	// SegmentSize is used for UDP segmentation offload, which is
+	// only supported on Linux. If SegmentSize is positive,
+	// WriteBatch asks the kernel to split the payload into
+	// datagrams of SegmentSize bytes each, except possibly the
+	// last (UDP GSO). ReadBatch sets SegmentSize if the kernel
+	// delivered several datagrams from the same source coalesced
+	// into one message, and sets it to 0 otherwise; this requires
+	// the UDP_GRO socket option to be enabled, for example using
+	// SyscallConn, and OOB to have room for the control message.
+	SegmentSize int
+}
+
+// ReadBatch reads up to len(ms) messages from c, blocking until
+// at least one message is available or the read deadline expires.
+// It returns the number of messages read, which have their N, NN,
+// Flags, Addr and SegmentSize fields set.
+//
+// On Linux, ReadBatch uses a single recvmmsg system call. On other
+// systems, it reads at most one message per call.
+func (c *UDPConn) ReadBatch(ms []UDPMessage) (int, error) {
+	if !c.ok() {
+		return 0, syscall.EINVAL
+	}
+	n, err := c.readBatch(ms)
+	if err != nil {
+		err = &OpError{Op: "read", Net: c.fd.net, Source: c.fd.laddr, Addr: c.fd.raddr, Err: err}
+	}
+	return n, err
+}
+
+// WriteBatch writes the messages in ms via c. Each message is sent
+// to its Addr if c isn't connected, or to c's remote address if c
+// is connected (in which case every Addr must be nil). WriteBatch
+// blocks until all the messages have been written or an error
+// occurs, such as the write deadline expiring. It returns the number
+// of messages written, which have their N and NN fields set.
+//
+// On Linux, WriteBatch uses the sendmmsg system call. On other
+// systems, it writes one message at a time.
+func (c *UDPConn) WriteBatch(ms []UDPMessage) (int, error) {
+	if !c.ok() {
+		return 0, syscall.EINVAL
+	}
+	n, err := c.writeBatch(ms)
+	if err != nil {
+		var addr Addr = c.fd.raddr
+		if n < len(ms) && ms[n].Addr != nil {
+			addr = ms[n].Addr
+		}
+		err = &OpError{Op: "write", Net: c.fd.net, Source: c.fd.laddr, Addr: addr, Err: err}
+	}
+	return n, err
+}
+
 func newUDPConn(fd *netFD) *UDPConn { return &UDPConn{conn{fd}} }
 
 // DialUDP acts like Dial for UDP networks.
@@ -0,0 +1,69 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !linux
+// +build !linux
+
+package net
+
+import "errors"
+
+var errNoSegmentationOffload = errors.New("UDP segmentation offload not supported")
+
+func (c *UDPConn) readBatch(ms []UDPMessage) (int, error) {
+	if len(ms) == 0 {
+		return 0, nil
+	}
+	m := &ms[0]
+	b := gatherBuffers(m.Buffers)
+	n, oobn, flags, addr, err := c.readMsg(b, m.OOB)
+	if err != nil {
+		return 0, err
+	}
+	if len(m.Buffers) > 1 {
+		scatterBuffers(m.Buffers, b[:n])
+	}
+	m.N, m.NN, m.Flags, m.Addr, m.SegmentSize = n, oobn, flags, addr, 0
+	return 1, nil
+}
+
+func (c *UDPConn) writeBatch(ms []UDPMessage) (int, error) {
+	for i := range ms {
+		if ms[i].SegmentSize > 0 {
+			return 0, errNoSegmentationOffload
+		}
+	}
+	for i := range ms {
+		m := &ms[i]
+		n, oobn, err := c.writeMsg(gatherBuffers(m.Buffers), m.OOB, m.Addr)
+		if err != nil {
+			return i, err
+		}
+		m.N, m.NN = n, oobn
+	}
+	return len(ms), nil
+}
+
+// gatherBuffers returns the contents of bufs as a single slice,
+// which aliases bufs[0] if there is only one buffer.
+func gatherBuffers(bufs [][]byte) []byte {
+	switch len(bufs) {
+	case 0:
+		return nil
+	case 1:
+		return bufs[0]
+	}
+	var b []byte
+	for _, buf := range bufs {
+		b = append(b, buf...)
+	}
+	return b
+}
+
+// scatterBuffers copies b into bufs in order.
+func scatterBuffers(bufs [][]byte, b []byte) {
+	for _, buf := range bufs {
+		b = b[copy(buf, b):]
+	}
+}
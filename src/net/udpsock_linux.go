@@ -0,0 +1,230 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package net
+
+import (
+	"internal/syscall/unix"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+// mmsgBuffers holds the scratch space for one ReadBatch or WriteBatch
+// call: the message headers, the socket addresses they point to and
+// their I/O vectors.
+type mmsgBuffers struct {
+	hs    []unix.Mmsghdr
+	names []syscall.RawSockaddrAny
+	iovs  []syscall.Iovec
+}
+
+var mmsgPool = sync.Pool{
+	New: func() interface{} { return new(mmsgBuffers) },
+}
+
+// getMmsgBuffers returns zeroed buffers for n messages holding
+// nbufs buffers in total.
+func getMmsgBuffers(n, nbufs int) *mmsgBuffers {
+	b := mmsgPool.Get().(*mmsgBuffers)
+	if cap(b.hs) < n {
+		b.hs = make([]unix.Mmsghdr, n)
+		b.names = make([]syscall.RawSockaddrAny, n)
+	}
+	b.hs = b.hs[:n]
+	b.names = b.names[:n]
+	if cap(b.iovs) < nbufs {
+		b.iovs = make([]syscall.Iovec, 0, nbufs)
+	}
+	b.iovs = b.iovs[:0]
+	return b
+}
+
+// putMmsgBuffers clears b, so that it does not keep the caller's
+// buffers alive, and returns it to the pool.
+func putMmsgBuffers(b *mmsgBuffers) {
+	for i := range b.hs {
+		b.hs[i] = unix.Mmsghdr{}
+	}
+	for i := range b.names {
+		b.names[i] = syscall.RawSockaddrAny{}
+	}
+	iovs := b.iovs[:cap(b.iovs)]
+	for i := range iovs {
+		iovs[i] = syscall.Iovec{}
+	}
+	mmsgPool.Put(b)
+}
+
+func (c *UDPConn) readBatch(ms []UDPMessage) (int, error) {
+	if len(ms) == 0 {
+		return 0, nil
+	}
+	b := getMmsgBuffers(len(ms), countBuffers(ms))
+	defer putMmsgBuffers(b)
+	hs, names, iovs := b.hs, b.names, b.iovs
+	for i := range ms {
+		m, h := &ms[i], &hs[i]
+		h.Hdr.Name = (*byte)(unsafe.Pointer(&names[i]))
+		h.Hdr.Namelen = syscall.SizeofSockaddrAny
+		iovs = appendIovecs(iovs, h, m.Buffers)
+		if len(m.OOB) > 0 {
+			h.Hdr.Control = &m.OOB[0]
+			h.Hdr.SetControllen(len(m.OOB))
+		}
+	}
+	n, err := c.fd.readMsgs(hs, 0)
+	for i := 0; i < n; i++ {
+		m, h := &ms[i], &hs[i]
+		m.N = int(h.Len)
+		m.NN = int(h.Hdr.Controllen)
+		m.Flags = int(h.Hdr.Flags)
+		m.Addr = rawSockaddrToUDP(&names[i])
+		m.SegmentSize = 0
+		if m.NN > 0 {
+			m.SegmentSize = parseGROSegmentSize(m.OOB[:m.NN])
+		}
+	}
+	return n, err
+}
+
+func (c *UDPConn) writeBatch(ms []UDPMessage) (int, error) {
+	if len(ms) == 0 {
+		return 0, nil
+	}
+	b := getMmsgBuffers(len(ms), countBuffers(ms))
+	defer putMmsgBuffers(b)
+	hs, names, iovs := b.hs, b.names, b.iovs
+	for i := range ms {
+		m, h := &ms[i], &hs[i]
+		if c.fd.isConnected && m.Addr != nil {
+			return 0, ErrWriteToConnected
+		}
+		if !c.fd.isConnected && m.Addr == nil {
+			return 0, errMissingAddress
+		}
+		if m.Addr != nil {
+			sa, err := m.Addr.sockaddr(c.fd.family)
+			if err != nil {
+				return 0, err
+			}
+			h.Hdr.Name = (*byte)(unsafe.Pointer(&names[i]))
+			h.Hdr.Namelen = sockaddrToRaw(sa, &names[i])
+		}
+		iovs = appendIovecs(iovs, h, m.Buffers)
+		oob := m.OOB
+		if m.SegmentSize > 0 {
+			oob = appendGSOSegmentSize(oob[:len(oob):len(oob)], m.SegmentSize)
+		}
+		if len(oob) > 0 {
+			h.Hdr.Control = &oob[0]
+			h.Hdr.SetControllen(len(oob))
+		}
+	}
+	n, err := c.fd.writeMsgs(hs, 0)
+	for i := 0; i < n; i++ {
+		ms[i].N = int(hs[i].Len)
+		ms[i].NN = len(ms[i].OOB)
+	}
+	return n, err
+}
+
+// countBuffers returns the total number of buffers in ms.
+func countBuffers(ms []UDPMessage) int {
+	n := 0
+	for i := range ms {
+		n += len(ms[i].Buffers)
+	}
+	return n
+}
+
+// appendIovecs appends the I/O vectors for bufs to iovs and points
+// the message header h at them. The capacity of iovs must be large
+// enough that the append doesn't reallocate it, since earlier headers
+// point into it.
+func appendIovecs(iovs []syscall.Iovec, h *unix.Mmsghdr, bufs [][]byte) []syscall.Iovec {
+	start := len(iovs)
+	for _, b := range bufs {
+		if len(b) == 0 {
+			continue
+		}
+		iov := syscall.Iovec{Base: &b[0]}
+		iov.SetLen(len(b))
+		iovs = append(iovs, iov)
+	}
+	if n := len(iovs) - start; n > 0 {
+		h.Hdr.Iov = &iovs[start]
+		h.SetIovlen(n)
+	}
+	return iovs
+}
+
+// sockaddrToRaw stores sa in rsa and returns its length.
+func sockaddrToRaw(sa syscall.Sockaddr, rsa *syscall.RawSockaddrAny) uint32 {
+	switch sa := sa.(type) {
+	case *syscall.SockaddrInet4:
+		raw := (*syscall.RawSockaddrInet4)(unsafe.Pointer(rsa))
+		raw.Family = syscall.AF_INET
+		p := (*[2]byte)(unsafe.Pointer(&raw.Port))
+		p[0], p[1] = byte(sa.Port>>8), byte(sa.Port)
+		raw.Addr = sa.Addr
+		return syscall.SizeofSockaddrInet4
+	case *syscall.SockaddrInet6:
+		raw := (*syscall.RawSockaddrInet6)(unsafe.Pointer(rsa))
+		raw.Family = syscall.AF_INET6
+		p := (*[2]byte)(unsafe.Pointer(&raw.Port))
+		p[0], p[1] = byte(sa.Port>>8), byte(sa.Port)
+		raw.Scope_id = sa.ZoneId
+		raw.Addr = sa.Addr
+		return syscall.SizeofSockaddrInet6
+	}
+	return 0
+}
+
+// rawSockaddrToUDP converts the source address in rsa to a UDPAddr.
+func rawSockaddrToUDP(rsa *syscall.RawSockaddrAny) *UDPAddr {
+	switch rsa.Addr.Family {
+	case syscall.AF_INET:
+		raw := (*syscall.RawSockaddrInet4)(unsafe.Pointer(rsa))
+		p := (*[2]byte)(unsafe.Pointer(&raw.Port))
+		ip := make(IP, IPv4len)
+		copy(ip, raw.Addr[:])
+		return &UDPAddr{IP: ip, Port: int(p[0])<<8 + int(p[1])}
+	case syscall.AF_INET6:
+		raw := (*syscall.RawSockaddrInet6)(unsafe.Pointer(rsa))
+		p := (*[2]byte)(unsafe.Pointer(&raw.Port))
+		ip := make(IP, IPv6len)
+		copy(ip, raw.Addr[:])
+		return &UDPAddr{IP: ip, Port: int(p[0])<<8 + int(p[1]), Zone: zoneCache.name(int(raw.Scope_id))}
+	}
+	return nil
+}
+
+// appendGSOSegmentSize appends a UDP_SEGMENT control message
+// carrying size to oob.
+func appendGSOSegmentSize(oob []byte, size int) []byte {
+	off := len(oob)
+	oob = append(oob, make([]byte, syscall.CmsgSpace(2))...)
+	h := (*syscall.Cmsghdr)(unsafe.Pointer(&oob[off]))
+	h.Level = unix.SOL_UDP
+	h.Type = unix.UDP_SEGMENT
+	h.SetLen(syscall.CmsgLen(2))
+	*(*uint16)(unsafe.Pointer(&oob[off+syscall.CmsgLen(0)])) = uint16(size)
+	return oob
+}
+
+// parseGROSegmentSize returns the segment size reported by a UDP_GRO
+// control message in oob, or 0 if there is none.
+func parseGROSegmentSize(oob []byte) int {
+	cmsgs, err := syscall.ParseSocketControlMessage(oob)
+	if err != nil {
+		return 0
+	}
+	for _, cm := range cmsgs {
+		if cm.Header.Level == unix.SOL_UDP && cm.Header.Type == unix.UDP_GRO && len(cm.Data) >= 4 {
+			return int(*(*int32)(unsafe.Pointer(&cm.Data[0])))
+		}
+	}
+	return 0
+}
@@ -0,0 +1,136 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package net
+
+import (
+	"errors"
+	"internal/race"
+	"internal/syscall/unix"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestUDPBatchSegmentationOffload(t *testing.T) {
+	c1, err := ListenUDP("udp4", &UDPAddr{IP: IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c1.Close()
+	c2, err := DialUDP("udp4", nil, c1.LocalAddr().(*UDPAddr))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c2.Close()
+
+	payload := []byte("0123456789")
+	const segSize = 4
+	write := func() {
+		t.Helper()
+		ms := []UDPMessage{{Buffers: [][]byte{payload}, SegmentSize: segSize}}
+		if _, err := c2.WriteBatch(ms); err != nil {
+			if errors.Is(err, syscall.EIO) || errors.Is(err, syscall.EINVAL) || errors.Is(err, syscall.ENOPROTOOPT) {
+				t.Skipf("UDP GSO not supported: %v", err)
+			}
+			t.Fatal(err)
+		}
+	}
+	read := func() []UDPMessage {
+		t.Helper()
+		ms := make([]UDPMessage, 4)
+		for i := range ms {
+			ms[i].Buffers = [][]byte{make([]byte, 64)}
+			ms[i].OOB = make([]byte, syscall.CmsgSpace(4))
+		}
+		c1.SetReadDeadline(time.Now().Add(5 * time.Second))
+		n, err := c1.ReadBatch(ms)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ms[:n]
+	}
+
+	// Without GRO, the receiver sees the individual datagrams.
+	write()
+	var got []string
+	for len(got) < 3 {
+		for _, m := range read() {
+			if m.SegmentSize != 0 {
+				t.Errorf("SegmentSize = %d without GRO; want 0", m.SegmentSize)
+			}
+			got = append(got, string(m.Buffers[0][:m.N]))
+		}
+	}
+	if len(got) != 3 || got[0] != "0123" || got[1] != "4567" || got[2] != "89" {
+		t.Fatalf("got datagrams %q; want %q", got, []string{"0123", "4567", "89"})
+	}
+
+	// With GRO, the datagrams are coalesced again.
+	rc, err := c1.SyscallConn()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var serr error
+	if err := rc.Control(func(fd uintptr) {
+		serr = syscall.SetsockoptInt(int(fd), unix.SOL_UDP, unix.UDP_GRO, 1)
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if serr != nil {
+		t.Skipf("UDP GRO not supported: %v", serr)
+	}
+	write()
+	ms := read()
+	if ms[0].N == segSize && ms[0].SegmentSize == 0 {
+		t.Skip("kernel did not coalesce datagrams")
+	}
+	if ms[0].N != len(payload) || ms[0].SegmentSize != segSize {
+		t.Errorf("got N = %d, SegmentSize = %d; want %d, %d", ms[0].N, ms[0].SegmentSize, len(payload), segSize)
+	}
+}
+
+func TestUDPBatchAllocs(t *testing.T) {
+	if race.Enabled {
+		t.Skip("sync.Pool drops items at random under the race detector")
+	}
+	c1, err := ListenUDP("udp4", &UDPAddr{IP: IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c1.Close()
+	c2, err := DialUDP("udp4", nil, c1.LocalAddr().(*UDPAddr))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c2.Close()
+
+	ws := []UDPMessage{
+		{Buffers: [][]byte{[]byte("HELLO"), []byte(", ")}},
+		{Buffers: [][]byte{[]byte("WORLD")}},
+	}
+	rs := make([]UDPMessage, len(ws))
+	for i := range rs {
+		rs[i].Buffers = [][]byte{make([]byte, 64)}
+	}
+	c1.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	allocs := testing.AllocsPerRun(100, func() {
+		if _, err := c2.WriteBatch(ws); err != nil {
+			t.Fatal(err)
+		}
+		for n := 0; n < len(rs); {
+			nn, err := c1.ReadBatch(rs[n:])
+			if err != nil {
+				t.Fatal(err)
+			}
+			n += nn
+		}
+	})
+	// Only the source address of each message received is allocated:
+	// its IP and its UDPAddr.
+	if want := float64(2 * len(rs)); allocs > want {
+		t.Errorf("got %v allocs; want at most %v", allocs, want)
+	}
+}
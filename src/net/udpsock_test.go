@@ -475,6 +475,109 @@ func TestUDPReadTimeout(t *testing.T) {
 	}
 }
 
+func TestUDPBatch(t *testing.T) {
+	switch runtime.GOOS {
+	case "plan9":
+		t.Skipf("not supported on %s", runtime.GOOS)
+	}
+
+	c1, err := ListenUDP("udp4", &UDPAddr{IP: IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c1.Close()
+	c2, err := ListenUDP("udp4", &UDPAddr{IP: IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c2.Close()
+	c3, err := DialUDP("udp4", nil, c1.LocalAddr().(*UDPAddr))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c3.Close()
+
+	payloads := []string{"BATCH", "", "TEST MESSAGE", "3"}
+	ws := make([]UDPMessage, len(payloads))
+	for i, p := range payloads {
+		ws[i] = UDPMessage{Buffers: [][]byte{[]byte(p[:len(p)/2]), []byte(p[len(p)/2:])}, Addr: c1.LocalAddr().(*UDPAddr)}
+	}
+	n, err := c2.WriteBatch(ws)
+	if err != nil || n != len(ws) {
+		t.Fatalf("WriteBatch = %d, %v; want %d, nil", n, err, len(ws))
+	}
+	for i, m := range ws {
+		if m.N != len(payloads[i]) {
+			t.Errorf("WriteBatch: message %d has N = %d; want %d", i, m.N, len(payloads[i]))
+		}
+	}
+
+	// Connected sockets must not specify an address.
+	if _, err := c3.WriteBatch(ws[:1]); !errors.Is(err, ErrWriteToConnected) {
+		t.Errorf("WriteBatch on connected socket with address: got %v; want %v", err, ErrWriteToConnected)
+	}
+	if _, err := c3.WriteBatch([]UDPMessage{{Buffers: [][]byte{[]byte("CONNECTED")}}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c2.WriteBatch([]UDPMessage{{Buffers: [][]byte{[]byte("NO ADDR")}}}); err == nil {
+		t.Error("WriteBatch on unconnected socket without address succeeded")
+	}
+
+	want := append(payloads, "CONNECTED")
+	var got []string
+	c1.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for len(got) < len(want) {
+		rs := make([]UDPMessage, len(want)-len(got))
+		for i := range rs {
+			rs[i].Buffers = [][]byte{make([]byte, 4), make([]byte, 32)}
+		}
+		n, err := c1.ReadBatch(rs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n == 0 {
+			t.Fatal("ReadBatch returned no messages")
+		}
+		for _, m := range rs[:n] {
+			b := append(append([]byte(nil), m.Buffers[0]...), m.Buffers[1]...)[:m.N]
+			got = append(got, string(b))
+			wantAddr := c2.LocalAddr().(*UDPAddr)
+			if len(got) == len(want) {
+				wantAddr = c3.LocalAddr().(*UDPAddr)
+			}
+			if m.Addr == nil || !m.Addr.IP.Equal(wantAddr.IP) || m.Addr.Port != wantAddr.Port {
+				t.Errorf("ReadBatch: message %q from %v; want %v", b, m.Addr, wantAddr)
+			}
+		}
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadBatch got %q; want %q", got, want)
+	}
+}
+
+func TestUDPBatchReadTimeout(t *testing.T) {
+	switch runtime.GOOS {
+	case "plan9":
+		t.Skipf("not supported on %s", runtime.GOOS)
+	}
+
+	c, err := ListenUDP("udp4", &UDPAddr{IP: IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	c.SetReadDeadline(time.Now().Add(10 * time.Millisecond))
+	ms := []UDPMessage{{Buffers: [][]byte{make([]byte, 1)}}}
+	n, err := c.ReadBatch(ms)
+	if !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Errorf("ReadBatch got err %v want os.ErrDeadlineExceeded", err)
+	}
+	if n != 0 {
+		t.Errorf("ReadBatch got n %d want 0", n)
+	}
+}
+
 func BenchmarkWriteToReadFromUDP(b *testing.B) {
 	conn, err := ListenUDP("udp4", &UDPAddr{IP: IPv4(127, 0, 0, 1)})
 	if err != nil {
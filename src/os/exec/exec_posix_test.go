@@ -8,6 +8,9 @@
 package exec_test
 
 import (
+	"context"
+	"io"
+	"os/exec"
 	"os/user"
 	"runtime"
 	"strconv"
@@ -87,3 +90,61 @@ func TestWaitid(t *testing.T) {
 
 	<-ch
 }
+
+func TestCancelSIGTERM(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cmd := helperCommandContext(t, ctx, "sleep")
+	cmd.Cancel = func() error {
+		return cmd.Process.Signal(syscall.SIGTERM)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+
+	err := cmd.Wait()
+	ee, ok := err.(*exec.ExitError)
+	if !ok {
+		t.Fatalf("Wait() = %v, want *exec.ExitError", err)
+	}
+	if ws := ee.Sys().(syscall.WaitStatus); !ws.Signaled() || ws.Signal() != syscall.SIGTERM {
+		t.Errorf("Wait() = %v, want termination by %v", err, syscall.SIGTERM)
+	}
+}
+
+func TestCancelProcessGroup(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	// The grandchild started by "leak" holds stdout open, so Wait returns
+	// promptly only if cancellation kills it along with the child.
+	cmd := helperCommandContext(t, ctx, "leak", "sleep")
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	// Make sure the grandchild has been started before canceling.
+	buf := make([]byte, len("leaked\n"))
+	if _, err := io.ReadFull(stdout, buf); err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	cancel()
+	if _, err := io.Copy(io.Discard, stdout); err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Since(start); d > 2*time.Second {
+		t.Errorf("stdout closed after %v; expected cancellation to kill the process group", d)
+	}
+	if err := cmd.Wait(); err == nil {
+		t.Error("Wait() = nil, want error after cancellation")
+	}
+}
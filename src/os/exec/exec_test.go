@@ -915,6 +915,20 @@ func TestHelperProcess(*testing.T) {
 	case "sleep":
 		time.Sleep(3 * time.Second)
 		os.Exit(0)
+	case "leak":
+		// Start a grandchild that keeps our stdout open after we exit,
+		// optionally sleeping ourselves as well.
+		cmd := exec.Command(os.Args[0], "-test.run=TestHelperProcess", "--", "sleep")
+		cmd.Stdout = os.Stdout
+		if err := cmd.Start(); err != nil {
+			fmt.Fprintf(os.Stderr, "Child: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println("leaked")
+		if len(args) > 0 && args[0] == "sleep" {
+			time.Sleep(3 * time.Second)
+		}
+		os.Exit(0)
 	case "pipehandle":
 		handle, _ := strconv.ParseUint(args[0], 16, 64)
 		pipe := os.NewFile(uintptr(handle), "")
@@ -1164,3 +1178,76 @@ func TestNoPath(t *testing.T) {
 		t.Errorf("new(Cmd).Start() = %v, want %q", err, want)
 	}
 }
+
+func TestCancelWithoutContext(t *testing.T) {
+	cmd := helperCommand(t, "echo")
+	cmd.Cancel = func() error { return nil }
+	if err := cmd.Start(); err == nil {
+		cmd.Wait()
+		t.Fatal("Start succeeded with a non-nil Cancel and no Context")
+	}
+}
+
+// Issue 23019: a grandchild holding the stdout pipe open must not make Wait
+// hang past WaitDelay.
+func TestWaitDelayLeakedPipe(t *testing.T) {
+	cmd := helperCommand(t, "leak")
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.WaitDelay = 100 * time.Millisecond
+
+	start := time.Now()
+	err := cmd.Run()
+	if err != exec.ErrWaitDelay {
+		t.Errorf("Run() = %v, want %v", err, exec.ErrWaitDelay)
+	}
+	if d := time.Since(start); d > 2*time.Second {
+		t.Errorf("Run took %v; expected it to return shortly after WaitDelay", d)
+	}
+	if got := stdout.String(); got != "leaked\n" {
+		t.Errorf("stdout = %q, want %q", got, "leaked\n")
+	}
+}
+
+func TestCancelClosesStdin(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cmd := helperCommandContext(t, ctx, "cat")
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmd.Cancel = stdin.Close
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+
+	// The command exits successfully once its stdin is closed, but since
+	// Cancel was called, Wait reports the Context error.
+	if err := cmd.Wait(); err != context.Canceled {
+		t.Errorf("Wait() = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestWaitDelayAfterCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cmd := helperCommandContext(t, ctx, "sleep")
+	// Ignore the cancellation, so that only WaitDelay stops the command.
+	cmd.Cancel = func() error { return nil }
+	cmd.WaitDelay = 50 * time.Millisecond
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+
+	start := time.Now()
+	err := cmd.Wait()
+	if d := time.Since(start); d > 2*time.Second {
+		t.Errorf("Wait took %v; expected the command to be killed after WaitDelay", d)
+	}
+	if _, ok := err.(*exec.ExitError); !ok {
+		t.Errorf("Wait() = %v, want *exec.ExitError", err)
+	}
+}
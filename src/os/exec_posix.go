@@ -52,6 +52,10 @@ func startProcess(name string, argv []string, attr *ProcAttr) (p *Process, err e
 		sysattr.Files = append(sysattr.Files, f.Fd())
 	}
 
+	// On Linux, ask for a pidfd referring to the child.
+	var needsDup bool
+	sysattr.Sys, needsDup = ensurePidfd(sysattr.Sys)
+
 	pid, h, e := syscall.StartProcess(name, argv, sysattr)
 
 	// Make sure we don't run the finalizers of attr.Files.
@@ -61,6 +65,12 @@ func startProcess(name string, argv []string, attr *ProcAttr) (p *Process, err e
 		return nil, &PathError{Op: "fork/exec", Path: name, Err: e}
 	}
 
+	// On Windows, syscall.StartProcess above already returned
+	// a process handle.
+	if runtime.GOOS != "windows" {
+		h = getPidfd(sysattr.Sys, needsDup)
+	}
+
 	return newProcess(pid, h), nil
 }
 
@@ -10,15 +10,25 @@ package os
 import (
 	"errors"
 	"runtime"
+	"sync/atomic"
 	"syscall"
 	"time"
 )
 
+// On Unix systems, Process.handle holds a pidfd referring to the
+// process (only on Linux), or 0 if there is none.
+
 func (p *Process) wait() (ps *ProcessState, err error) {
 	if p.Pid == -1 {
 		return nil, syscall.EINVAL
 	}
 
+	// A pidfd cannot refer to a different process, even if the
+	// PID has been reused, so wait on that if we have one.
+	if atomic.LoadUintptr(&p.handle) != 0 {
+		return p.pidfdWait()
+	}
+
 	// If we can block until Wait4 will succeed immediately, do so.
 	ready, err := p.blockUntilWaitable()
 	if err != nil {
@@ -76,6 +86,9 @@ func (p *Process) signal(sig Signal) error {
 	if !ok {
 		return errors.New("os: unsupported signal type")
 	}
+	if atomic.LoadUintptr(&p.handle) != 0 {
+		return p.pidfdSendSignal(s)
+	}
 	if e := syscall.Kill(p.Pid, s); e != nil {
 		if e == syscall.ESRCH {
 			return ErrProcessDone
@@ -86,16 +99,24 @@ func (p *Process) signal(sig Signal) error {
 }
 
 func (p *Process) release() error {
-	// NOOP for unix.
+	p.closeHandle()
 	p.Pid = -1
 	// no need for a finalizer anymore
 	runtime.SetFinalizer(p, nil)
 	return nil
 }
 
+// closeHandle closes the pidfd of p, if any.
+func (p *Process) closeHandle() {
+	if h := atomic.SwapUintptr(&p.handle, 0); h != 0 {
+		syscall.Close(int(h))
+	}
+}
+
 func findProcess(pid int) (p *Process, err error) {
-	// NOOP for unix.
-	return newProcess(pid, 0), nil
+	// On Unix systems, FindProcess always succeeds; on Linux it
+	// also opens a pidfd for the process, if it exists.
+	return newProcess(pid, pidfdFind(pid)), nil
 }
 
 func (p *ProcessState) userTime() time.Duration {
@@ -4,4 +4,9 @@
 
 package os
 
-var PollCopyFileRangeP = &pollCopyFileRange
+var (
+	PollCopyFileRangeP = &pollCopyFileRange
+	PidfdWorks         = pidfdWorks
+)
+
+func (p *Process) Handle() uintptr { return p.handle }
@@ -0,0 +1,259 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Support for pidfd was added during the course of a few Linux releases:
+//  v5.1: pidfd_send_signal syscall;
+//  v5.2: CLONE_PIDFD flag for clone syscall;
+//  v5.3: pidfd_open syscall;
+//  v5.4: P_PIDFD idtype support for waitid syscall.
+//
+// On older kernels, and where these system calls are filtered out,
+// processes are tracked by PID alone.
+
+package os
+
+import (
+	"errors"
+	"internal/bytealg"
+	"internal/itoa"
+	"internal/poll"
+	"internal/syscall/unix"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	_ "unsafe" // for go:linkname
+)
+
+// ensurePidfd returns a SysProcAttr that asks syscall.StartProcess to
+// return a pidfd for the child, and reports whether the pidfd has to be
+// duplicated because it belongs to the caller.
+func ensurePidfd(sysAttr *syscall.SysProcAttr) (*syscall.SysProcAttr, bool) {
+	if !pidfdWorks() {
+		return sysAttr, false
+	}
+
+	var pidfd int
+
+	if sysAttr == nil {
+		return &syscall.SysProcAttr{
+			PidFD: &pidfd,
+		}, false
+	}
+	if sysAttr.PidFD == nil {
+		newSys := *sysAttr // copy
+		newSys.PidFD = &pidfd
+		return &newSys, false
+	}
+
+	return sysAttr, true
+}
+
+// getPidfd returns the value of sysAttr.PidFD (or its duplicate if needsDup
+// is set) for use as a Process handle, or 0 if there is none.
+func getPidfd(sysAttr *syscall.SysProcAttr, needsDup bool) uintptr {
+	if sysAttr == nil || sysAttr.PidFD == nil || *sysAttr.PidFD < 0 {
+		return 0
+	}
+	h := *sysAttr.PidFD
+	if needsDup {
+		dup, _, err := poll.DupCloseOnExec(h)
+		if err != nil {
+			return 0
+		}
+		h = dup
+	}
+	return nonzeroPidfd(uintptr(h))
+}
+
+// nonzeroPidfd returns h, or a duplicate of it if h is descriptor 0, since
+// a handle of 0 means that the Process has no pidfd. It returns 0 if h must
+// be moved but cannot be.
+func nonzeroPidfd(h uintptr) uintptr {
+	if h != 0 {
+		return h
+	}
+	dup, _, err := poll.DupCloseOnExec(0)
+	syscall.Close(0)
+	if err != nil {
+		return 0
+	}
+	return uintptr(dup)
+}
+
+// pidfdFind returns a pidfd for the process with the given PID,
+// or 0 if one cannot be obtained.
+func pidfdFind(pid int) uintptr {
+	if !pidfdWorks() {
+		return 0
+	}
+	h, err := unix.PidFDOpen(pid, 0)
+	if err != nil {
+		return 0
+	}
+	return nonzeroPidfd(h)
+}
+
+// NewProcessFromPidfd returns a Process for the process referred to by
+// the Linux pidfd fd, such as one obtained from SysProcAttr.PidFD or
+// from the pidfd_open system call. The Process takes ownership of fd:
+// it is closed when the process has been waited for, or by Release.
+//
+// If fd does not refer to a running or unreaped process,
+// NewProcessFromPidfd returns an error and does not close fd.
+func NewProcessFromPidfd(fd uintptr) (*Process, error) {
+	if !pidfdWorks() {
+		return nil, NewSyscallError("pidfd_send_signal", syscall.ENOSYS)
+	}
+	pid, err := pidfdPid(fd)
+	if err != nil {
+		return nil, err
+	}
+	if fd = nonzeroPidfd(fd); fd == 0 {
+		return nil, errors.New("os: cannot move pidfd from descriptor 0")
+	}
+	return newProcess(pid, fd), nil
+}
+
+// pidfdPid returns the PID of the process referred to by pidfd,
+// as reported in its /proc/self/fdinfo entry.
+func pidfdPid(fd uintptr) (int, error) {
+	b, err := ReadFile("/proc/self/fdinfo/" + itoa.Uitoa(uint(fd)))
+	if err != nil {
+		return 0, err
+	}
+	for len(b) > 0 {
+		line := b
+		if i := bytealg.IndexByte(b, '\n'); i >= 0 {
+			line, b = b[:i], b[i+1:]
+		} else {
+			b = nil
+		}
+		if len(line) < len("Pid:") || string(line[:len("Pid:")]) != "Pid:" {
+			continue
+		}
+		s := line[len("Pid:"):]
+		for len(s) > 0 && (s[0] == ' ' || s[0] == '\t') {
+			s = s[1:]
+		}
+		if len(s) > 0 && s[0] == '-' {
+			// The process has exited and been reaped.
+			return 0, ErrProcessDone
+		}
+		if len(s) == 0 {
+			return 0, errors.New("os: malformed pidfd fdinfo: " + string(line))
+		}
+		pid := 0
+		for _, c := range s {
+			if c < '0' || c > '9' {
+				return 0, errors.New("os: malformed pidfd fdinfo: " + string(line))
+			}
+			pid = pid*10 + int(c-'0')
+		}
+		if pid == 0 {
+			return 0, ErrProcessDone
+		}
+		return pid, nil
+	}
+	return 0, errors.New("os: not a pidfd")
+}
+
+func (p *Process) pidfdWait() (*ProcessState, error) {
+	handle := atomic.LoadUintptr(&p.handle)
+	var (
+		info   unix.SiginfoChild
+		rusage syscall.Rusage
+		e      error
+	)
+	for {
+		e = unix.Waitid(unix.P_PIDFD, int(handle), &info, syscall.WEXITED, &rusage)
+		if e != syscall.EINTR {
+			break
+		}
+	}
+	if e != nil {
+		return nil, NewSyscallError("waitid", e)
+	}
+	// The process is gone; mark it done so that Process.signal does not
+	// use the pidfd, then wait for any active signal call to complete
+	// before closing it.
+	p.setDone()
+	p.sigMu.Lock()
+	p.closeHandle()
+	p.sigMu.Unlock()
+	return &ProcessState{
+		pid:    int(info.Pid),
+		status: info.WaitStatus(),
+		rusage: &rusage,
+	}, nil
+}
+
+func (p *Process) pidfdSendSignal(s syscall.Signal) error {
+	if e := unix.PidFDSendSignal(atomic.LoadUintptr(&p.handle), s); e != nil {
+		if e == syscall.ESRCH {
+			return ErrProcessDone
+		}
+		return NewSyscallError("pidfd_send_signal", e)
+	}
+	return nil
+}
+
+var (
+	pidfdOnce sync.Once
+	pidfdOK   bool
+)
+
+// pidfdWorks reports whether the kernel supports everything
+// needed to track processes by pidfd.
+func pidfdWorks() bool {
+	pidfdOnce.Do(func() {
+		pidfdOK = checkPidfd() == nil
+	})
+	return pidfdOK
+}
+
+// checkPidfd checks whether all required pidfd-related syscalls work. This
+// consists of pidfd_open and pidfd_send_signal syscalls, waitid syscall with
+// idtype of P_PIDFD, and clone(CLONE_PIDFD).
+//
+// Reasons for non-working pidfd syscalls include an older kernel and an
+// execution environment in which the above system calls are restricted by
+// seccomp or a similar technology.
+func checkPidfd() error {
+	// Get a pidfd of the current process (opening of "/proc/self" won't
+	// work for waitid).
+	fd, err := unix.PidFDOpen(syscall.Getpid(), 0)
+	if err != nil {
+		return NewSyscallError("pidfd_open", err)
+	}
+	defer syscall.Close(int(fd))
+
+	// Check waitid(P_PIDFD) works.
+	var info unix.SiginfoChild
+	for {
+		err = unix.Waitid(unix.P_PIDFD, int(fd), &info, syscall.WEXITED|syscall.WNOHANG, nil)
+		if err != syscall.EINTR {
+			break
+		}
+	}
+	// Expect ECHILD from waitid since we're not our own parent.
+	if err != syscall.ECHILD {
+		return NewSyscallError("pidfd_wait", err)
+	}
+
+	// Check pidfd_send_signal works (should be able to send 0 to itself).
+	if err := unix.PidFDSendSignal(fd, 0); err != nil {
+		return NewSyscallError("pidfd_send_signal", err)
+	}
+
+	// Verify that clone(CLONE_PIDFD) works.
+	if err := checkClonePidfd(); err != nil {
+		return NewSyscallError("clone(CLONE_PIDFD)", err)
+	}
+
+	return nil
+}
+
+// Implemented in syscall/exec_linux.go.
+//go:linkname checkClonePidfd syscall.checkClonePidfd
+func checkClonePidfd() error
@@ -0,0 +1,138 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package os_test
+
+import (
+	"errors"
+	"internal/testenv"
+	"os"
+	osexec "os/exec"
+	"syscall"
+	"testing"
+)
+
+func TestStartProcessPidfd(t *testing.T) {
+	testenv.MustHaveExec(t)
+	if !os.PidfdWorks() {
+		t.Skip("pidfd not supported")
+	}
+
+	cmd := osexec.Command(os.Args[0], "-test.run=^$")
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	if cmd.Process.Handle() == 0 {
+		t.Error("started process has no pidfd")
+	}
+	if err := cmd.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if h := cmd.Process.Handle(); h != 0 {
+		t.Errorf("pidfd %d still open after Wait", h)
+	}
+	if err := cmd.Process.Signal(os.Kill); !errors.Is(err, os.ErrProcessDone) {
+		t.Errorf("Signal after Wait = %v, want %v", err, os.ErrProcessDone)
+	}
+}
+
+func TestNewProcessFromPidfd(t *testing.T) {
+	testenv.MustHaveExec(t)
+	if !os.PidfdWorks() {
+		t.Skip("pidfd not supported")
+	}
+	sleep, err := osexec.LookPath("sleep")
+	if err != nil {
+		t.Skip("sleep not found")
+	}
+
+	pidfd := -1
+	cmd := osexec.Command(sleep, "60")
+	cmd.SysProcAttr = &syscall.SysProcAttr{PidFD: &pidfd}
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	if pidfd < 0 {
+		cmd.Process.Kill()
+		cmd.Wait()
+		t.Fatal("SysProcAttr.PidFD was not set")
+	}
+
+	// The caller keeps ownership of SysProcAttr.PidFD, so the Process
+	// must have its own copy.
+	if h := cmd.Process.Handle(); h == uintptr(pidfd) {
+		t.Errorf("Process handle %d is the caller's pidfd", h)
+	}
+
+	p, err := os.NewProcessFromPidfd(uintptr(pidfd))
+	if err != nil {
+		cmd.Process.Kill()
+		cmd.Wait()
+		t.Fatal(err)
+	}
+	if p.Pid != cmd.Process.Pid {
+		t.Errorf("Pid = %d, want %d", p.Pid, cmd.Process.Pid)
+	}
+	if err := p.Kill(); err != nil {
+		t.Fatal(err)
+	}
+	state, err := p.Wait()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ws := state.Sys().(syscall.WaitStatus); !ws.Signaled() || ws.Signal() != syscall.SIGKILL {
+		t.Errorf("process state = %v, want killed", state)
+	}
+	if state.Pid() != cmd.Process.Pid {
+		t.Errorf("ProcessState.Pid() = %d, want %d", state.Pid(), cmd.Process.Pid)
+	}
+	cmd.Process.Release()
+}
+
+func TestStartProcessPidfdFailure(t *testing.T) {
+	testenv.MustHaveExec(t)
+	exe, err := os.Executable()
+	if err != nil {
+		t.Skipf("os.Executable: %v", err)
+	}
+	for _, test := range []struct {
+		name string
+		path string
+		sys  syscall.SysProcAttr
+	}{
+		// The child fails to exec.
+		{"exec", "/nonexistent/program", syscall.SysProcAttr{}},
+		// clone itself fails, since -1 is not a cgroup descriptor.
+		{"clone", exe, syscall.SysProcAttr{UseCgroupFD: true, CgroupFD: -1}},
+	} {
+		pidfd := 12345
+		test.sys.PidFD = &pidfd
+		p, err := os.StartProcess(test.path, []string{"x"}, &os.ProcAttr{Sys: &test.sys})
+		if err == nil {
+			p.Kill()
+			p.Wait()
+			t.Errorf("%s: StartProcess succeeded unexpectedly", test.name)
+			continue
+		}
+		if pidfd != -1 {
+			t.Errorf("%s: PidFD = %d after failed start; want -1", test.name, pidfd)
+		}
+	}
+}
+
+func TestExitStatusPidfd(t *testing.T) {
+	testenv.MustHaveExec(t)
+	sh, err := osexec.LookPath("sh")
+	if err != nil {
+		t.Skip("sh not found")
+	}
+	err = osexec.Command(sh, "-c", "exit 7").Run()
+	ee, ok := err.(*osexec.ExitError)
+	if !ok {
+		t.Fatalf("Run() = %v, want *osexec.ExitError", err)
+	}
+	if code := ee.ExitCode(); code != 7 {
+		t.Errorf("ExitCode() = %d, want 7", code)
+	}
+}
@@ -0,0 +1,30 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build aix || darwin || dragonfly || freebsd || (js && wasm) || netbsd || openbsd || solaris || windows
+// +build aix darwin dragonfly freebsd js,wasm netbsd openbsd solaris windows
+
+package os
+
+import "syscall"
+
+func ensurePidfd(sysAttr *syscall.SysProcAttr) (*syscall.SysProcAttr, bool) {
+	return sysAttr, false
+}
+
+func getPidfd(_ *syscall.SysProcAttr, _ bool) uintptr {
+	return 0
+}
+
+func pidfdFind(_ int) uintptr {
+	return 0
+}
+
+func (p *Process) pidfdWait() (*ProcessState, error) {
+	panic("unreachable")
+}
+
+func (p *Process) pidfdSendSignal(_ syscall.Signal) error {
+	panic("unreachable")
+}
@@ -7310,6 +7310,249 @@ func iterateToString(it *MapIter) string {
 	return "[" + strings.Join(got, ", ") + "]"
 }
 
+func TestMapIterSet(t *testing.T) {
+	m := make(map[string]interface{}, len(valueTests))
+	for _, tt := range valueTests {
+		m[tt.s] = tt.i
+	}
+	v := ValueOf(m)
+
+	k := New(v.Type().Key()).Elem()
+	e := New(v.Type().Elem()).Elem()
+
+	iter := v.MapRange()
+	for iter.Next() {
+		k.SetIterKey(iter)
+		e.SetIterValue(iter)
+		want := m[k.String()]
+		got := e.Interface()
+		if got != want {
+			t.Errorf("%q: want (%T) %v, got (%T) %v", k.String(), want, want, got, got)
+		}
+		if setkey, key := valueToString(k), valueToString(iter.Key()); setkey != key {
+			t.Errorf("MapIter.Key() = %q, MapIter.SetKey() = %q", key, setkey)
+		}
+		if setval, val := valueToString(e), valueToString(iter.Value()); setval != val {
+			t.Errorf("MapIter.Value() = %q, MapIter.SetValue() = %q", val, setval)
+		}
+	}
+
+	// Calling MapRange should not allocate even though it returns a *MapIter.
+	// The function is inlineable, so if the local usage does not escape
+	// the *MapIter, it can remain stack allocated.
+	noAlloc(t, 10, func(int) {
+		iter := v.MapRange()
+		for iter.Next() {
+			k.SetIterKey(iter)
+			e.SetIterValue(iter)
+		}
+	})
+}
+
+func TestMapIterSetUnexported(t *testing.T) {
+	v := ValueOf(struct{ m map[string]int }{map[string]int{"one": 1}}).Field(0)
+	k := New(TypeOf("")).Elem()
+	iter := v.MapRange()
+	iter.Next()
+	shouldPanic("unexported field", func() { k.SetIterKey(iter) })
+	shouldPanic("unexported field", func() { New(TypeOf(0)).Elem().SetIterValue(iter) })
+	shouldPanic("unaddressable", func() { ValueOf("").SetIterKey(iter) })
+}
+
+func TestMapIterReset(t *testing.T) {
+	iter := new(MapIter)
+
+	// Use of zero iterator should panic.
+	func() {
+		defer func() { recover() }()
+		iter.Next()
+		t.Error("Next did not panic")
+	}()
+
+	// Reset to new Map should work.
+	m := map[string]int{"one": 1, "two": 2, "three": 3}
+	iter.Reset(ValueOf(m))
+	if got, want := iterateToString(iter), `[one: 1, three: 3, two: 2]`; got != want {
+		t.Errorf("iterator returned %s (after sorting), want %s", got, want)
+	}
+
+	// Reset to Zero value should work, but iterating over it should panic.
+	iter.Reset(Value{})
+	func() {
+		defer func() { recover() }()
+		iter.Next()
+		t.Error("Next did not panic")
+	}()
+
+	// Reset to a different Map with different types should work.
+	m2 := map[int]string{1: "one", 2: "two", 3: "three"}
+	iter.Reset(ValueOf(m2))
+	if got, want := iterateToString(iter), `[1: one, 2: two, 3: three]`; got != want {
+		t.Errorf("iterator returned %s (after sorting), want %s", got, want)
+	}
+
+	// Check that Reset, Next, and SetKey/SetValue play nicely together.
+	m3 := map[uint64]uint64{
+		1 << 0: 1 << 1,
+		1 << 1: 1 << 2,
+		1 << 2: 1 << 3,
+	}
+	kv := New(TypeOf(uint64(0))).Elem()
+	for i := 0; i < 5; i++ {
+		var seenk, seenv uint64
+		iter.Reset(ValueOf(m3))
+		for iter.Next() {
+			kv.SetIterKey(iter)
+			seenk ^= kv.Uint()
+			kv.SetIterValue(iter)
+			seenv ^= kv.Uint()
+		}
+		if seenk != 0b111 {
+			t.Errorf("iteration yielded keys %b, want %b", seenk, 0b111)
+		}
+		if seenv != 0b1110 {
+			t.Errorf("iteration yielded values %b, want %b", seenv, 0b1110)
+		}
+	}
+
+	// Reset should not allocate.
+	noAlloc(t, 10, func(int) {
+		iter.Reset(ValueOf(m2))
+		iter.Reset(Value{})
+	})
+}
+
+var valueEqualComparableTests = []struct {
+	v, u           Value
+	vDeref, uDeref bool
+	eq             bool
+}{
+	{Value{}, Value{}, false, false, true},
+	{ValueOf(true), ValueOf(false), false, false, false},
+	{ValueOf(int8(1)), ValueOf(int8(1)), false, false, true},
+	{ValueOf(int8(1)), ValueOf(int16(1)), false, false, false},
+	{ValueOf(uint(2)), ValueOf(uint(2)), false, false, true},
+	{ValueOf(3.1), ValueOf(3.1), false, false, true},
+	{ValueOf(math.NaN()), ValueOf(math.NaN()), false, false, false},
+	{ValueOf(complex(1, 2)), ValueOf(complex(1, 2)), false, false, true},
+	{ValueOf("abc"), ValueOf("abc"), false, false, true},
+	{ValueOf("abc"), ValueOf("abd"), false, false, false},
+	{ValueOf([2]int{1, 2}), ValueOf([2]int{1, 2}), false, false, true},
+	{ValueOf([2]int{1, 2}), ValueOf([2]int{1, 3}), false, false, false},
+	{ValueOf(struct{ A, B int }{1, 2}), ValueOf(struct{ A, B int }{1, 2}), false, false, true},
+	{ValueOf(struct{ A, B int }{1, 2}), ValueOf(struct{ A, B int }{1, 3}), false, false, false},
+	{ValueOf(struct {
+		A int
+		_ int
+	}{A: 1}), ValueOf(struct {
+		A int
+		_ int
+	}{A: 1}), false, false, true},
+	{ValueOf(&equalI), ValueOf(&equalI), false, false, true},
+	{ValueOf(&equalI), ValueOf(&equalJ), false, false, false},
+	{ValueOf(&equalI), ValueOf(&equalJ), true, true, true},
+	{ValueOf(&equalIface1), ValueOf(&equalIface2), true, true, true},
+	{ValueOf(&equalIface1), ValueOf(1), true, false, true},
+	{ValueOf(&equalIface1), ValueOf("1"), true, false, false},
+	{ValueOf(&equalNilIface), ValueOf(&equalNilIface), true, true, true},
+	{ValueOf(&equalNilIface), ValueOf(&equalIface1), true, true, false},
+	{ValueOf(equalChan), ValueOf(equalChan), false, false, true},
+	{ValueOf(equalChan), ValueOf(make(chan int)), false, false, false},
+	{ValueOf(unsafe.Pointer(&equalI)), ValueOf(unsafe.Pointer(&equalI)), false, false, true},
+}
+
+var (
+	equalI                    = 1
+	equalJ                    = 1
+	equalIface1   interface{} = 1
+	equalIface2   interface{} = 1
+	equalNilIface interface{}
+	equalChan     = make(chan int)
+)
+
+func TestValueEqual(t *testing.T) {
+	for _, test := range valueEqualComparableTests {
+		v, u := test.v, test.u
+		if test.vDeref {
+			v = v.Elem()
+		}
+		if test.uDeref {
+			u = u.Elem()
+		}
+		if r := v.Equal(u); r != test.eq {
+			t.Errorf("%s == %s got %t, want %t", v.Type(), u.Type(), r, test.eq)
+		}
+		if r := u.Equal(v); r != test.eq {
+			t.Errorf("%s == %s got %t, want %t", u.Type(), v.Type(), r, test.eq)
+		}
+	}
+}
+
+func TestValueEqualPanic(t *testing.T) {
+	tests := []struct {
+		v, u Value
+	}{
+		{ValueOf([]int{1}), ValueOf([]int{1})},
+		{ValueOf(map[int]int{}), ValueOf(map[int]int{})},
+		{ValueOf(func() {}), ValueOf(func() {})},
+		{ValueOf([0]func(){}), ValueOf([0]func(){})}, // elements not comparable
+		{ValueOf([]interface{}{[]int{1}}).Index(0), ValueOf([]interface{}{[]int{1}}).Index(0)},
+		{ValueOf(struct{ F func() }{}), ValueOf(struct{ F func() }{})},
+		{ValueOf(struct{ _ func() }{}), ValueOf(struct{ _ func() }{})},
+	}
+	for _, test := range tests {
+		shouldPanic("are not comparable", func() { test.v.Equal(test.u) })
+	}
+}
+
+func TestValueComparable(t *testing.T) {
+	var a int
+	var s []int
+	var i interface{} = a
+	var iSlice interface{} = s
+	var iArrayFalse interface{} = [2]interface{}{1, map[int]int{}}
+	var iArrayTrue interface{} = [2]interface{}{1, struct{ I interface{} }{1}}
+	var testcases = []struct {
+		value      Value
+		comparable bool
+		deref      bool
+	}{
+		{ValueOf(32), true, false},
+		{ValueOf(int8(1)), true, false},
+		{ValueOf(uintptr(1)), true, false},
+		{ValueOf(complex(1, 2)), true, false},
+		{ValueOf("abc"), true, false},
+		{ValueOf(equalChan), true, false},
+		{ValueOf(unsafe.Pointer(&a)), true, false},
+		{ValueOf(&a), true, false},
+		{ValueOf(s), false, false},
+		{ValueOf(map[int]int{}), false, false},
+		{ValueOf(func() {}), false, false},
+		{ValueOf(struct{}{}), true, false},
+		{ValueOf(struct{ F []int }{}), false, false},
+		{ValueOf(struct{ I interface{} }{s}), false, false},
+		{ValueOf(struct{ I interface{} }{a}), true, false},
+		{ValueOf([2]int{}), true, false},
+		{ValueOf([2][]int{}), false, false},
+		{ValueOf([2]interface{}{1, s}), false, false},
+		{ValueOf(&i), true, true},
+		{ValueOf(&iSlice), false, true},
+		{ValueOf(&iArrayFalse), false, true},
+		{ValueOf(&iArrayTrue), true, true},
+		{Value{}, false, false},
+	}
+
+	for _, cas := range testcases {
+		v := cas.value
+		if cas.deref {
+			v = v.Elem()
+		}
+		if got := v.Comparable(); got != cas.comparable {
+			t.Errorf("%v.Comparable() = %t, want %t", v, got, cas.comparable)
+		}
+	}
+}
+
 func TestConvertibleTo(t *testing.T) {
 	t1 := ValueOf(example1.MyStruct{}).Type()
 	t2 := ValueOf(example2.MyStruct{}).Type()
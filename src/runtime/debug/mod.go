@@ -21,9 +21,10 @@ func ReadBuildInfo() (info *BuildInfo, ok bool) {
 // BuildInfo represents the build information read from
 // the running binary.
 type BuildInfo struct {
-	Path string    // The main package path
-	Main Module    // The module containing the main package
-	Deps []*Module // Module dependencies
+	Path     string         // The main package path
+	Main     Module         // The module containing the main package
+	Deps     []*Module      // Module dependencies
+	Settings []BuildSetting // Other information about the build
 }
 
 // Module represents a module.
@@ -34,6 +35,13 @@ type Module struct {
 	Replace *Module // replaced by this module
 }
 
+// BuildSetting is a key-value pair describing one setting that influenced
+// a build, such as the version control system ("vcs") and revision
+// ("vcs.revision") of the main module's repository.
+type BuildSetting struct {
+	Key, Value string
+}
+
 func readBuildInfo(data string) (*BuildInfo, bool) {
 	if len(data) < 32 {
 		return nil, false
@@ -41,10 +49,11 @@ func readBuildInfo(data string) (*BuildInfo, bool) {
 	data = data[16 : len(data)-16]
 
 	const (
-		pathLine = "path\t"
-		modLine  = "mod\t"
-		depLine  = "dep\t"
-		repLine  = "=>\t"
+		pathLine  = "path\t"
+		modLine   = "mod\t"
+		depLine   = "dep\t"
+		repLine   = "=>\t"
+		buildLine = "build\t"
 	)
 
 	readEntryFirstLine := func(elem []string) (Module, bool) {
@@ -108,6 +117,13 @@ func readBuildInfo(data string) (*BuildInfo, bool) {
 				Sum:     elem[2],
 			}
 			last = nil
+		case strings.HasPrefix(line, buildLine):
+			kv := line[len(buildLine):]
+			i := strings.IndexByte(kv, '=')
+			if i < 1 {
+				return nil, false
+			}
+			info.Settings = append(info.Settings, BuildSetting{Key: kv[:i], Value: kv[i+1:]})
 		}
 	}
 	return info, true
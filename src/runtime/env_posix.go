@@ -48,10 +48,12 @@ func lowerASCII(c byte) byte {
 var _cgo_setenv unsafe.Pointer   // pointer to C function
 var _cgo_unsetenv unsafe.Pointer // pointer to C function
 
-// Update the C environment if cgo is loaded.
+// Update the C environment if cgo is loaded,
+// and tell internal/godebug about changes to $GODEBUG.
 // Called from syscall.Setenv.
 //go:linkname syscall_setenv_c syscall.setenv_c
 func syscall_setenv_c(k string, v string) {
+	godebugSetenv(k, v)
 	if _cgo_setenv == nil {
 		return
 	}
@@ -59,10 +61,12 @@ func syscall_setenv_c(k string, v string) {
 	asmcgocall(_cgo_setenv, unsafe.Pointer(&arg))
 }
 
-// Update the C environment if cgo is loaded.
-// Called from syscall.unsetenv.
+// Update the C environment if cgo is loaded,
+// and tell internal/godebug about changes to $GODEBUG.
+// Called from syscall.Unsetenv.
 //go:linkname syscall_unsetenv_c syscall.unsetenv_c
 func syscall_unsetenv_c(k string) {
+	godebugSetenv(k, "")
 	if _cgo_unsetenv == nil {
 		return
 	}
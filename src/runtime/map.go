@@ -1141,7 +1141,8 @@ func bucketEvacuated(t *maptype, h *hmap, bucket uintptr) bool {
 	return evacuated(b)
 }
 
-// map 的迭代器结构，如果修改 hiter，还要更改 cmd/compile/internal/reflect/data/reflect.go 以指示此结构的布局
+// map 的迭代器结构，如果修改 hiter，还要更改 cmd/compile/internal/reflect/data/reflect.go 以指示此结构的布局，
+// 并同步修改 reflect/value.go 中的 hiter 以匹配此结构的布局
 type hiter struct {
 	key         unsafe.Pointer // 必须首位，置为 nil 时表示到了 map 的末尾 (see cmd/compile/internal/walk/range.go).
 	elem        unsafe.Pointer // 必须第二位 (see cmd/compile/internal/walk/range.go).
@@ -1161,13 +1162,15 @@ type hiter struct {
 }
 
 // mapiterinit 初始化用于在 map 上迭代的迭代器 hiter 结构
-// “it”指向的迭代器结构由编译器顺序传递在栈上分配，或由reflect_mapiterinit在堆上分配。由于结构体包含指针，因此两者都需要有零值的迭代器
+// “it”指向的迭代器结构由编译器顺序传递在栈上分配，或由 reflect 作为 MapIter 的一部分分配。由于结构体包含指针，因此两者都需要有零值的迭代器
 func mapiterinit(t *maptype, h *hmap, it *hiter) {
 	if raceenabled && h != nil {
 		callerpc := getcallerpc()
 		racereadpc(unsafe.Pointer(h), callerpc, funcPC(mapiterinit))
 	}
 
+	// 即使 map 为空也记录类型，reflect 据此判断迭代器是否已初始化
+	it.t = t
 	// map 为空或是没有元素，直接返回
 	if h == nil || h.count == 0 {
 		return
@@ -1177,7 +1180,6 @@ func mapiterinit(t *maptype, h *hmap, it *hiter) {
 	if unsafe.Sizeof(hiter{})/sys.PtrSize != 12 {
 		throw("hash_iter size incorrect") // see cmd/compile/internal/reflectdata/reflect.go
 	}
-	it.t = t
 	it.h = h
 
 	// 抓取存储桶状态的快照
@@ -1396,10 +1398,8 @@ func reflect_mapdelete(t *maptype, h *hmap, key unsafe.Pointer) {
 }
 
 //go:linkname reflect_mapiterinit reflect.mapiterinit
-func reflect_mapiterinit(t *maptype, h *hmap) *hiter {
-	it := new(hiter)
+func reflect_mapiterinit(t *maptype, h *hmap, it *hiter) {
 	mapiterinit(t, h, it)
-	return it
 }
 
 //go:linkname reflect_mapiternext reflect.mapiternext
@@ -7,6 +7,7 @@ package runtime
 // Metrics implementation exported to runtime/metrics.
 
 import (
+	"internal/godebugs"
 	"runtime/internal/atomic"
 	"unsafe"
 )
@@ -35,6 +36,11 @@ type metricData struct {
 	// compute is a function that populates a metricValue
 	// given a populated statAggregate structure.
 	compute func(in *statAggregate, out *metricValue)
+
+	// read, if non-nil, overrides compute. It is set for metrics
+	// whose values are maintained outside the runtime, such as
+	// the counters registered by internal/godebug.
+	read func() uint64
 }
 
 func metricsLock() {
@@ -310,9 +316,38 @@ func initMetrics() {
 			},
 		},
 	}
+
+	for _, info := range godebugs.All {
+		if !info.Opaque {
+			metrics["/godebug/non-default-behavior/"+info.Name+":events"] = metricData{compute: compute0}
+		}
+	}
+
 	metricsInit = true
 }
 
+func compute0(_ *statAggregate, out *metricValue) {
+	out.kind = metricKindUint64
+	out.scalar = 0
+}
+
+// godebug_registerMetric makes read the source of the value of the
+// runtime/metrics metric name, which must be one of the placeholder
+// /godebug/non-default-behavior metrics set up by initMetrics.
+//
+//go:linkname godebug_registerMetric internal/godebug.registerMetric
+func godebug_registerMetric(name string, read func() uint64) {
+	metricsLock()
+	initMetrics()
+	d, ok := metrics[name]
+	if !ok {
+		throw("runtime: unexpected metric registration for " + name)
+	}
+	d.read = read
+	metrics[name] = d
+	metricsUnlock()
+}
+
 // statDep is a dependency on a group of statistics
 // that a metric might have.
 type statDep uint
@@ -582,6 +617,12 @@ func readMetrics(samplesp unsafe.Pointer, len int, cap int) {
 			sample.value.kind = metricKindBad
 			continue
 		}
+		if data.read != nil {
+			sample.value.kind = metricKindUint64
+			sample.value.scalar = data.read()
+			continue
+		}
+
 		// Ensure we have all the stats we need.
 		// agg is populated lazily.
 		agg.ensure(&data.deps)
@@ -140,6 +140,20 @@ var allDesc = []Description{
 		Kind:        KindFloat64Histogram,
 		Cumulative:  true,
 	},
+	{
+		Name: "/godebug/non-default-behavior/http2client:events",
+		Description: "The number of non-default behaviors executed by the net/http package " +
+			"due to a non-default GODEBUG=http2client=... setting.",
+		Kind:       KindUint64,
+		Cumulative: true,
+	},
+	{
+		Name: "/godebug/non-default-behavior/http2server:events",
+		Description: "The number of non-default behaviors executed by the net/http package " +
+			"due to a non-default GODEBUG=http2server=... setting.",
+		Kind:       KindUint64,
+		Cumulative: true,
+	},
 	{
 		Name: "/memory/classes/heap/free:bytes",
 		Description: "Memory that is completely free and eligible to be returned to the underlying system, " +
@@ -6,6 +6,7 @@ package metrics_test
 
 import (
 	"bufio"
+	"internal/godebugs"
 	"os"
 	"regexp"
 	"runtime/metrics"
@@ -113,3 +114,20 @@ func TestDescriptionDocs(t *testing.T) {
 		}
 	}
 }
+
+func TestGodebugDescriptions(t *testing.T) {
+	have := make(map[string]bool)
+	for _, d := range metrics.All() {
+		have[d.Name] = true
+	}
+	for _, info := range godebugs.All {
+		name := "/godebug/non-default-behavior/" + info.Name + ":events"
+		if have[name] == info.Opaque {
+			if info.Opaque {
+				t.Errorf("metric %s exists for opaque GODEBUG setting", name)
+			} else {
+				t.Errorf("no metric %s for GODEBUG setting %s", name, info.Name)
+			}
+		}
+	}
+}
@@ -362,7 +362,73 @@ func parsedebugvars() {
 		debug.madvdontneed = 1
 	}
 
-	for p := gogetenv("GODEBUG"); p != ""; {
+	// apply compile-time GODEBUG settings, then the environment
+	parsegodebug(godebugDefault)
+	parsegodebug(gogetenv("GODEBUG"))
+
+	debug.malloc = (debug.allocfreetrace | debug.inittrace | debug.sbrk) != 0
+
+	setTraceback(gogetenv("GOTRACEBACK"))
+	traceback_env = traceback_cache
+}
+
+// godebugDefault is the default GODEBUG setting for the program.
+// The go command computes it from the main module's go.mod and the
+// main package's //go:debug directives and passes it to the linker
+// with -X. The internal/godebug package reads it as well.
+var godebugDefault string
+
+// godebugUpdate is the *func(def, env string) registered by
+// internal/godebug, which is called with godebugDefault and
+// the current $GODEBUG whenever $GODEBUG changes.
+// It is accessed atomically.
+var godebugUpdate unsafe.Pointer
+
+// godebugEnv is the *string holding $GODEBUG as last set by
+// syscall.Setenv or syscall.Unsetenv, or nil if it has not been
+// changed since the program started. It is accessed atomically.
+var godebugEnv unsafe.Pointer
+
+//go:linkname godebug_setUpdate internal/godebug.setUpdate
+func godebug_setUpdate(update func(string, string)) {
+	p := new(func(string, string))
+	*p = update
+	atomicstorep(unsafe.Pointer(&godebugUpdate), unsafe.Pointer(p))
+	godebugNotify()
+}
+
+// godebugSetenv records that the environment variable k has been
+// set to v, and tells internal/godebug if k is GODEBUG.
+func godebugSetenv(k, v string) {
+	if k != "GODEBUG" {
+		return
+	}
+	p := new(string)
+	*p = v
+	atomicstorep(unsafe.Pointer(&godebugEnv), unsafe.Pointer(p))
+	godebugNotify()
+}
+
+// godebugNotify calls the function registered by internal/godebug,
+// if any, with the current $GODEBUG.
+func godebugNotify() {
+	update := (*func(string, string))(atomic.Loadp(unsafe.Pointer(&godebugUpdate)))
+	if update == nil {
+		return
+	}
+	var env string
+	if p := (*string)(atomic.Loadp(unsafe.Pointer(&godebugEnv))); p != nil {
+		env = *p
+	} else {
+		env = gogetenv("GODEBUG")
+	}
+	(*update)(godebugDefault, env)
+}
+
+// parsegodebug applies the runtime's settings in godebug,
+// a comma-separated list of key=value pairs.
+func parsegodebug(godebug string) {
+	for p := godebug; p != ""; {
 		field := ""
 		i := bytealg.IndexByteString(p, ',')
 		if i < 0 {
@@ -393,11 +459,6 @@ func parsedebugvars() {
 			}
 		}
 	}
-
-	debug.malloc = (debug.allocfreetrace | debug.inittrace | debug.sbrk) != 0
-
-	setTraceback(gogetenv("GOTRACEBACK"))
-	traceback_env = traceback_cache
 }
 
 //go:linkname setTraceback runtime/debug.SetTraceback
@@ -11,6 +11,11 @@ import (
 	"unsafe"
 )
 
+// setenv_c and unsetenv_c are provided by the runtime.
+// They keep internal/godebug informed of changes to $GODEBUG.
+func setenv_c(k, v string)
+func unsetenv_c(k string)
+
 func Getenv(key string) (value string, found bool) {
 	keyp, err := UTF16PtrFromString(key)
 	if err != nil {
@@ -42,6 +47,7 @@ func Setenv(key, value string) error {
 	if e != nil {
 		return e
 	}
+	setenv_c(key, value)
 	return nil
 }
 
@@ -50,7 +56,12 @@ func Unsetenv(key string) error {
 	if err != nil {
 		return err
 	}
-	return SetEnvironmentVariable(keyp, nil)
+	e := SetEnvironmentVariable(keyp, nil)
+	if e != nil {
+		return e
+	}
+	unsetenv_c(key)
+	return nil
 }
 
 func Clearenv() {
@@ -8,6 +8,7 @@
 package syscall_test
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"internal/testenv"
@@ -651,3 +652,122 @@ func testAmbientCaps(t *testing.T, userns bool) {
 		t.Fatal(err.Error())
 	}
 }
+
+// prepareCgroupFD creates a new cgroup below the current one and returns
+// an open descriptor for it along with its path as reported by
+// /proc/self/cgroup. It skips the test if cgroup v2 is not available or
+// the cgroup cannot be created.
+func prepareCgroupFD(t *testing.T) (int, string) {
+	t.Helper()
+
+	const O_PATH = 0x200000 // Same for all architectures, but for some reason not defined in syscall for 386||amd64.
+
+	// Requires cgroup v2.
+	const prefix = "/sys/fs/cgroup"
+	selfCg, err := os.ReadFile("/proc/self/cgroup")
+	if err != nil {
+		if os.IsNotExist(err) || os.IsPermission(err) {
+			t.Skip(err)
+		}
+		t.Fatal(err)
+	}
+
+	// Expect a single line like this:
+	//	0::/user.slice/user-1000.slice/user@1000.service/app.slice/vte-spawn-891992a2-efbb-4f28-aedb-b24f9e706770.scope
+	// Otherwise it's either cgroup v1 or a hybrid hierarchy.
+	if strings.Count(string(selfCg), "\n") != 1 || !strings.HasPrefix(string(selfCg), "0::") {
+		t.Skip("cgroup v2 not available")
+	}
+	cg := strings.TrimSpace(string(selfCg)[len("0::"):])
+
+	// Need an ability to create a sub-cgroup.
+	subCgroup, err := os.MkdirTemp(prefix+cg, "subcg-")
+	if err != nil {
+		if os.IsPermission(err) || os.IsNotExist(err) || err.(*os.PathError).Err == syscall.EROFS {
+			t.Skip(err)
+		}
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { syscall.Rmdir(subCgroup) })
+
+	cgroupFD, err := syscall.Open(subCgroup, O_PATH, 0)
+	if err != nil {
+		t.Fatal(&os.PathError{Op: "open", Path: subCgroup, Err: err})
+	}
+	t.Cleanup(func() { syscall.Close(cgroupFD) })
+
+	return cgroupFD, "/" + filepath.Base(subCgroup)
+}
+
+// TestUseCgroupFDHelper isn't a real test. It's used as a helper process
+// for TestUseCgroupFD.
+func TestUseCgroupFDHelper(*testing.T) {
+	if os.Getenv("GO_WANT_HELPER_PROCESS") != "1" {
+		return
+	}
+	defer os.Exit(0)
+	// Read and print own cgroup path.
+	selfCg, err := os.ReadFile("/proc/self/cgroup")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	fmt.Print(string(selfCg))
+}
+
+func TestUseCgroupFD(t *testing.T) {
+	fd, suffix := prepareCgroupFD(t)
+
+	cmd := exec.Command(os.Args[0], "-test.run=TestUseCgroupFDHelper")
+	cmd.Env = append(os.Environ(), "GO_WANT_HELPER_PROCESS=1")
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		UseCgroupFD: true,
+		CgroupFD:    fd,
+	}
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		if errors.Is(err, syscall.ENOSYS) || errors.Is(err, syscall.E2BIG) || errors.Is(err, syscall.EPERM) || errors.Is(err, syscall.EACCES) {
+			t.Skipf("clone3 with CLONE_INTO_CGROUP not usable: %v", err)
+		}
+		t.Fatalf("Cmd failed with err %v, output: %s", err, out)
+	}
+	// NB: this wouldn't work with cgroupns.
+	if !strings.HasSuffix(strings.TrimSpace(string(out)), suffix) {
+		t.Fatalf("got: %q, want: a line that ends with %q", out, suffix)
+	}
+}
+
+func TestUseCgroupFDPidFD(t *testing.T) {
+	fd, _ := prepareCgroupFD(t)
+
+	pidfd := -2
+	cmd := exec.Command(os.Args[0], "-test.run=TestUseCgroupFDHelper")
+	cmd.Env = append(os.Environ(), "GO_WANT_HELPER_PROCESS=1")
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		UseCgroupFD: true,
+		CgroupFD:    fd,
+		PidFD:       &pidfd,
+	}
+	if err := cmd.Start(); err != nil {
+		if errors.Is(err, syscall.ENOSYS) || errors.Is(err, syscall.E2BIG) || errors.Is(err, syscall.EPERM) || errors.Is(err, syscall.EACCES) {
+			t.Skipf("clone3 with CLONE_INTO_CGROUP not usable: %v", err)
+		}
+		t.Fatal(err)
+	}
+	defer cmd.Wait()
+	if pidfd < 0 {
+		t.Fatalf("PidFD = %d, want a valid descriptor", pidfd)
+	}
+	syscall.Close(pidfd)
+}
+
+func TestUseCgroupFDBadFD(t *testing.T) {
+	cmd := exec.Command(os.Args[0], "-test.run=^$")
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		UseCgroupFD: true,
+		CgroupFD:    -1,
+	}
+	if err := cmd.Run(); err == nil {
+		t.Fatal("Run with an invalid CgroupFD succeeded, want error")
+	}
+}
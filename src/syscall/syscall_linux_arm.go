@@ -12,7 +12,10 @@ import "unsafe"
 // ABI. See "man syscall". [EABI assumed.]
 const archHonorsR2 = true
 
-const _SYS_setgroups = SYS_SETGROUPS32
+const (
+	_SYS_setgroups = SYS_SETGROUPS32
+	_SYS_clone3    = 435
+)
 
 func setTimespec(sec, nsec int64) Timespec {
 	return Timespec{Sec: int32(sec), Nsec: int32(nsec)}
@@ -240,4 +243,4 @@ func (cmsg *Cmsghdr) SetLen(length int) {
 	cmsg.Len = uint32(length)
 }
 
-func rawVforkSyscall(trap, a1 uintptr) (r1 uintptr, err Errno)
+func rawVforkSyscall(trap, a1, a2, a3 uintptr) (r1 uintptr, err Errno)
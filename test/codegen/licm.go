@@ -0,0 +1,34 @@
+// asmcheck
+
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package codegen
+
+// This file contains code generation tests related to the hoisting
+// of loop-invariant loads and computations out of loops.
+
+type licmT struct {
+	k int
+	f float64
+	a []int
+}
+
+func licmLen(s *licmT) (r int) {
+	// The bound is loaded once, before the loop,
+	// instead of being compared against memory.
+	// amd64:-`CMPQ\t[0-9]*\(`
+	for i := 0; i < len(s.a); i++ {
+		r += s.a[i]
+	}
+	return
+}
+
+func licmField(s *licmT, x []float64) (r float64) {
+	for i := range x {
+		// amd64:-`MULSD\t[0-9]*\(`
+		r += x[i] * s.f
+	}
+	return
+}
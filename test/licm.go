@@ -0,0 +1,76 @@
+// +build amd64
+// errorcheck -0 -d=ssa/licm/debug=1
+
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Test that loop-invariant values are hoisted out of loops,
+// and that values that may fault only when the loop runs are not.
+
+package main
+
+type T struct {
+	k    int
+	a    []int
+	next *T
+}
+
+func f0(s *T) (r int) {
+	for i := 0; i < len(s.a); i++ { // ERROR "Hoisted (OffPtr|Load|NilCheck)$"
+		r += s.a[i]
+	}
+	return
+}
+
+func f1(s *T, a []int) (r int) {
+	for i := range a { // ERROR "Rotated loop$"
+		r += a[i] * s.k // ERROR "Hoisted (OffPtr|Load|NilCheck)$"
+	}
+	return
+}
+
+func f2(s *T, a []int) {
+	for i := range a { // ERROR "Rotated loop$"
+		a[i] = s.k // ERROR "Hoisted (OffPtr|NilCheck)$"
+	}
+}
+
+func f3(s *T, a []int) (r int) {
+	for i := range a {
+		if a[i] > 0 {
+			r += s.k
+		}
+	}
+	return
+}
+
+func f4(a []int, d int) (r int) {
+	for i := range a {
+		r += a[i] + 100/d // ERROR "Hoisted Neq64$"
+	}
+	return
+}
+
+func f5(a []int, x, y int) (r int) {
+	for i := range a {
+		r += a[i] + x*y // ERROR "Hoisted Mul64$"
+	}
+	return
+}
+
+func f6(s *T, n int) (r int) {
+	for i := 0; i < n; i++ { // ERROR "Rotated loop$"
+		r += s.k     // ERROR "Hoisted (OffPtr|Load|NilCheck)$"
+		s.next = nil // ERROR "Hoisted OffPtr$"
+	}
+	return
+}
+
+func f7(s *T, n int) (r int) {
+	for i := 0; i < n; i++ { // ERROR "Rotated loop$"
+		r += s.k // ERROR "Hoisted (OffPtr|NilCheck)$"
+		s.k = i
+	}
+	return
+}